@@ -147,7 +147,8 @@ class AIServiceHandler(ai_service_pb2_grpc.AIServiceServicer):
             max_tokens=grpc_request.settings.max_tokens,
             enable_rag=grpc_request.settings.enable_rag,
             document_sources=list(grpc_request.settings.document_sources),
-            system_prompt=grpc_request.settings.system_prompt
+            system_prompt=grpc_request.settings.system_prompt,
+            model=grpc_request.settings.model
         )
         
         return GenerateResponseRequest(
@@ -25,6 +25,7 @@ class AISettings(BaseModel):
     enable_rag: bool = True
     document_sources: List[str] = Field(default_factory=list)
     system_prompt: str = ""
+    model: str = ""
 
 class GenerateResponseRequest(BaseModel):
     session_id: str
@@ -32,7 +32,7 @@ class AIService:
             
             end_time = time.time()
             metadata = ResponseMetadata(
-                model_used=request.settings.ai_persona or settings.DEFAULT_MODEL,
+                model_used=request.settings.model or request.settings.ai_persona or settings.DEFAULT_MODEL,
                 token_count=len(response_text.split()), 
                 response_time_ms=(end_time - start_time) * 1000,
                 relevance_score=0.85,  
@@ -90,7 +90,7 @@ class AIService:
             
             end_time = time.time()
             metadata = ResponseMetadata(
-                model_used=request.settings.ai_persona or settings.DEFAULT_MODEL,
+                model_used=request.settings.model or request.settings.ai_persona or settings.DEFAULT_MODEL,
                 token_count=len(accumulated_response.split()),
                 response_time_ms=(end_time - start_time) * 1000,
                 relevance_score=0.85,
@@ -161,8 +161,9 @@ class AIService:
         if request.settings.system_prompt:
             context_parts.append(f"System: {request.settings.system_prompt}")
         
-        for message in request.conversation_history[-10:]:  
-            role = "User" if message.type == "user" else "Assistant"
+        # chat-service already trims the history to the model's token budget
+        for message in request.conversation_history:
+            role = {"user": "User", "system": "System"}.get(message.type, "Assistant")
             context_parts.append(f"{role}: {message.content}")
         
         if request.settings.enable_rag:
@@ -24,7 +24,7 @@ class OllamaService:
             response = await self.client.post(
                 f"{self.base_url}/api/generate",
                 json={
-                    "model": settings.ai_persona or settings.DEFAULT_MODEL,
+                    "model": settings.model or settings.ai_persona or settings.DEFAULT_MODEL,
                     "prompt": prompt,
                     "stream": False,
                     "options": {
@@ -69,6 +69,8 @@ class OllamaService:
 
             model_config = model_map.get(settings.ai_persona)
             model_name = model_config["model"] if model_config else "mistral:7b-instruct-v0.2-q4_K_M"
+            if settings.model:
+                model_name = settings.model
 
             print("modellellelelelelle>>>>>>>>>>>", model_name)
 
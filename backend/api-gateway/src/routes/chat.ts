@@ -752,7 +752,8 @@ router.post('/sessions/:sessionId/typing', async (req: Request, res: Response, n
 router.get('/sessions/:sessionId/typing', async (req: Request, res: Response, next: NextFunction) => {
   try {
     const response: Types.GetTypingUsersResponse = await chatServiceMethods.getTypingUsers({
-      sessionId: req.params.sessionId
+      sessionId: req.params.sessionId,
+      userId: req.user!.id
     });
 
     if (!response.success) {
@@ -182,6 +182,7 @@ export interface Session {
   id: string;
   userId: string;
   title: string;
+  titleSource?: string;
   status: string;
   settings?: SessionSettings;
   createdAt?: {
@@ -235,7 +236,7 @@ export interface MessageMetadata {
 // Session Request/Response types
 export interface CreateSessionRequest {
   userId: string;
-  title: string;
+  title?: string;
   settings?: SessionSettings;
 }
 
@@ -357,6 +358,7 @@ export interface UpdateTypingStatusRequest {
 
 export interface GetTypingUsersRequest {
   sessionId: string;
+  userId: string;
 }
 
 export interface GetTypingUsersResponse {
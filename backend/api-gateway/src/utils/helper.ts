@@ -27,7 +27,7 @@ export const updateUserSchema = Joi.object({
 
 // Validation schemas
 export const createSessionSchema = Joi.object({
-  title: Joi.string().min(1).max(200).required(),
+  title: Joi.string().max(200).allow('').default(''),
   settings: Joi.object({
     ai_persona: Joi.string().default('assistant'),
     temperature: Joi.number().min(0).max(2).default(0.7),
@@ -9,11 +9,16 @@ import (
 
 	"go.uber.org/zap"
 
+	"github.com/Sourav01112/chat-service/internal/ai/grpcclient"
 	"github.com/Sourav01112/chat-service/internal/config"
+	"github.com/Sourav01112/chat-service/internal/contextwindow"
 	"github.com/Sourav01112/chat-service/internal/grpc"
+	"github.com/Sourav01112/chat-service/internal/httpapi"
 	"github.com/Sourav01112/chat-service/internal/repository/cache"
 	"github.com/Sourav01112/chat-service/internal/repository/postgres"
 	"github.com/Sourav01112/chat-service/internal/service"
+	usersgrpc "github.com/Sourav01112/chat-service/internal/users/grpcclient"
+	"github.com/Sourav01112/chat-service/internal/worker"
 )
 
 func main() {
@@ -49,13 +54,52 @@ func main() {
 	// Initialize repositories
 	sessionRepo := postgres.NewSessionRepository(db, logger)
 	messageRepo := postgres.NewMessageRepository(db, logger)
-	cacheRepo := cache.NewCacheRepository(rdb, logger)
+	summaryRepo := postgres.NewSummaryRepository(db, logger)
+	shareLinkRepo := postgres.NewShareLinkRepository(db, logger)
+	memberRepo := postgres.NewMemberRepository(db, logger)
+	folderRepo := postgres.NewFolderRepository(db, logger)
+	tagRepo := postgres.NewTagRepository(db, logger)
+	idempotencyRepo := postgres.NewIdempotencyRepository(db, logger)
+	outboxRepo := postgres.NewOutboxRepository(db, logger)
+	cacheRepo := cache.NewCacheRepository(rdb, cfg.CacheRecentMessages, logger)
+	eventRepo := cache.NewEventRepository(rdb, cfg.EventStreamMaxLen, cfg.EventRetention, logger)
+	lockRepo := cache.NewLockRepository(rdb, logger)
+
+	// Setup AI service client
+	aiClient, err := grpcclient.NewAIClient(cfg, logger)
+	if err != nil {
+		logger.Fatal("Failed to setup AI service client", zap.Error(err))
+	}
+	defer aiClient.Close()
+
+	// Setup user service client
+	userClient, err := usersgrpc.NewUserClient(cfg, logger)
+	if err != nil {
+		logger.Fatal("Failed to setup user service client", zap.Error(err))
+	}
+	defer userClient.Close()
+
+	// Setup prompt context builder
+	contextBuilder := contextwindow.NewBuilder(contextwindow.EstimateTokenizer{}, contextwindow.Budgets{
+		Default:  cfg.AIContextTokenBudget,
+		PerModel: cfg.AIModelTokenBudgets,
+	})
 
 	// Initialize service
 	chatService := service.NewChatService(
 		sessionRepo,
 		messageRepo,
+		summaryRepo,
+		shareLinkRepo,
+		memberRepo,
+		folderRepo,
+		tagRepo,
+		idempotencyRepo,
 		cacheRepo,
+		eventRepo,
+		aiClient,
+		userClient,
+		contextBuilder,
 		cfg,
 		logger,
 	)
@@ -63,6 +107,21 @@ func main() {
 	// Initialize gRPC server
 	grpcServer := grpc.NewServer(chatService, cfg, logger)
 
+	// Initialize HTTP server
+	httpServer := httpapi.NewServer(chatService, cfg, logger)
+
+	// Initialize background job scheduler
+	scheduler := worker.NewScheduler(lockRepo, cfg, logger,
+		worker.NewIdleSessionJob(sessionRepo, cacheRepo, cfg),
+		worker.NewMessageRetentionJob(sessionRepo, messageRepo, cacheRepo, userClient, cfg, logger),
+		worker.NewTrashJob(sessionRepo, messageRepo, cfg),
+		worker.NewIdempotencyJob(idempotencyRepo, cfg),
+		worker.NewOutboxJob(outboxRepo, cfg),
+	)
+
+	// Initialize outbox relay to user-service analytics
+	outboxRelay := worker.NewOutboxRelay(outboxRepo, lockRepo, userClient, cfg, logger)
+
 	// Setup context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -75,6 +134,20 @@ func main() {
 		}
 	}()
 
+	// Start HTTP server in goroutine
+	go func() {
+		if err := httpServer.Start(ctx); err != nil {
+			logger.Error("HTTP server failed", zap.Error(err))
+			cancel()
+		}
+	}()
+
+	// Start background job scheduler in goroutine
+	go scheduler.Start(ctx)
+
+	// Start outbox relay in goroutine
+	go outboxRelay.Start(ctx)
+
 	logger.Info("Chat Service started successfully",
 		zap.String("grpc_port", cfg.GRPCPort),
 		zap.String("environment", cfg.Env),
@@ -95,6 +168,7 @@ func main() {
 	logger.Info("Shutting down Chat Service...")
 
 	grpcServer.Stop()
+	httpServer.Stop()
 
 	if err := config.CloseDatabase(db, logger); err != nil {
 		logger.Error("Error closing database", zap.Error(err))
@@ -0,0 +1,37 @@
+package ai
+
+import (
+	"context"
+
+	"github.com/Sourav01112/chat-service/internal/models"
+)
+
+type Client interface {
+	GenerateResponse(ctx context.Context, req *GenerateRequest) (*GenerateResponse, error)
+	GenerateStreamResponse(ctx context.Context, req *GenerateRequest) (ResponseStream, error)
+	Close() error
+}
+
+// ResponseStream yields content deltas until a response with IsFinal set,
+// which carries the metadata for the whole reply. Recv returns io.EOF once
+// the upstream closes the stream.
+type ResponseStream interface {
+	Recv() (*GenerateResponse, error)
+}
+
+// GenerateRequest is one prompt for the AI service. Model overrides the model
+// the AI service would pick for the persona; empty leaves the choice to it.
+type GenerateRequest struct {
+	SessionID   string
+	UserID      string
+	UserMessage string
+	History     []*models.Message
+	Settings    models.SessionSettings
+	Model       string
+}
+
+type GenerateResponse struct {
+	Content  string
+	Metadata models.MessageMetadata
+	IsFinal  bool
+}
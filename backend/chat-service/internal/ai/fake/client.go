@@ -0,0 +1,120 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/Sourav01112/chat-service/internal/ai"
+	"github.com/Sourav01112/chat-service/internal/models"
+)
+
+// Client is an in-memory stand-in for the AI service so chat flows can run
+// without a model backend. Set Reply to control what comes back; every
+// request is recorded in Requests. Streams emit Reply's content word by word,
+// and StreamErr, when set, replaces the final chunk to simulate an upstream
+// failure mid-stream.
+type Client struct {
+	mu        sync.Mutex
+	Reply     func(req *ai.GenerateRequest) (*ai.GenerateResponse, error)
+	StreamErr error
+	Requests  []*ai.GenerateRequest
+}
+
+var _ ai.Client = (*Client)(nil)
+
+func NewClient() *Client {
+	return &Client{}
+}
+
+func (c *Client) GenerateResponse(ctx context.Context, req *ai.GenerateRequest) (*ai.GenerateResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.Requests = append(c.Requests, req)
+	reply := c.Reply
+	c.mu.Unlock()
+
+	if reply != nil {
+		return reply(req)
+	}
+	return echoReply(req), nil
+}
+
+func (c *Client) GenerateStreamResponse(ctx context.Context, req *ai.GenerateRequest) (ai.ResponseStream, error) {
+	resp, err := c.GenerateResponse(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	streamErr := c.StreamErr
+	c.mu.Unlock()
+
+	var chunks []*ai.GenerateResponse
+	for i, word := range strings.Fields(resp.Content) {
+		if i > 0 {
+			word = " " + word
+		}
+		chunks = append(chunks, &ai.GenerateResponse{Content: word})
+	}
+	chunks = append(chunks, &ai.GenerateResponse{Metadata: resp.Metadata, IsFinal: true})
+
+	return &stream{ctx: ctx, chunks: chunks, err: streamErr}, nil
+}
+
+func echoReply(req *ai.GenerateRequest) *ai.GenerateResponse {
+	content := fmt.Sprintf("echo: %s", req.UserMessage)
+	return &ai.GenerateResponse{
+		Content: content,
+		Metadata: models.MessageMetadata{
+			SourceCitations: map[string]string{},
+			ModelUsed:       "fake",
+			TokenCount:      len(strings.Fields(content)),
+			Tags:            []string{"generated"},
+			ProcessingSteps: []string{"response_generation"},
+		},
+		IsFinal: true,
+	}
+}
+
+func (c *Client) Close() error {
+	return nil
+}
+
+func (c *Client) LastRequest() *ai.GenerateRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.Requests) == 0 {
+		return nil
+	}
+	return c.Requests[len(c.Requests)-1]
+}
+
+type stream struct {
+	ctx    context.Context
+	chunks []*ai.GenerateResponse
+	err    error
+}
+
+func (s *stream) Recv() (*ai.GenerateResponse, error) {
+	if err := s.ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(s.chunks) == 0 {
+		return nil, io.EOF
+	}
+	if len(s.chunks) == 1 && s.err != nil {
+		s.chunks = nil
+		return nil, s.err
+	}
+
+	chunk := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return chunk, nil
+}
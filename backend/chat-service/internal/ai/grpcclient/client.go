@@ -0,0 +1,154 @@
+package grpcclient
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/Sourav01112/chat-service/internal/ai"
+	"github.com/Sourav01112/chat-service/internal/config"
+	"github.com/Sourav01112/chat-service/internal/models"
+	aipb "github.com/Sourav01112/chat-service/proto/ai"
+)
+
+type aiClient struct {
+	conn    *grpc.ClientConn
+	client  aipb.AIServiceClient
+	timeout time.Duration
+	log     *zap.Logger
+}
+
+func NewAIClient(cfg *config.Config, log *zap.Logger) (ai.Client, error) {
+	conn, err := grpc.NewClient(cfg.AIServiceURL,
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to AI service: %w", err)
+	}
+
+	log.Info("AI service client created", zap.String("url", cfg.AIServiceURL))
+
+	return &aiClient{
+		conn:    conn,
+		client:  aipb.NewAIServiceClient(conn),
+		timeout: cfg.AIRequestTimeout,
+		log:     log,
+	}, nil
+}
+
+func (c *aiClient) GenerateResponse(ctx context.Context, req *ai.GenerateRequest) (*ai.GenerateResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := c.client.GenerateResponse(ctx, generateRequestToProto(req))
+	if err != nil {
+		c.log.Error("AI service GenerateResponse failed",
+			zap.Error(err),
+			zap.String("session_id", req.SessionID))
+		return nil, fmt.Errorf("failed to generate response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("AI service error: %s", resp.Error)
+	}
+
+	return generateResponseFromProto(resp), nil
+}
+
+func (c *aiClient) GenerateStreamResponse(ctx context.Context, req *ai.GenerateRequest) (ai.ResponseStream, error) {
+	stream, err := c.client.GenerateStreamResponse(ctx, generateRequestToProto(req))
+	if err != nil {
+		c.log.Error("AI service GenerateStreamResponse failed",
+			zap.Error(err),
+			zap.String("session_id", req.SessionID))
+		return nil, fmt.Errorf("failed to open response stream: %w", err)
+	}
+
+	return &responseStream{stream: stream}, nil
+}
+
+func (c *aiClient) Close() error {
+	return c.conn.Close()
+}
+
+type responseStream struct {
+	stream aipb.AIService_GenerateStreamResponseClient
+}
+
+func (s *responseStream) Recv() (*ai.GenerateResponse, error) {
+	resp, err := s.stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("AI service error: %s", resp.Error)
+	}
+
+	return generateResponseFromProto(resp), nil
+}
+
+func generateRequestToProto(req *ai.GenerateRequest) *aipb.GenerateResponseRequest {
+	history := make([]*aipb.ConversationMessage, len(req.History))
+	for i, message := range req.History {
+		history[i] = &aipb.ConversationMessage{
+			Id:        message.ID,
+			Content:   message.Content,
+			Type:      message.Type.String(),
+			Metadata:  messageMetadataToProto(message.Metadata),
+			CreatedAt: message.CreatedAt.UTC().Format(time.RFC3339Nano),
+		}
+	}
+
+	return &aipb.GenerateResponseRequest{
+		SessionId:           req.SessionID,
+		UserId:              req.UserID,
+		UserMessage:         req.UserMessage,
+		ConversationHistory: history,
+		Settings: &aipb.AISettings{
+			AiPersona:       req.Settings.AIPersona,
+			Temperature:     req.Settings.Temperature,
+			MaxTokens:       int32(req.Settings.MaxTokens),
+			EnableRag:       req.Settings.EnableRAG,
+			DocumentSources: req.Settings.DocumentSources,
+			SystemPrompt:    req.Settings.SystemPrompt,
+			Model:           req.Model,
+		},
+	}
+}
+
+func generateResponseFromProto(resp *aipb.GenerateResponseResponse) *ai.GenerateResponse {
+	result := &ai.GenerateResponse{
+		Content: resp.Response,
+		IsFinal: resp.IsFinal,
+	}
+
+	if resp.Metadata != nil {
+		result.Metadata = models.MessageMetadata{
+			SourceCitations: resp.Metadata.SourceCitations,
+			RelevanceScore:  resp.Metadata.RelevanceScore,
+			Tags:            resp.Metadata.Tags,
+			ModelUsed:       resp.Metadata.ModelUsed,
+			TokenCount:      int(resp.Metadata.TokenCount),
+			ResponseTimeMs:  resp.Metadata.ResponseTimeMs,
+			ProcessingSteps: resp.Metadata.ProcessingSteps,
+		}
+	}
+
+	return result
+}
+
+func messageMetadataToProto(metadata models.MessageMetadata) *aipb.MessageMetadata {
+	return &aipb.MessageMetadata{
+		SourceCitations: metadata.SourceCitations,
+		RelevanceScore:  metadata.RelevanceScore,
+		Tags:            metadata.Tags,
+		ModelUsed:       metadata.ModelUsed,
+		TokenCount:      int32(metadata.TokenCount),
+		ResponseTimeMs:  metadata.ResponseTimeMs,
+		ProcessingSteps: metadata.ProcessingSteps,
+	}
+}
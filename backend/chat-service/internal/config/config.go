@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -27,9 +28,62 @@ type Config struct {
 	CacheTTLSessions time.Duration
 	CacheTTLTyping   time.Duration
 
+	// CacheRecentMessages is how many messages at the end of a session's
+	// active branch are cached. History pages beyond them are read from the
+	// database.
+	CacheRecentMessages int
+
+	EventStreamMaxLen int64
+	EventRetention    time.Duration
+
 	MaxMessageLength      int
 	MaxMessagesPerSession int
 
+	AIServiceURL       string
+	AIRequestTimeout   time.Duration
+	AIContextMessages  int
+	StreamSaveInterval time.Duration
+
+	AIContextTokenBudget int
+	AIModelTokenBudgets  map[string]int
+
+	SummaryThresholdTokens int
+	SummaryMaxTokens       int
+	SummaryModel           string
+
+	ImportMaxBytes int
+
+	ShareLinkTTL time.Duration
+
+	TrashRetention time.Duration
+
+	// IdempotencyWindow is how long a retry with the same idempotency key
+	// gets the original result back.
+	IdempotencyWindow time.Duration
+
+	// Retention jobs run every RetentionInterval on whichever replica holds
+	// the scheduler lock. A zero age turns the matching job off; message
+	// retention can also be set per user in user-service preferences.
+	RetentionInterval      time.Duration
+	RetentionLockTTL       time.Duration
+	RetentionBatchSize     int
+	RetentionDryRun        bool
+	RetentionPauseAfter    time.Duration
+	RetentionArchiveAfter  time.Duration
+	RetentionMessageMaxAge time.Duration
+
+	// The outbox relay polls every OutboxPollInterval on whichever replica
+	// holds the outbox lock. An event that fails OutboxMaxAttempts times is
+	// given up on; delivered events are deleted after OutboxRetention.
+	OutboxPollInterval time.Duration
+	OutboxLockTTL      time.Duration
+	OutboxBatchSize    int
+	OutboxMaxAttempts  int
+	OutboxRetention    time.Duration
+
+	UserServiceURL     string
+	UserServiceTimeout time.Duration
+
 	LogLevel  string
 	LogFormat string
 }
@@ -59,9 +113,51 @@ func Load() (*Config, error) {
 		CacheTTLSessions: getEnvDuration("CACHE_TTL_SESSIONS", 24*time.Hour),
 		CacheTTLTyping:   getEnvDuration("CACHE_TTL_TYPING", 30*time.Second),
 
+		CacheRecentMessages: getEnvInt("CACHE_RECENT_MESSAGES", 200),
+
+		EventStreamMaxLen: int64(getEnvInt("EVENT_STREAM_MAX_LEN", 1000)),
+		EventRetention:    getEnvDuration("EVENT_RETENTION", 24*time.Hour),
+
 		MaxMessageLength:      getEnvInt("MAX_MESSAGE_LENGTH", 10000),
 		MaxMessagesPerSession: getEnvInt("MAX_MESSAGES_PER_SESSION", 10000),
 
+		AIServiceURL:       getEnv("AI_SERVICE_URL", "localhost:50053"),
+		AIRequestTimeout:   getEnvDuration("AI_REQUEST_TIMEOUT", 2*time.Minute),
+		AIContextMessages:  getEnvInt("AI_CONTEXT_MESSAGES", 200),
+		StreamSaveInterval: getEnvDuration("STREAM_SAVE_INTERVAL", time.Second),
+
+		AIContextTokenBudget: getEnvInt("AI_CONTEXT_TOKEN_BUDGET", 4096),
+		AIModelTokenBudgets:  getEnvIntMap("AI_MODEL_TOKEN_BUDGETS"),
+
+		SummaryThresholdTokens: getEnvInt("SUMMARY_THRESHOLD_TOKENS", 1024),
+		SummaryMaxTokens:       getEnvInt("SUMMARY_MAX_TOKENS", 512),
+		SummaryModel:           getEnv("SUMMARY_MODEL", ""),
+
+		ImportMaxBytes: getEnvInt("IMPORT_MAX_BYTES", 50*1024*1024),
+
+		ShareLinkTTL: getEnvDuration("SHARE_LINK_TTL", 30*24*time.Hour),
+
+		TrashRetention: getEnvDuration("TRASH_RETENTION", 30*24*time.Hour),
+
+		IdempotencyWindow: getEnvDuration("IDEMPOTENCY_WINDOW", 24*time.Hour),
+
+		RetentionInterval:      getEnvDuration("RETENTION_INTERVAL", time.Hour),
+		RetentionLockTTL:       getEnvDuration("RETENTION_LOCK_TTL", 5*time.Minute),
+		RetentionBatchSize:     getEnvInt("RETENTION_BATCH_SIZE", 500),
+		RetentionDryRun:        getEnvBool("RETENTION_DRY_RUN", false),
+		RetentionPauseAfter:    getEnvDuration("RETENTION_PAUSE_AFTER", 0),
+		RetentionArchiveAfter:  getEnvDuration("RETENTION_ARCHIVE_AFTER", 0),
+		RetentionMessageMaxAge: getEnvDuration("RETENTION_MESSAGE_MAX_AGE", 0),
+
+		OutboxPollInterval: getEnvDuration("OUTBOX_POLL_INTERVAL", time.Second),
+		OutboxLockTTL:      getEnvDuration("OUTBOX_LOCK_TTL", 30*time.Second),
+		OutboxBatchSize:    getEnvInt("OUTBOX_BATCH_SIZE", 100),
+		OutboxMaxAttempts:  getEnvInt("OUTBOX_MAX_ATTEMPTS", 20),
+		OutboxRetention:    getEnvDuration("OUTBOX_RETENTION", 7*24*time.Hour),
+
+		UserServiceURL:     getEnv("USER_SERVICE_URL", "localhost:50052"),
+		UserServiceTimeout: getEnvDuration("USER_SERVICE_TIMEOUT", 3*time.Second),
+
 		LogLevel:  getEnv("LOG_LEVEL", "info"),
 		LogFormat: getEnv("LOG_FORMAT", "json"),
 	}
@@ -89,6 +185,18 @@ func (c *Config) validate() error {
 	if c.DatabaseURL == "" {
 		return fmt.Errorf("DATABASE_URL is required")
 	}
+	if c.RetentionInterval > 0 && c.RetentionLockTTL <= 0 {
+		return fmt.Errorf("RETENTION_LOCK_TTL must be positive")
+	}
+	if c.OutboxPollInterval > 0 && c.OutboxLockTTL <= 0 {
+		return fmt.Errorf("OUTBOX_LOCK_TTL must be positive")
+	}
+	if c.CacheRecentMessages <= 0 {
+		return fmt.Errorf("CACHE_RECENT_MESSAGES must be positive")
+	}
+	if c.IdempotencyWindow <= 0 {
+		return fmt.Errorf("IDEMPOTENCY_WINDOW must be positive")
+	}
 	return nil
 }
 
@@ -108,6 +216,15 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
@@ -116,3 +233,19 @@ func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	}
 	return defaultValue
 }
+
+// getEnvIntMap parses "name=value" pairs separated by commas, skipping pairs
+// that do not parse.
+func getEnvIntMap(key string) map[string]int {
+	values := make(map[string]int)
+	for _, pair := range strings.Split(os.Getenv(key), ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+		if intValue, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+			values[strings.TrimSpace(name)] = intValue
+		}
+	}
+	return values
+}
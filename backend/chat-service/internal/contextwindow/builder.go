@@ -0,0 +1,230 @@
+package contextwindow
+
+import (
+	"sort"
+
+	"github.com/Sourav01112/chat-service/internal/models"
+)
+
+// messageOverhead is what each message costs on top of its content once the
+// AI service has wrapped it in role markers.
+const messageOverhead = 4
+
+// Budgets maps model names to the number of prompt tokens they accept.
+// Models without an entry get Default.
+type Budgets struct {
+	Default  int
+	PerModel map[string]int
+}
+
+func (b Budgets) For(model string) int {
+	if budget, ok := b.PerModel[model]; ok {
+		return budget
+	}
+	return b.Default
+}
+
+type Decision string
+
+const (
+	DecisionPrompt   Decision = "prompt"
+	DecisionPinned   Decision = "pinned"
+	DecisionIncluded Decision = "included"
+	DecisionDropped  Decision = "dropped"
+
+	// DecisionSummary marks the summary itself and DecisionSummarized the
+	// messages it stands in for.
+	DecisionSummary    Decision = "summary"
+	DecisionSummarized Decision = "summarized"
+)
+
+// TraceEntry records what happened to one message while the window was
+// built. The system prompt has an entry with an empty MessageID.
+type TraceEntry struct {
+	MessageID string             `json:"message_id,omitempty"`
+	Type      models.MessageType `json:"type,omitempty"`
+	Tokens    int                `json:"tokens"`
+	Decision  Decision           `json:"decision"`
+}
+
+// Input is everything the builder chooses from. History is the active branch
+// leading up to Prompt, oldest first, and may contain Prompt itself. Pinned
+// messages are kept whatever their age. Summary, when set, replaces the
+// history it covers. ReservedTokens is held back for the reply, normally the
+// session's MaxTokens, but never more than half the model's budget.
+type Input struct {
+	Model          string
+	SystemPrompt   string
+	Prompt         *models.Message
+	History        []*models.Message
+	Pinned         []*models.Message
+	Summary        *models.SessionSummary
+	ReservedTokens int
+}
+
+// Window is the history to send with a prompt, oldest first, and how it was
+// chosen. UsedTokens counts the system prompt and the prompt as well as the
+// history, so it can exceed Budget when those alone do not fit.
+type Window struct {
+	Messages   []*models.Message `json:"messages"`
+	Budget     int               `json:"budget"`
+	UsedTokens int               `json:"used_tokens"`
+	Trace      []TraceEntry      `json:"trace"`
+}
+
+// Dropped reports how many history messages did not fit and how many tokens
+// they hold.
+func (w *Window) Dropped() (int, int) {
+	dropped, tokens := 0, 0
+	for _, entry := range w.Trace {
+		if entry.Decision == DecisionDropped {
+			dropped++
+			tokens += entry.Tokens
+		}
+	}
+	return dropped, tokens
+}
+
+// NewestDropped returns the ID of the most recent history message that did
+// not fit, or "" when everything fit.
+func (w *Window) NewestDropped() string {
+	for _, entry := range w.Trace {
+		if entry.Decision == DecisionDropped {
+			return entry.MessageID
+		}
+	}
+	return ""
+}
+
+type Builder struct {
+	tokenizer Tokenizer
+	budgets   Budgets
+}
+
+func NewBuilder(tokenizer Tokenizer, budgets Budgets) *Builder {
+	return &Builder{
+		tokenizer: tokenizer,
+		budgets:   budgets,
+	}
+}
+
+// Build picks the history for a prompt. The system prompt, the prompt, the
+// summary and pinned messages are always kept; the rest of the branch after
+// the summary is then walked
+// from the newest message back and kept until the first message that does
+// not fit, so the model never sees a conversation with a hole in it.
+func (b *Builder) Build(input Input) *Window {
+	// A session may ask for replies as long as the model's whole window.
+	// Capping the reservation keeps room for recent history instead of
+	// leaving a negative budget that drops all of it.
+	budget := b.budgets.For(input.Model)
+	reserved := input.ReservedTokens
+	if reserved > budget/2 {
+		reserved = budget / 2
+	}
+	if reserved < 0 {
+		reserved = 0
+	}
+	window := &Window{Budget: budget - reserved}
+
+	if input.SystemPrompt != "" {
+		tokens := b.tokenizer.CountTokens(input.SystemPrompt) + messageOverhead
+		window.UsedTokens += tokens
+		window.Trace = append(window.Trace, TraceEntry{
+			Type:     models.MessageTypeSystem,
+			Tokens:   tokens,
+			Decision: DecisionPinned,
+		})
+	}
+
+	kept := make(map[string]bool)
+
+	if input.Prompt != nil {
+		kept[input.Prompt.ID] = true
+		window.keep(input.Prompt, b.messageTokens(input.Prompt), DecisionPrompt)
+	}
+
+	summarized := -1
+	if input.Summary != nil {
+		summarized = input.Summary.ThroughOrderIndex
+		message := SummaryMessage(input.Summary)
+		window.keep(message, b.messageTokens(message), DecisionSummary)
+		window.Messages = append(window.Messages, message)
+	}
+
+	for _, message := range input.Pinned {
+		if kept[message.ID] {
+			continue
+		}
+		kept[message.ID] = true
+		window.keep(message, b.messageTokens(message), DecisionPinned)
+		window.Messages = append(window.Messages, message)
+	}
+
+	full := false
+	for i := len(input.History) - 1; i >= 0; i-- {
+		message := input.History[i]
+		if kept[message.ID] {
+			continue
+		}
+
+		if message.OrderIndex <= summarized {
+			window.Trace = append(window.Trace, TraceEntry{
+				MessageID: message.ID,
+				Type:      message.Type,
+				Tokens:    b.messageTokens(message),
+				Decision:  DecisionSummarized,
+			})
+			continue
+		}
+
+		tokens := b.messageTokens(message)
+		if !full && window.UsedTokens+tokens <= window.Budget {
+			window.keep(message, tokens, DecisionIncluded)
+			window.Messages = append(window.Messages, message)
+			continue
+		}
+
+		full = true
+		window.Trace = append(window.Trace, TraceEntry{
+			MessageID: message.ID,
+			Type:      message.Type,
+			Tokens:    tokens,
+			Decision:  DecisionDropped,
+		})
+	}
+
+	sort.SliceStable(window.Messages, func(i, j int) bool {
+		return window.Messages[i].OrderIndex < window.Messages[j].OrderIndex
+	})
+
+	return window
+}
+
+// SummaryMessage presents a summary to the AI service as a system message
+// placed where the turns it covers would have been.
+func SummaryMessage(summary *models.SessionSummary) *models.Message {
+	return &models.Message{
+		ID:         summary.ID,
+		SessionID:  summary.SessionID,
+		Content:    "Summary of the earlier conversation:\n" + summary.Content,
+		Type:       models.MessageTypeSystem,
+		CreatedAt:  summary.CreatedAt,
+		OrderIndex: summary.ThroughOrderIndex,
+		Status:     models.MessageStatusComplete,
+	}
+}
+
+func (w *Window) keep(message *models.Message, tokens int, decision Decision) {
+	w.UsedTokens += tokens
+	w.Trace = append(w.Trace, TraceEntry{
+		MessageID: message.ID,
+		Type:      message.Type,
+		Tokens:    tokens,
+		Decision:  decision,
+	})
+}
+
+func (b *Builder) messageTokens(message *models.Message) int {
+	return b.tokenizer.CountTokens(message.Content) + messageOverhead
+}
@@ -0,0 +1,187 @@
+package contextwindow
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Sourav01112/chat-service/internal/models"
+)
+
+// wordTokenizer counts one token per word, which keeps budgets in the tests
+// easy to work out: a message costs its words plus messageOverhead.
+type wordTokenizer struct{}
+
+func (wordTokenizer) CountTokens(text string) int {
+	return len(strings.Fields(text))
+}
+
+// words returns a message of n words at orderIndex.
+func words(id string, orderIndex int, n int) *models.Message {
+	return &models.Message{
+		ID:         id,
+		Content:    strings.TrimSpace(strings.Repeat("word ", n)),
+		Type:       models.MessageTypeUser,
+		OrderIndex: orderIndex,
+	}
+}
+
+func messageIDs(messages []*models.Message) []string {
+	ids := []string{}
+	for _, message := range messages {
+		ids = append(ids, message.ID)
+	}
+	return ids
+}
+
+func decisions(window *Window) map[string]Decision {
+	out := make(map[string]Decision)
+	for _, entry := range window.Trace {
+		if entry.MessageID != "" {
+			out[entry.MessageID] = entry.Decision
+		}
+	}
+	return out
+}
+
+func TestBuild(t *testing.T) {
+	// Six history messages of 6 tokens each, then a 6-token prompt.
+	var history []*models.Message
+	for i, id := range []string{"m1", "m2", "m3", "m4", "m5", "m6"} {
+		history = append(history, words(id, i+1, 2))
+	}
+	prompt := words("prompt", 7, 2)
+
+	summary := &models.SessionSummary{ID: "summary", Content: "short", ThroughOrderIndex: 2}
+
+	tests := []struct {
+		name          string
+		budget        int
+		input         Input
+		wantMessages  []string
+		wantDecisions map[string]Decision
+		wantBudget    int
+	}{
+		{
+			name:         "everything fits",
+			budget:       100,
+			input:        Input{Prompt: prompt, History: history},
+			wantMessages: []string{"m1", "m2", "m3", "m4", "m5", "m6"},
+			wantBudget:   100,
+		},
+		{
+			name:         "oldest messages are trimmed first",
+			budget:       24,
+			input:        Input{Prompt: prompt, History: history},
+			wantMessages: []string{"m4", "m5", "m6"},
+			wantDecisions: map[string]Decision{
+				"prompt": DecisionPrompt,
+				"m1":     DecisionDropped,
+				"m2":     DecisionDropped,
+				"m3":     DecisionDropped,
+				"m4":     DecisionIncluded,
+			},
+			wantBudget: 24,
+		},
+		{
+			name:   "trimming stops at the first message that does not fit",
+			budget: 24,
+			input: Input{Prompt: prompt, History: []*models.Message{
+				words("small", 1, 1), words("large", 2, 20), words("m3", 3, 2),
+			}},
+			wantMessages: []string{"m3"},
+			wantDecisions: map[string]Decision{
+				"small": DecisionDropped,
+				"large": DecisionDropped,
+				"m3":    DecisionIncluded,
+			},
+			wantBudget: 24,
+		},
+		{
+			name:         "pinned messages come before recent history",
+			budget:       24,
+			input:        Input{Prompt: prompt, History: history, Pinned: []*models.Message{history[0]}},
+			wantMessages: []string{"m1", "m5", "m6"},
+			wantDecisions: map[string]Decision{
+				"m1": DecisionPinned,
+				"m4": DecisionDropped,
+			},
+			wantBudget: 24,
+		},
+		{
+			name:         "summary replaces the history it covers",
+			budget:       100,
+			input:        Input{Prompt: prompt, History: history, Summary: summary},
+			wantMessages: []string{"summary", "m3", "m4", "m5", "m6"},
+			wantDecisions: map[string]Decision{
+				"summary": DecisionSummary,
+				"m1":      DecisionSummarized,
+				"m2":      DecisionSummarized,
+				"m3":      DecisionIncluded,
+			},
+			wantBudget: 100,
+		},
+		{
+			name:   "summary and pinned messages are kept over budget",
+			budget: 10,
+			input: Input{
+				Prompt:  prompt,
+				History: history,
+				Pinned:  []*models.Message{history[3]},
+				Summary: summary,
+			},
+			wantMessages: []string{"summary", "m4"},
+			wantDecisions: map[string]Decision{
+				"summary": DecisionSummary,
+				"m4":      DecisionPinned,
+				"m6":      DecisionDropped,
+			},
+			wantBudget: 10,
+		},
+		{
+			name:         "reservation is capped at half the budget",
+			budget:       48,
+			input:        Input{Prompt: prompt, History: history, ReservedTokens: 1000},
+			wantMessages: []string{"m4", "m5", "m6"},
+			wantBudget:   24,
+		},
+		{
+			name:         "reservation within half the budget is kept",
+			budget:       48,
+			input:        Input{Prompt: prompt, History: history, ReservedTokens: 12},
+			wantMessages: []string{"m2", "m3", "m4", "m5", "m6"},
+			wantBudget:   36,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := NewBuilder(wordTokenizer{}, Budgets{Default: tt.budget})
+			window := builder.Build(tt.input)
+
+			if got := messageIDs(window.Messages); !reflect.DeepEqual(got, tt.wantMessages) {
+				t.Errorf("messages = %v, want %v", got, tt.wantMessages)
+			}
+			if window.Budget != tt.wantBudget {
+				t.Errorf("Budget = %d, want %d", window.Budget, tt.wantBudget)
+			}
+			got := decisions(window)
+			for id, want := range tt.wantDecisions {
+				if got[id] != want {
+					t.Errorf("decision for %s = %q, want %q", id, got[id], want)
+				}
+			}
+		})
+	}
+}
+
+func TestBudgetsFor(t *testing.T) {
+	budgets := Budgets{Default: 100, PerModel: map[string]int{"large": 1000}}
+
+	if got := budgets.For("large"); got != 1000 {
+		t.Errorf("For(large) = %d, want 1000", got)
+	}
+	if got := budgets.For("unknown"); got != 100 {
+		t.Errorf("For(unknown) = %d, want 100", got)
+	}
+}
@@ -0,0 +1,68 @@
+package contextwindow
+
+import (
+	"github.com/Sourav01112/chat-service/internal/models"
+)
+
+// Budget returns the number of prompt tokens model accepts.
+func (b *Builder) Budget(model string) int {
+	return b.budgets.For(model)
+}
+
+// CountTokens counts text the way Build does.
+func (b *Builder) CountTokens(text string) int {
+	return b.tokenizer.CountTokens(text)
+}
+
+// MessageTokens is what message costs in a prompt.
+func (b *Builder) MessageTokens(message *models.Message) int {
+	return b.messageTokens(message)
+}
+
+// Chunks splits messages, oldest first, into consecutive runs that each fit
+// in budget tokens, for work that has to see every message but can only send
+// so many at once. A message that does not fit on its own is cut short.
+func (b *Builder) Chunks(messages []*models.Message, budget int) [][]*models.Message {
+	var chunks [][]*models.Message
+	var chunk []*models.Message
+	used := 0
+
+	for _, message := range messages {
+		tokens := b.messageTokens(message)
+		if tokens > budget {
+			message = b.truncate(message, budget)
+			tokens = b.messageTokens(message)
+		}
+
+		if len(chunk) > 0 && used+tokens > budget {
+			chunks = append(chunks, chunk)
+			chunk, used = nil, 0
+		}
+		chunk = append(chunk, message)
+		used += tokens
+	}
+
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// truncate returns a copy of message with as much of its content as fits in
+// budget tokens.
+func (b *Builder) truncate(message *models.Message, budget int) *models.Message {
+	content := []rune(message.Content)
+	low, high := 0, len(content)
+	for low < high {
+		mid := (low + high + 1) / 2
+		if b.tokenizer.CountTokens(string(content[:mid]))+messageOverhead <= budget {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+
+	truncated := *message
+	truncated.Content = string(content[:low])
+	return &truncated
+}
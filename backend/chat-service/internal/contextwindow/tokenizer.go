@@ -0,0 +1,32 @@
+package contextwindow
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Tokenizer counts how many model tokens a piece of text takes up. Counts
+// only need to be close enough to keep prompts inside the model's window, so
+// an estimate is fine where no real tokenizer is available.
+type Tokenizer interface {
+	CountTokens(text string) int
+}
+
+// EstimateTokenizer approximates token counts without a vocabulary: roughly
+// four characters per token for English text, and never fewer tokens than
+// words.
+type EstimateTokenizer struct{}
+
+var _ Tokenizer = EstimateTokenizer{}
+
+func (EstimateTokenizer) CountTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+
+	byChars := (utf8.RuneCountInString(text) + 3) / 4
+	if words := len(strings.Fields(text)); words > byChars {
+		return words
+	}
+	return byChars
+}
@@ -0,0 +1,134 @@
+// Package export renders a session and its messages as a downloadable
+// transcript. Renderers write as messages arrive so large sessions never
+// have to be held in memory.
+package export
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/Sourav01112/chat-service/internal/models"
+)
+
+type Format string
+type Scope string
+
+const (
+	FormatMarkdown Format = "markdown"
+	FormatJSON     Format = "json"
+	FormatHTML     Format = "html"
+)
+
+// ScopeActiveBranch exports the messages from the root to the session's
+// active leaf; ScopeFullTree exports every branch.
+const (
+	ScopeActiveBranch Scope = "active_branch"
+	ScopeFullTree     Scope = "full_tree"
+)
+
+func (f Format) String() string {
+	return string(f)
+}
+
+func (s Scope) String() string {
+	return string(s)
+}
+
+func (f Format) IsValid() bool {
+	switch f {
+	case FormatMarkdown, FormatJSON, FormatHTML:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s Scope) IsValid() bool {
+	switch s {
+	case ScopeActiveBranch, ScopeFullTree:
+		return true
+	default:
+		return false
+	}
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatJSON:
+		return "application/json; charset=utf-8"
+	case FormatHTML:
+		return "text/html; charset=utf-8"
+	default:
+		return "text/markdown; charset=utf-8"
+	}
+}
+
+func (f Format) Extension() string {
+	switch f {
+	case FormatJSON:
+		return "json"
+	case FormatHTML:
+		return "html"
+	default:
+		return "md"
+	}
+}
+
+// Filename is the name a download of the session is saved under.
+func Filename(sessionID string, format Format) string {
+	return fmt.Sprintf("chat-%s.%s", sessionID, format.Extension())
+}
+
+// Renderer writes one export. Begin is called once with the session, Message
+// once per message in order_index order, and End once after the last.
+type Renderer interface {
+	Begin(w io.Writer, session *models.Session) error
+	Message(w io.Writer, message *models.Message) error
+	End(w io.Writer) error
+}
+
+func NewRenderer(format Format, scope Scope) (Renderer, error) {
+	switch format {
+	case FormatMarkdown:
+		return &markdownRenderer{scope: scope, positions: make(map[string]int)}, nil
+	case FormatJSON:
+		return &jsonRenderer{scope: scope}, nil
+	case FormatHTML:
+		return &htmlRenderer{scope: scope, positions: make(map[string]int)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// sortedCitations returns citation keys in a stable order so repeated
+// exports of the same session are identical.
+func sortedCitations(citations map[string]string) []string {
+	keys := make([]string, 0, len(citations))
+	for key := range citations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func roleLabel(messageType models.MessageType) string {
+	switch messageType {
+	case models.MessageTypeUser:
+		return "User"
+	case models.MessageTypeAssistant:
+		return "Assistant"
+	default:
+		return "System"
+	}
+}
+
+// replyTo describes where a message hangs in the full tree, using the
+// position each message was given as it was written. Parents always come
+// before their replies, so the parent's position is already known.
+func replyTo(scope Scope, positions map[string]int, message *models.Message) int {
+	if scope != ScopeFullTree || message.ParentMessageID == nil {
+		return 0
+	}
+	return positions[*message.ParentMessageID]
+}
@@ -0,0 +1,98 @@
+package export
+
+import (
+	"html/template"
+	"io"
+	"time"
+
+	"github.com/Sourav01112/chat-service/internal/models"
+)
+
+// The page is self-contained and its print styles keep messages from being
+// split across pages, so printing it to PDF from a browser gives a clean
+// document.
+var htmlTemplates = template.Must(template.New("begin").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Session.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; max-width: 48rem; margin: 2rem auto; padding: 0 1rem; color: #1f2328; line-height: 1.5; }
+header { border-bottom: 1px solid #d0d7de; margin-bottom: 1.5rem; }
+.meta { color: #656d76; font-size: 0.85rem; }
+article { border: 1px solid #d0d7de; border-radius: 6px; padding: 0.75rem 1rem; margin-bottom: 1rem; }
+article.user { background: #f6f8fa; }
+article.system { border-style: dashed; }
+article h2 { font-size: 0.95rem; margin: 0 0 0.25rem; }
+.content { white-space: pre-wrap; word-wrap: break-word; }
+.sources { font-size: 0.85rem; margin: 0.5rem 0 0; padding-left: 1.25rem; }
+@media print {
+  body { margin: 0; max-width: none; }
+  article { break-inside: avoid; page-break-inside: avoid; }
+}
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Session.Title}}</h1>
+<p class="meta">Session {{.Session.ID}} · created {{.Session.CreatedAt.UTC.Format "2006-01-02 15:04 MST"}} · exported {{.ExportedAt.Format "2006-01-02 15:04 MST"}} · {{.Scope}}</p>
+</header>
+<main>
+`))
+
+func init() {
+	template.Must(htmlTemplates.New("message").Parse(`<article id="m{{.Position}}" class="{{.Message.Type}}">
+<h2>{{.Position}}. {{.Role}}</h2>
+<p class="meta">{{.Message.CreatedAt.UTC.Format "2006-01-02 15:04:05 MST"}}{{if .ReplyTo}} · reply to <a href="#m{{.ReplyTo}}">{{.ReplyTo}}</a>{{end}}{{with .Message.Metadata.ModelUsed}} · model {{.}}{{end}}{{if .Message.EditedAt}} · edited{{end}}</p>
+<div class="content">{{.Message.Content}}</div>
+{{- if .Citations}}
+<ol class="sources">
+{{- range .Citations}}
+<li>{{.Key}}: {{.Value}}</li>
+{{- end}}
+</ol>
+{{- end}}
+</article>
+`))
+	template.Must(htmlTemplates.New("end").Parse("</main>\n</body>\n</html>\n"))
+}
+
+type htmlRenderer struct {
+	scope     Scope
+	positions map[string]int
+}
+
+type htmlCitation struct {
+	Key   string
+	Value string
+}
+
+func (r *htmlRenderer) Begin(w io.Writer, session *models.Session) error {
+	return htmlTemplates.ExecuteTemplate(w, "begin", map[string]interface{}{
+		"Session":    session,
+		"ExportedAt": time.Now().UTC(),
+		"Scope":      r.scope,
+	})
+}
+
+func (r *htmlRenderer) Message(w io.Writer, message *models.Message) error {
+	position := len(r.positions) + 1
+	r.positions[message.ID] = position
+
+	var citations []htmlCitation
+	for _, key := range sortedCitations(message.Metadata.SourceCitations) {
+		citations = append(citations, htmlCitation{Key: key, Value: message.Metadata.SourceCitations[key]})
+	}
+
+	return htmlTemplates.ExecuteTemplate(w, "message", map[string]interface{}{
+		"Message":   message,
+		"Position":  position,
+		"Role":      roleLabel(message.Type),
+		"ReplyTo":   replyTo(r.scope, r.positions, message),
+		"Citations": citations,
+	})
+}
+
+func (r *htmlRenderer) End(w io.Writer) error {
+	return htmlTemplates.ExecuteTemplate(w, "end", nil)
+}
@@ -0,0 +1,82 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Sourav01112/chat-service/internal/models"
+)
+
+// FormatVersion is bumped whenever Document changes in a way importers have
+// to know about.
+const FormatVersion = 1
+
+// Document is the canonical JSON export. Messages use the same structure as
+// models.Message, so an export can be read back with the models package.
+type Document struct {
+	Format     string            `json:"format"`
+	Version    int               `json:"version"`
+	ExportedAt time.Time         `json:"exported_at"`
+	Scope      Scope             `json:"scope"`
+	Session    *models.Session   `json:"session"`
+	Messages   []*models.Message `json:"messages"`
+}
+
+const documentFormat = "chat-service.session"
+
+// exportedMessage hides the session a message belongs to, which the
+// document already carries once at the top.
+type exportedMessage struct {
+	*models.Message
+	Session *models.Session `json:"session,omitempty"`
+}
+
+type jsonRenderer struct {
+	scope Scope
+	count int
+}
+
+// Begin writes every field of Document but the messages, which are streamed
+// into the array it leaves open.
+func (r *jsonRenderer) Begin(w io.Writer, session *models.Session) error {
+	header, err := json.Marshal(Document{
+		Format:     documentFormat,
+		Version:    FormatVersion,
+		ExportedAt: time.Now().UTC(),
+		Scope:      r.scope,
+		Session:    session,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	// Drop the closing `null}` of the messages field and reopen it as an
+	// array.
+	header = header[:len(header)-len("null}")]
+	_, err = fmt.Fprintf(w, "%s[", header)
+	return err
+}
+
+func (r *jsonRenderer) Message(w io.Writer, message *models.Message) error {
+	data, err := json.Marshal(exportedMessage{Message: message})
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	if r.count > 0 {
+		if _, err := io.WriteString(w, ","); err != nil {
+			return err
+		}
+	}
+	r.count++
+
+	_, err = w.Write(data)
+	return err
+}
+
+func (r *jsonRenderer) End(w io.Writer) error {
+	_, err := io.WriteString(w, "]}\n")
+	return err
+}
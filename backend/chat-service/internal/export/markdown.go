@@ -0,0 +1,62 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Sourav01112/chat-service/internal/models"
+)
+
+type markdownRenderer struct {
+	scope     Scope
+	positions map[string]int
+}
+
+func (r *markdownRenderer) Begin(w io.Writer, session *models.Session) error {
+	_, err := fmt.Fprintf(w, "# %s\n\n- Session: `%s`\n- Created: %s\n- Exported: %s\n- Scope: %s\n",
+		session.Title,
+		session.ID,
+		session.CreatedAt.UTC().Format(time.RFC3339),
+		time.Now().UTC().Format(time.RFC3339),
+		r.scope)
+	return err
+}
+
+func (r *markdownRenderer) Message(w io.Writer, message *models.Message) error {
+	position := len(r.positions) + 1
+	r.positions[message.ID] = position
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "\n---\n\n### %d. %s\n\n", position, roleLabel(message.Type))
+	fmt.Fprintf(&b, "_%s", message.CreatedAt.UTC().Format(time.RFC3339))
+	if parent := replyTo(r.scope, r.positions, message); parent > 0 {
+		fmt.Fprintf(&b, " · reply to %d", parent)
+	}
+	if message.Metadata.ModelUsed != "" {
+		fmt.Fprintf(&b, " · model %s", message.Metadata.ModelUsed)
+	}
+	if message.EditedAt != nil {
+		b.WriteString(" · edited")
+	}
+	b.WriteString("_\n\n")
+
+	b.WriteString(message.Content)
+	b.WriteString("\n")
+
+	if keys := sortedCitations(message.Metadata.SourceCitations); len(keys) > 0 {
+		b.WriteString("\n**Sources**\n\n")
+		for _, key := range keys {
+			fmt.Fprintf(&b, "- %s: %s\n", key, message.Metadata.SourceCitations[key])
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (r *markdownRenderer) End(w io.Writer) error {
+	return nil
+}
@@ -1,13 +1,18 @@
 package grpc
 
 import (
+	"bufio"
 	"context"
+	"io"
+	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
+	"github.com/Sourav01112/chat-service/internal/export"
+	"github.com/Sourav01112/chat-service/internal/importer"
 	"github.com/Sourav01112/chat-service/internal/models"
 	"github.com/Sourav01112/chat-service/internal/service"
 	pb "github.com/Sourav01112/chat-service/proto"
@@ -15,8 +20,9 @@ import (
 
 func (s *Server) CreateSession(ctx context.Context, req *pb.CreateSessionRequest) (*pb.CreateSessionResponse, error) {
 	serviceReq := &service.CreateSessionRequest{
-		UserID: req.UserId,
-		Title:  req.Title,
+		UserID:         req.UserId,
+		Title:          req.Title,
+		IdempotencyKey: req.IdempotencyKey,
 	}
 
 	if req.Settings != nil {
@@ -65,7 +71,21 @@ func (s *Server) GetUserSessions(ctx context.Context, req *pb.GetUserSessionsReq
 		limit = 20
 	}
 
-	response, err := s.chatService.GetUserSessions(ctx, req.UserId, limit, int(req.Offset))
+	response, err := s.chatService.GetUserSessions(ctx, &service.GetUserSessionsRequest{
+		UserID:       req.UserId,
+		Limit:        limit,
+		Offset:       int(req.Offset),
+		Cursor:       req.Cursor,
+		Direction:    models.PageDirection(req.Direction),
+		IncludeTotal: req.IncludeTotal,
+
+		Statuses:      sessionStatuses(req.Statuses),
+		FolderID:      req.FolderId,
+		TagID:         req.TagId,
+		FavoritesOnly: req.FavoritesOnly,
+		TitlePrefix:   req.TitlePrefix,
+		SortBy:        models.SessionSort(req.SortBy),
+	})
 	if err != nil {
 		return &pb.GetUserSessionsResponse{
 			Success: false,
@@ -79,10 +99,12 @@ func (s *Server) GetUserSessions(ctx context.Context, req *pb.GetUserSessionsReq
 	}
 
 	return &pb.GetUserSessionsResponse{
-		Sessions:   sessions,
-		TotalCount: response.TotalCount,
-		HasMore:    response.HasMore,
-		Success:    true,
+		Sessions:    sessions,
+		TotalCount:  response.TotalCount,
+		HasMore:     response.HasMore,
+		OlderCursor: response.OlderCursor,
+		NewerCursor: response.NewerCursor,
+		Success:     true,
 	}, nil
 }
 
@@ -138,22 +160,15 @@ func (s *Server) DeleteSession(ctx context.Context, req *pb.DeleteSessionRequest
 
 func (s *Server) SendMessage(ctx context.Context, req *pb.SendMessageRequest) (*pb.SendMessageResponse, error) {
 	serviceReq := &service.SendMessageRequest{
-		SessionID: req.SessionId,
-		UserID:    req.UserId,
-		Content:   req.Content,
-		Type:      models.MessageType(req.Type),
+		SessionID:      req.SessionId,
+		UserID:         req.UserId,
+		Content:        req.Content,
+		Type:           models.MessageType(req.Type),
+		IdempotencyKey: req.IdempotencyKey,
 	}
 
 	if req.Metadata != nil {
-		serviceReq.Metadata = models.MessageMetadata{
-			SourceCitations: req.Metadata.SourceCitations,
-			RelevanceScore:  req.Metadata.RelevanceScore,
-			Tags:            req.Metadata.Tags,
-			ModelUsed:       req.Metadata.ModelUsed,
-			TokenCount:      int(req.Metadata.TokenCount),
-			ResponseTimeMs:  req.Metadata.ResponseTimeMs,
-			ProcessingSteps: req.Metadata.ProcessingSteps,
-		}
+		serviceReq.Metadata = messageMetadataFromProto(req.Metadata)
 	}
 
 	if req.ParentMessageId != "" {
@@ -174,12 +189,111 @@ func (s *Server) SendMessage(ctx context.Context, req *pb.SendMessageRequest) (*
 	}, nil
 }
 
+func (s *Server) SendMessageAndReply(ctx context.Context, req *pb.SendMessageAndReplyRequest) (*pb.SendMessageAndReplyResponse, error) {
+	serviceReq := &service.SendMessageRequest{
+		SessionID:      req.SessionId,
+		UserID:         req.UserId,
+		Content:        req.Content,
+		Type:           models.MessageTypeUser,
+		IdempotencyKey: req.IdempotencyKey,
+	}
+
+	if req.Metadata != nil {
+		serviceReq.Metadata = messageMetadataFromProto(req.Metadata)
+	}
+
+	if req.ParentMessageId != "" {
+		serviceReq.ParentMessageID = &req.ParentMessageId
+	}
+
+	response, err := s.chatService.SendMessageAndReply(ctx, serviceReq)
+
+	pbResponse := &pb.SendMessageAndReplyResponse{Success: err == nil}
+	if err != nil {
+		pbResponse.Error = err.Error()
+	}
+	if response != nil {
+		if response.UserMessage != nil {
+			pbResponse.UserMessage = messageToProto(response.UserMessage)
+		}
+		if response.AssistantMessage != nil {
+			pbResponse.AssistantMessage = messageToProto(response.AssistantMessage)
+		}
+	}
+
+	return pbResponse, nil
+}
+
+func (s *Server) StreamReply(req *pb.SendMessageAndReplyRequest, stream pb.ChatService_StreamReplyServer) error {
+	serviceReq := &service.SendMessageRequest{
+		SessionID:      req.SessionId,
+		UserID:         req.UserId,
+		Content:        req.Content,
+		Type:           models.MessageTypeUser,
+		IdempotencyKey: req.IdempotencyKey,
+	}
+
+	if req.Metadata != nil {
+		serviceReq.Metadata = messageMetadataFromProto(req.Metadata)
+	}
+
+	if req.ParentMessageId != "" {
+		serviceReq.ParentMessageID = &req.ParentMessageId
+	}
+
+	err := s.chatService.StreamReply(stream.Context(), serviceReq, func(event *service.StreamReplyEvent) error {
+		pbEvent := &pb.StreamReplyResponse{
+			AssistantMessageId: event.AssistantMessageID,
+			Delta:              event.Delta,
+			IsFinal:            event.IsFinal,
+		}
+		if event.UserMessage != nil {
+			pbEvent.UserMessage = messageToProto(event.UserMessage)
+		}
+		if event.AssistantMessage != nil {
+			pbEvent.AssistantMessage = messageToProto(event.AssistantMessage)
+		}
+		return stream.Send(pbEvent)
+	})
+	if err != nil {
+		if stream.Context().Err() != nil {
+			return status.FromContextError(stream.Context().Err()).Err()
+		}
+		return status.Errorf(codes.Internal, "Failed to stream reply: %v", err)
+	}
+
+	return nil
+}
+
+func (s *Server) RegenerateMessage(ctx context.Context, req *pb.RegenerateMessageRequest) (*pb.RegenerateMessageResponse, error) {
+	message, err := s.chatService.RegenerateMessage(ctx, &service.RegenerateMessageRequest{
+		MessageID:   req.MessageId,
+		UserID:      req.UserId,
+		Temperature: req.Temperature,
+		Model:       req.Model,
+	})
+	if err != nil {
+		return &pb.RegenerateMessageResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	return &pb.RegenerateMessageResponse{
+		Message: messageToProto(message),
+		Success: true,
+	}, nil
+}
+
 func (s *Server) GetChatHistory(ctx context.Context, req *pb.GetChatHistoryRequest) (*pb.GetChatHistoryResponse, error) {
 	serviceReq := &service.GetChatHistoryRequest{
-		SessionID: req.SessionId,
-		UserID:    req.UserId,
-		Limit:     int(req.Limit),
-		Offset:    int(req.Offset),
+		SessionID:    req.SessionId,
+		UserID:       req.UserId,
+		Limit:        int(req.Limit),
+		Offset:       int(req.Offset),
+		Cursor:       req.Cursor,
+		Direction:    models.PageDirection(req.Direction),
+		IncludeTotal: req.IncludeTotal,
 	}
 
 	if req.FromDate != nil {
@@ -192,6 +306,11 @@ func (s *Server) GetChatHistory(ctx context.Context, req *pb.GetChatHistoryReque
 		serviceReq.ToDate = &toTime
 	}
 
+	for _, messageType := range req.MessageTypes {
+		serviceReq.MessageTypes = append(serviceReq.MessageTypes, models.MessageType(messageType))
+	}
+	serviceReq.Tags = req.Tags
+
 	response, err := s.chatService.GetChatHistory(ctx, serviceReq)
 	if err != nil {
 		return &pb.GetChatHistoryResponse{
@@ -206,10 +325,12 @@ func (s *Server) GetChatHistory(ctx context.Context, req *pb.GetChatHistoryReque
 	}
 
 	return &pb.GetChatHistoryResponse{
-		Messages:   messages,
-		TotalCount: response.TotalCount,
-		HasMore:    response.HasMore,
-		Success:    true,
+		Messages:    messages,
+		TotalCount:  response.TotalCount,
+		HasMore:     response.HasMore,
+		OlderCursor: response.OlderCursor,
+		NewerCursor: response.NewerCursor,
+		Success:     true,
 	}, nil
 }
 
@@ -222,13 +343,98 @@ func (s *Server) DeleteMessage(ctx context.Context, req *pb.DeleteMessageRequest
 	return &emptypb.Empty{}, nil
 }
 
-func (s *Server) SearchMessages(ctx context.Context, req *pb.SearchMessagesRequest) (*pb.SearchMessagesResponse, error) {
-	serviceReq := &service.SearchMessagesRequest{
+func (s *Server) EditMessage(ctx context.Context, req *pb.EditMessageRequest) (*pb.EditMessageResponse, error) {
+	response, err := s.chatService.EditMessage(ctx, &service.EditMessageRequest{
+		MessageID:       req.MessageId,
+		UserID:          req.UserId,
+		Content:         req.Content,
+		TruncateReplies: req.TruncateReplies,
+	})
+	if err != nil {
+		return &pb.EditMessageResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	return &pb.EditMessageResponse{
+		Message:           messageToProto(response.Message),
+		Revision:          messageRevisionToProto(response.Revision),
+		RemovedMessageIds: response.RemovedMessageIDs,
+		Success:           true,
+	}, nil
+}
+
+func (s *Server) GetMessageRevisions(ctx context.Context, req *pb.GetMessageRevisionsRequest) (*pb.GetMessageRevisionsResponse, error) {
+	revisions, err := s.chatService.GetMessageRevisions(ctx, req.MessageId, req.UserId)
+	if err != nil {
+		return &pb.GetMessageRevisionsResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	pbRevisions := make([]*pb.MessageRevision, len(revisions))
+	for i, revision := range revisions {
+		pbRevisions[i] = messageRevisionToProto(revision)
+	}
+
+	return &pb.GetMessageRevisionsResponse{
+		Revisions: pbRevisions,
+		Success:   true,
+	}, nil
+}
+
+func (s *Server) GetMessageTree(ctx context.Context, req *pb.GetMessageTreeRequest) (*pb.GetMessageTreeResponse, error) {
+	response, err := s.chatService.GetMessageTree(ctx, req.SessionId, req.UserId)
+	if err != nil {
+		return &pb.GetMessageTreeResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	roots := make([]*pb.MessageNode, len(response.Roots))
+	for i, root := range response.Roots {
+		roots[i] = messageNodeToProto(root)
+	}
+
+	return &pb.GetMessageTreeResponse{
+		Roots:        roots,
+		ActiveLeafId: response.ActiveLeafID,
+		Success:      true,
+	}, nil
+}
+
+func (s *Server) SwitchBranch(ctx context.Context, req *pb.SwitchBranchRequest) (*pb.SwitchBranchResponse, error) {
+	session, err := s.chatService.SwitchBranch(ctx, &service.SwitchBranchRequest{
 		SessionID: req.SessionId,
 		UserID:    req.UserId,
-		Query:     req.Query,
-		Limit:     int(req.Limit),
-		Offset:    int(req.Offset),
+		MessageID: req.MessageId,
+	})
+	if err != nil {
+		return &pb.SwitchBranchResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	return &pb.SwitchBranchResponse{
+		Session: sessionToProto(session),
+		Success: true,
+	}, nil
+}
+
+func (s *Server) SearchMessages(ctx context.Context, req *pb.SearchMessagesRequest) (*pb.SearchMessagesResponse, error) {
+	serviceReq := &service.SearchMessagesRequest{
+		SessionID:    req.SessionId,
+		UserID:       req.UserId,
+		Query:        req.Query,
+		Limit:        int(req.Limit),
+		Offset:       int(req.Offset),
+		Cursor:       req.Cursor,
+		Direction:    models.PageDirection(req.Direction),
+		IncludeTotal: req.IncludeTotal,
 	}
 
 	response, err := s.chatService.SearchMessages(ctx, serviceReq)
@@ -239,13 +445,75 @@ func (s *Server) SearchMessages(ctx context.Context, req *pb.SearchMessagesReque
 		}, nil
 	}
 
-	messages := make([]*pb.Message, len(response.Messages))
-	for i, message := range response.Messages {
-		messages[i] = messageToProto(message)
+	messages := make([]*pb.Message, len(response.Results))
+	hits := make([]*pb.SearchHit, len(response.Results))
+	for i, result := range response.Results {
+		messages[i] = messageToProto(result.Message)
+		hits[i] = searchResultToProto(result)
 	}
 
 	return &pb.SearchMessagesResponse{
-		Messages:   messages,
+		Messages:    messages,
+		Hits:        hits,
+		TotalCount:  response.TotalCount,
+		HasMore:     response.HasMore,
+		OlderCursor: response.OlderCursor,
+		NewerCursor: response.NewerCursor,
+		Success:     true,
+	}, nil
+}
+
+func (s *Server) SearchAllMessages(ctx context.Context, req *pb.SearchAllMessagesRequest) (*pb.SearchAllMessagesResponse, error) {
+	serviceReq := &service.SearchAllMessagesRequest{
+		UserID:      req.UserId,
+		Query:       req.Query,
+		Limit:       int(req.Limit),
+		Offset:      int(req.Offset),
+		ContextSize: int(req.ContextSize),
+	}
+
+	if req.FromDate != nil {
+		fromTime := req.FromDate.AsTime()
+		serviceReq.FromDate = &fromTime
+	}
+
+	if req.ToDate != nil {
+		toTime := req.ToDate.AsTime()
+		serviceReq.ToDate = &toTime
+	}
+
+	for _, status := range req.SessionStatuses {
+		serviceReq.SessionStatuses = append(serviceReq.SessionStatuses, models.SessionStatus(status))
+	}
+	for _, messageType := range req.MessageTypes {
+		serviceReq.MessageTypes = append(serviceReq.MessageTypes, models.MessageType(messageType))
+	}
+
+	response, err := s.chatService.SearchAllMessages(ctx, serviceReq)
+	if err != nil {
+		return &pb.SearchAllMessagesResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	sessions := make([]*pb.SessionSearchResults, len(response.Sessions))
+	for i, group := range response.Sessions {
+		hits := make([]*pb.SearchHit, len(group.Results))
+		for j, result := range group.Results {
+			hits[j] = searchResultToProto(result)
+		}
+
+		sessions[i] = &pb.SessionSearchResults{
+			SessionId:     group.SessionID,
+			SessionTitle:  group.SessionTitle,
+			SessionStatus: group.SessionStatus.String(),
+			Hits:          hits,
+		}
+	}
+
+	return &pb.SearchAllMessagesResponse{
+		Sessions:   sessions,
 		TotalCount: response.TotalCount,
 		HasMore:    response.HasMore,
 		Success:    true,
@@ -268,7 +536,7 @@ func (s *Server) UpdateTypingStatus(ctx context.Context, req *pb.UpdateTypingSta
 }
 
 func (s *Server) GetTypingUsers(ctx context.Context, req *pb.GetTypingUsersRequest) (*pb.GetTypingUsersResponse, error) {
-	users, err := s.chatService.GetTypingUsers(ctx, req.SessionId)
+	users, err := s.chatService.GetTypingUsers(ctx, req.SessionId, req.UserId)
 	if err != nil {
 		return &pb.GetTypingUsersResponse{
 			Success: false,
@@ -282,17 +550,577 @@ func (s *Server) GetTypingUsers(ctx context.Context, req *pb.GetTypingUsersReque
 	}, nil
 }
 
+func (s *Server) WatchSession(req *pb.WatchSessionRequest, stream pb.ChatService_WatchSessionServer) error {
+	events, err := s.chatService.WatchSession(stream.Context(), req.SessionId, req.UserId, req.Cursor)
+	if err != nil {
+		return status.Errorf(codes.NotFound, "Failed to watch session: %v", err)
+	}
+
+	for event := range events {
+		if err := stream.Send(sessionEventToProto(event)); err != nil {
+			return err
+		}
+	}
+
+	if err := stream.Context().Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+
+	return nil
+}
+
+// exportChunkSize is the most export data sent in one message.
+const exportChunkSize = 32 * 1024
+
+func (s *Server) ExportSession(req *pb.ExportSessionRequest, stream pb.ChatService_ExportSessionServer) error {
+	format := export.Format(req.Format)
+
+	// The first chunk names the file; later chunks only carry data.
+	first := true
+	sender := chunkWriterFunc(func(data []byte) error {
+		chunk := &pb.ExportSessionChunk{Data: data}
+		if first {
+			chunk.ContentType = format.ContentType()
+			chunk.Filename = export.Filename(req.SessionId, format)
+			first = false
+		}
+		return stream.Send(chunk)
+	})
+
+	w := bufio.NewWriterSize(sender, exportChunkSize)
+
+	err := s.chatService.ExportSession(stream.Context(), &service.ExportSessionRequest{
+		SessionID: req.SessionId,
+		UserID:    req.UserId,
+		Format:    format,
+		Scope:     export.Scope(req.Scope),
+	}, w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		if stream.Context().Err() != nil {
+			return status.FromContextError(stream.Context().Err()).Err()
+		}
+		if first {
+			return status.Errorf(codes.InvalidArgument, "Failed to export session: %v", err)
+		}
+		return status.Errorf(codes.Internal, "Failed to export session: %v", err)
+	}
+
+	return nil
+}
+
+// ImportSessions reads the whole file before importing anything, since
+// neither format can be parsed a conversation at a time.
+func (s *Server) ImportSessions(stream pb.ChatService_ImportSessionsServer) error {
+	var userID, source string
+	var data []byte
+
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if userID == "" {
+			userID = chunk.UserId
+		}
+		if source == "" {
+			source = chunk.Source
+		}
+		if len(data)+len(chunk.Data) > s.config.ImportMaxBytes {
+			return status.Errorf(codes.ResourceExhausted, "Import file exceeds %d bytes", s.config.ImportMaxBytes)
+		}
+		data = append(data, chunk.Data...)
+	}
+
+	response, err := s.chatService.ImportSessions(stream.Context(), &service.ImportSessionsRequest{
+		UserID: userID,
+		Source: importer.Source(source),
+		Data:   data,
+	})
+	if err != nil {
+		return stream.SendAndClose(&pb.ImportSessionsResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+	}
+
+	reports := make([]*pb.ImportReport, len(response.Reports))
+	for i, report := range response.Reports {
+		reports[i] = &pb.ImportReport{
+			ExternalId:   report.ExternalID,
+			Title:        report.Title,
+			SessionId:    report.SessionID,
+			Status:       string(report.Status),
+			MessageCount: int32(report.MessageCount),
+			Error:        report.Error,
+		}
+	}
+
+	return stream.SendAndClose(&pb.ImportSessionsResponse{
+		Reports:  reports,
+		Imported: int32(response.Imported),
+		Skipped:  int32(response.Skipped),
+		Failed:   int32(response.Failed),
+		Success:  true,
+	})
+}
+
+func (s *Server) CreateShareLink(ctx context.Context, req *pb.CreateShareLinkRequest) (*pb.CreateShareLinkResponse, error) {
+	link, err := s.chatService.CreateShareLink(ctx, &service.CreateShareLinkRequest{
+		SessionID:        req.SessionId,
+		UserID:           req.UserId,
+		Mode:             models.ShareMode(req.Mode),
+		IncludeCitations: req.IncludeCitations,
+		ExpiresIn:        time.Duration(req.ExpiresInSeconds) * time.Second,
+	})
+	if err != nil {
+		return &pb.CreateShareLinkResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	return &pb.CreateShareLinkResponse{
+		ShareLink: shareLinkToProto(link),
+		Success:   true,
+	}, nil
+}
+
+func (s *Server) GetSharedSession(ctx context.Context, req *pb.GetSharedSessionRequest) (*pb.GetSharedSessionResponse, error) {
+	shared, err := s.chatService.GetSharedSession(ctx, req.Token)
+	if err != nil {
+		return &pb.GetSharedSessionResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	messages := make([]*pb.SharedMessage, len(shared.Messages))
+	for i, message := range shared.Messages {
+		messages[i] = sharedMessageToProto(message)
+	}
+
+	return &pb.GetSharedSessionResponse{
+		Title:     shared.Title,
+		Mode:      shared.Mode.String(),
+		CreatedAt: timestamppb.New(shared.CreatedAt),
+		SharedAt:  timestamppb.New(shared.SharedAt),
+		ViewCount: shared.ViewCount,
+		Messages:  messages,
+		Success:   true,
+	}, nil
+}
+
+func (s *Server) RevokeShareLink(ctx context.Context, req *pb.RevokeShareLinkRequest) (*pb.RevokeShareLinkResponse, error) {
+	if err := s.chatService.RevokeShareLink(ctx, req.Token, req.UserId); err != nil {
+		return &pb.RevokeShareLinkResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	return &pb.RevokeShareLinkResponse{Success: true}, nil
+}
+
+func (s *Server) InviteMember(ctx context.Context, req *pb.InviteMemberRequest) (*pb.InviteMemberResponse, error) {
+	member, err := s.chatService.InviteMember(ctx, &service.InviteMemberRequest{
+		SessionID:    req.SessionId,
+		UserID:       req.UserId,
+		MemberUserID: req.MemberUserId,
+		Role:         models.MemberRole(req.Role),
+	})
+	if err != nil {
+		return &pb.InviteMemberResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	return &pb.InviteMemberResponse{
+		Member:  sessionMemberToProto(member),
+		Success: true,
+	}, nil
+}
+
+func (s *Server) RemoveMember(ctx context.Context, req *pb.RemoveMemberRequest) (*pb.RemoveMemberResponse, error) {
+	err := s.chatService.RemoveMember(ctx, &service.RemoveMemberRequest{
+		SessionID:    req.SessionId,
+		UserID:       req.UserId,
+		MemberUserID: req.MemberUserId,
+	})
+	if err != nil {
+		return &pb.RemoveMemberResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	return &pb.RemoveMemberResponse{Success: true}, nil
+}
+
+func (s *Server) GetSessionMembers(ctx context.Context, req *pb.GetSessionMembersRequest) (*pb.GetSessionMembersResponse, error) {
+	members, err := s.chatService.GetSessionMembers(ctx, req.SessionId, req.UserId)
+	if err != nil {
+		return &pb.GetSessionMembersResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	pbMembers := make([]*pb.SessionMember, len(members))
+	for i, member := range members {
+		pbMembers[i] = sessionMemberToProto(member)
+	}
+
+	return &pb.GetSessionMembersResponse{
+		Members: pbMembers,
+		Success: true,
+	}, nil
+}
+
+func (s *Server) CreateFolder(ctx context.Context, req *pb.CreateFolderRequest) (*pb.FolderResponse, error) {
+	folder, err := s.chatService.CreateFolder(ctx, &service.FolderRequest{
+		UserID: req.UserId,
+		Name:   req.Name,
+	})
+	if err != nil {
+		return &pb.FolderResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	return &pb.FolderResponse{
+		Folder:  folderToProto(folder),
+		Success: true,
+	}, nil
+}
+
+func (s *Server) UpdateFolder(ctx context.Context, req *pb.UpdateFolderRequest) (*pb.FolderResponse, error) {
+	folder, err := s.chatService.UpdateFolder(ctx, &service.FolderRequest{
+		FolderID: req.FolderId,
+		UserID:   req.UserId,
+		Name:     req.Name,
+	})
+	if err != nil {
+		return &pb.FolderResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	return &pb.FolderResponse{
+		Folder:  folderToProto(folder),
+		Success: true,
+	}, nil
+}
+
+func (s *Server) DeleteFolder(ctx context.Context, req *pb.DeleteFolderRequest) (*pb.DeleteOrganizationResponse, error) {
+	if err := s.chatService.DeleteFolder(ctx, req.FolderId, req.UserId); err != nil {
+		return &pb.DeleteOrganizationResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	return &pb.DeleteOrganizationResponse{Success: true}, nil
+}
+
+func (s *Server) ListFolders(ctx context.Context, req *pb.ListFoldersRequest) (*pb.ListFoldersResponse, error) {
+	folders, err := s.chatService.ListFolders(ctx, req.UserId)
+	if err != nil {
+		return &pb.ListFoldersResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	pbFolders := make([]*pb.Folder, len(folders))
+	for i, folder := range folders {
+		pbFolders[i] = folderToProto(folder)
+	}
+
+	return &pb.ListFoldersResponse{
+		Folders: pbFolders,
+		Success: true,
+	}, nil
+}
+
+func (s *Server) CreateTag(ctx context.Context, req *pb.CreateTagRequest) (*pb.TagResponse, error) {
+	tag, err := s.chatService.CreateTag(ctx, &service.TagRequest{
+		UserID: req.UserId,
+		Name:   req.Name,
+		Color:  req.Color,
+	})
+	if err != nil {
+		return &pb.TagResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	return &pb.TagResponse{
+		Tag:     tagToProto(tag),
+		Success: true,
+	}, nil
+}
+
+func (s *Server) UpdateTag(ctx context.Context, req *pb.UpdateTagRequest) (*pb.TagResponse, error) {
+	tag, err := s.chatService.UpdateTag(ctx, &service.TagRequest{
+		TagID:  req.TagId,
+		UserID: req.UserId,
+		Name:   req.Name,
+		Color:  req.Color,
+	})
+	if err != nil {
+		return &pb.TagResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	return &pb.TagResponse{
+		Tag:     tagToProto(tag),
+		Success: true,
+	}, nil
+}
+
+func (s *Server) DeleteTag(ctx context.Context, req *pb.DeleteTagRequest) (*pb.DeleteOrganizationResponse, error) {
+	if err := s.chatService.DeleteTag(ctx, req.TagId, req.UserId); err != nil {
+		return &pb.DeleteOrganizationResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	return &pb.DeleteOrganizationResponse{Success: true}, nil
+}
+
+func (s *Server) ListTags(ctx context.Context, req *pb.ListTagsRequest) (*pb.ListTagsResponse, error) {
+	tags, err := s.chatService.ListTags(ctx, req.UserId)
+	if err != nil {
+		return &pb.ListTagsResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	return &pb.ListTagsResponse{
+		Tags:    tagsToProto(tags),
+		Success: true,
+	}, nil
+}
+
+func (s *Server) OrganizeSession(ctx context.Context, req *pb.OrganizeSessionRequest) (*pb.OrganizeSessionResponse, error) {
+	session, err := s.chatService.OrganizeSession(ctx, &service.OrganizeSessionRequest{
+		SessionID: req.SessionId,
+		UserID:    req.UserId,
+		FolderID:  req.FolderId,
+		Pinned:    req.Pinned,
+		Favorite:  req.Favorite,
+		SetTags:   req.SetTags,
+		TagIDs:    req.TagIds,
+	})
+	if err != nil {
+		return &pb.OrganizeSessionResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	return &pb.OrganizeSessionResponse{
+		Session: sessionToProto(session),
+		Success: true,
+	}, nil
+}
+
+func (s *Server) ForkSession(ctx context.Context, req *pb.ForkSessionRequest) (*pb.ForkSessionResponse, error) {
+	session, err := s.chatService.ForkSession(ctx, &service.ForkSessionRequest{
+		MessageID: req.MessageId,
+		UserID:    req.UserId,
+		Title:     req.Title,
+	})
+	if err != nil {
+		return &pb.ForkSessionResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	return &pb.ForkSessionResponse{
+		Session: sessionToProto(session),
+		Success: true,
+	}, nil
+}
+
+func (s *Server) ListTrash(ctx context.Context, req *pb.ListTrashRequest) (*pb.ListTrashResponse, error) {
+	response, err := s.chatService.ListTrash(ctx, &service.ListTrashRequest{
+		UserID:    req.UserId,
+		SessionID: req.SessionId,
+		Limit:     int(req.Limit),
+		Offset:    int(req.Offset),
+	})
+	if err != nil {
+		return &pb.ListTrashResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	sessions := make([]*pb.Session, len(response.Sessions))
+	for i, session := range response.Sessions {
+		sessions[i] = sessionToProto(session)
+	}
+
+	messages := make([]*pb.Message, len(response.Messages))
+	for i, message := range response.Messages {
+		messages[i] = messageToProto(message)
+	}
+
+	return &pb.ListTrashResponse{
+		Sessions:          sessions,
+		Messages:          messages,
+		TotalCount:        response.TotalCount,
+		HasMore:           response.HasMore,
+		PurgeAfterSeconds: int64(response.PurgeAfter.Seconds()),
+		Success:           true,
+	}, nil
+}
+
+func (s *Server) RestoreSession(ctx context.Context, req *pb.RestoreSessionRequest) (*pb.RestoreSessionResponse, error) {
+	session, err := s.chatService.RestoreSession(ctx, req.SessionId, req.UserId)
+	if err != nil {
+		return &pb.RestoreSessionResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	return &pb.RestoreSessionResponse{
+		Session: sessionToProto(session),
+		Success: true,
+	}, nil
+}
+
+func (s *Server) RestoreMessage(ctx context.Context, req *pb.RestoreMessageRequest) (*pb.RestoreMessageResponse, error) {
+	message, err := s.chatService.RestoreMessage(ctx, req.MessageId, req.UserId)
+	if err != nil {
+		return &pb.RestoreMessageResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	return &pb.RestoreMessageResponse{
+		Message: messageToProto(message),
+		Success: true,
+	}, nil
+}
+
+// chunkWriterFunc sends each write as one message. Writes are copied
+// because the buffer in front of it reuses its memory.
+type chunkWriterFunc func(data []byte) error
+
+func (f chunkWriterFunc) Write(data []byte) (int, error) {
+	if err := f(append([]byte(nil), data...)); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
 func sessionToProto(session *models.Session) *pb.Session {
-	return &pb.Session{
+	pbSession := &pb.Session{
 		Id:           session.ID,
 		UserId:       session.UserID,
 		Title:        session.Title,
+		TitleSource:  session.TitleSource.String(),
 		Status:       session.Status.String(),
 		Settings:     sessionSettingsToProto(session.Settings),
 		CreatedAt:    timestamppb.New(session.CreatedAt),
 		UpdatedAt:    timestamppb.New(session.UpdatedAt),
 		LastActivity: timestamppb.New(session.LastActivity),
+		ActiveLeafId: stringValue(session.ActiveLeafID),
+		Pinned:       session.Pinned,
+		Favorite:     session.Favorite,
+		FolderId:     stringValue(session.FolderID),
+		Tags:         tagsToProto(session.Tags),
+
+		ForkedFromSessionId: stringValue(session.ForkedFromSessionID),
+		ForkedFromMessageId: stringValue(session.ForkedFromMessageID),
+		Metadata:            sessionMetadataToProto(session.Metadata),
+	}
+
+	if session.ArchivedAt != nil {
+		pbSession.ArchivedAt = timestamppb.New(*session.ArchivedAt)
+	}
+	if session.TrashedAt != nil {
+		pbSession.TrashedAt = timestamppb.New(*session.TrashedAt)
+	}
+
+	return pbSession
+}
+
+func sessionMetadataToProto(metadata models.SessionMetadata) *pb.SessionMetadata {
+	forks := make([]*pb.SessionFork, len(metadata.Forks))
+	for i, fork := range metadata.Forks {
+		forks[i] = &pb.SessionFork{
+			SessionId: fork.SessionID,
+			MessageId: fork.MessageID,
+			UserId:    fork.UserID,
+			CreatedAt: timestamppb.New(fork.CreatedAt),
+		}
+	}
+	return &pb.SessionMetadata{Forks: forks}
+}
+
+func sessionStatuses(values []string) []models.SessionStatus {
+	statuses := make([]models.SessionStatus, len(values))
+	for i, value := range values {
+		statuses[i] = models.SessionStatus(value)
+	}
+	return statuses
+}
+
+func folderToProto(folder *models.Folder) *pb.Folder {
+	return &pb.Folder{
+		Id:        folder.ID,
+		UserId:    folder.UserID,
+		Name:      folder.Name,
+		CreatedAt: timestamppb.New(folder.CreatedAt),
+		UpdatedAt: timestamppb.New(folder.UpdatedAt),
+	}
+}
+
+func tagsToProto(tags []*models.Tag) []*pb.Tag {
+	pbTags := make([]*pb.Tag, len(tags))
+	for i, tag := range tags {
+		pbTags[i] = tagToProto(tag)
 	}
+	return pbTags
+}
+
+func tagToProto(tag *models.Tag) *pb.Tag {
+	return &pb.Tag{
+		Id:        tag.ID,
+		UserId:    tag.UserID,
+		Name:      tag.Name,
+		Color:     tag.Color,
+		CreatedAt: timestamppb.New(tag.CreatedAt),
+		UpdatedAt: timestamppb.New(tag.UpdatedAt),
+	}
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
 }
 
 func sessionSettingsToProto(settings models.SessionSettings) *pb.SessionSettings {
@@ -316,15 +1144,105 @@ func messageToProto(message *models.Message) *pb.Message {
 		Metadata:   messageMetadataToProto(message.Metadata),
 		CreatedAt:  timestamppb.New(message.CreatedAt),
 		OrderIndex: int32(message.OrderIndex),
+		Status:     models.MessageStatusComplete.String(),
+		SiblingIds: message.SiblingIDs,
+	}
+
+	if message.Status != "" {
+		pbMessage.Status = message.Status.String()
 	}
 
 	if message.ParentMessageID != nil {
 		pbMessage.ParentMessageId = *message.ParentMessageID
 	}
 
+	if message.EditedAt != nil {
+		pbMessage.EditedAt = timestamppb.New(*message.EditedAt)
+	}
+
+	if message.DeletedAt.Valid {
+		pbMessage.DeletedAt = timestamppb.New(message.DeletedAt.Time)
+	}
+
 	return pbMessage
 }
 
+func sessionMemberToProto(member *models.SessionMember) *pb.SessionMember {
+	pbMember := &pb.SessionMember{
+		SessionId: member.SessionID,
+		UserId:    member.UserID,
+		Role:      member.Role.String(),
+		CreatedAt: timestamppb.New(member.CreatedAt),
+	}
+
+	if member.InvitedBy != nil {
+		pbMember.InvitedBy = *member.InvitedBy
+	}
+
+	return pbMember
+}
+
+func shareLinkToProto(link *models.ShareLink) *pb.ShareLink {
+	pbLink := &pb.ShareLink{
+		Id:               link.ID,
+		SessionId:        link.SessionID,
+		Token:            link.Token,
+		Mode:             link.Mode.String(),
+		IncludeCitations: link.IncludeCitations,
+		ViewCount:        link.ViewCount,
+		CreatedAt:        timestamppb.New(link.CreatedAt),
+	}
+
+	if link.ExpiresAt != nil {
+		pbLink.ExpiresAt = timestamppb.New(*link.ExpiresAt)
+	}
+
+	return pbLink
+}
+
+func sharedMessageToProto(message *models.SharedMessage) *pb.SharedMessage {
+	pbMessage := &pb.SharedMessage{
+		Id:              message.ID,
+		Content:         message.Content,
+		Type:            message.Type.String(),
+		CreatedAt:       timestamppb.New(message.CreatedAt),
+		OrderIndex:      int32(message.OrderIndex),
+		ModelUsed:       message.ModelUsed,
+		SourceCitations: message.SourceCitations,
+	}
+
+	if message.ParentMessageID != nil {
+		pbMessage.ParentMessageId = *message.ParentMessageID
+	}
+
+	return pbMessage
+}
+
+func messageNodeToProto(node *models.MessageNode) *pb.MessageNode {
+	pbNode := &pb.MessageNode{
+		Message: messageToProto(node.Message),
+		Active:  node.Active,
+	}
+
+	for _, child := range node.Children {
+		pbNode.Children = append(pbNode.Children, messageNodeToProto(child))
+	}
+
+	return pbNode
+}
+
+func messageRevisionToProto(revision *models.MessageRevision) *pb.MessageRevision {
+	return &pb.MessageRevision{
+		Id:        revision.ID,
+		MessageId: revision.MessageID,
+		Revision:  int32(revision.Revision),
+		Content:   revision.Content,
+		Metadata:  messageMetadataToProto(revision.Metadata),
+		EditedBy:  revision.EditedBy,
+		CreatedAt: timestamppb.New(revision.CreatedAt),
+	}
+}
+
 func messageMetadataToProto(metadata models.MessageMetadata) *pb.MessageMetadata {
 	return &pb.MessageMetadata{
 		SourceCitations: metadata.SourceCitations,
@@ -336,3 +1254,57 @@ func messageMetadataToProto(metadata models.MessageMetadata) *pb.MessageMetadata
 		ProcessingSteps: metadata.ProcessingSteps,
 	}
 }
+
+func messageMetadataFromProto(metadata *pb.MessageMetadata) models.MessageMetadata {
+	return models.MessageMetadata{
+		SourceCitations: metadata.SourceCitations,
+		RelevanceScore:  metadata.RelevanceScore,
+		Tags:            metadata.Tags,
+		ModelUsed:       metadata.ModelUsed,
+		TokenCount:      int(metadata.TokenCount),
+		ResponseTimeMs:  metadata.ResponseTimeMs,
+		ProcessingSteps: metadata.ProcessingSteps,
+	}
+}
+
+func sessionEventToProto(event *models.SessionEvent) *pb.SessionEvent {
+	pbEvent := &pb.SessionEvent{
+		Id:        event.ID,
+		SessionId: event.SessionID,
+		Type:      event.Type.String(),
+		UserId:    event.UserID,
+		MessageId: event.MessageID,
+		CreatedAt: timestamppb.New(event.CreatedAt),
+	}
+
+	if event.Message != nil {
+		pbEvent.Message = messageToProto(event.Message)
+	}
+
+	if event.Session != nil {
+		pbEvent.Session = sessionToProto(event.Session)
+	}
+
+	if event.Member != nil {
+		pbEvent.Member = sessionMemberToProto(event.Member)
+	}
+
+	return pbEvent
+}
+
+func searchResultToProto(result *models.SearchResult) *pb.SearchHit {
+	hit := &pb.SearchHit{
+		Message: messageToProto(result.Message),
+		Rank:    result.Rank,
+		Snippet: result.Snippet,
+	}
+
+	for _, message := range result.Before {
+		hit.Before = append(hit.Before, messageToProto(message))
+	}
+	for _, message := range result.After {
+		hit.After = append(hit.After, messageToProto(message))
+	}
+
+	return hit
+}
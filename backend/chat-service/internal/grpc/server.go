@@ -38,6 +38,7 @@ func NewServer(chatService service.ChatService, config *config.Config, log *zap.
             PermitWithoutStream: true,
         }),
         grpc.UnaryInterceptor(loggingInterceptor(log)),
+        grpc.StreamInterceptor(streamLoggingInterceptor(log)),
     }
     
     grpcServer := grpc.NewServer(opts...)
@@ -104,4 +105,27 @@ func loggingInterceptor(log *zap.Logger) grpc.UnaryServerInterceptor {
         
         return resp, err
     }
+}
+
+func streamLoggingInterceptor(log *zap.Logger) grpc.StreamServerInterceptor {
+    return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+        start := time.Now()
+        
+        err := handler(srv, ss)
+        
+        duration := time.Since(start)
+        
+        if err != nil {
+            log.Error("gRPC stream failed",
+                zap.String("method", info.FullMethod),
+                zap.Duration("duration", duration),
+                zap.Error(err))
+        } else {
+            log.Info("gRPC stream completed",
+                zap.String("method", info.FullMethod),
+                zap.Duration("duration", duration))
+        }
+        
+        return err
+    }
 }
\ No newline at end of file
@@ -0,0 +1,134 @@
+// Package httpapi serves the parts of chat-service that are better as plain
+// HTTP than gRPC, such as file downloads. Authentication happens at the
+// gateway, which passes the caller's ID in the X-User-ID header.
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Sourav01112/chat-service/internal/config"
+	"github.com/Sourav01112/chat-service/internal/export"
+	"github.com/Sourav01112/chat-service/internal/service"
+)
+
+const userIDHeader = "X-User-ID"
+
+type Server struct {
+	httpServer  *http.Server
+	chatService service.ChatService
+	config      *config.Config
+	log         *zap.Logger
+}
+
+func NewServer(chatService service.ChatService, config *config.Config, log *zap.Logger) *Server {
+	server := &Server{
+		chatService: chatService,
+		config:      config,
+		log:         log,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /sessions/{sessionID}/export", server.exportSession)
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.Handle("GET /debug/vars", expvar.Handler())
+
+	server.httpServer = &http.Server{
+		Addr:              ":" + config.HTTPPort,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	return server
+}
+
+func (s *Server) Start(ctx context.Context) error {
+	s.log.Info("HTTP server starting", zap.String("port", s.config.HTTPPort))
+
+	go func() {
+		<-ctx.Done()
+		s.Stop()
+	}()
+
+	if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Server) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		s.log.Error("Error shutting down HTTP server", zap.Error(err))
+	}
+}
+
+// exportSession downloads a session. The format query parameter defaults to
+// markdown and scope to the active branch.
+func (s *Server) exportSession(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get(userIDHeader)
+	if userID == "" {
+		http.Error(w, "missing user", http.StatusUnauthorized)
+		return
+	}
+
+	sessionID := r.PathValue("sessionID")
+
+	format := export.Format(r.URL.Query().Get("format"))
+	if format == "" {
+		format = export.FormatMarkdown
+	}
+
+	download := &downloadWriter{
+		w:           w,
+		contentType: format.ContentType(),
+		filename:    export.Filename(sessionID, format),
+	}
+
+	err := s.chatService.ExportSession(r.Context(), &service.ExportSessionRequest{
+		SessionID: sessionID,
+		UserID:    userID,
+		Format:    format,
+		Scope:     export.Scope(r.URL.Query().Get("scope")),
+	}, download)
+	if err != nil {
+		s.log.Warn("Session export failed",
+			zap.Error(err),
+			zap.String("session_id", sessionID),
+			zap.String("user_id", userID))
+
+		// Once the download has started all we can do is cut it short.
+		if !download.started {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	}
+}
+
+// downloadWriter sends the download headers with the first write, so an
+// export that fails before writing anything can still return an error page.
+type downloadWriter struct {
+	w           http.ResponseWriter
+	contentType string
+	filename    string
+	started     bool
+}
+
+func (d *downloadWriter) Write(data []byte) (int, error) {
+	if !d.started {
+		d.w.Header().Set("Content-Type", d.contentType)
+		d.w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", d.filename))
+		d.started = true
+	}
+	return d.w.Write(data)
+}
@@ -0,0 +1,75 @@
+package importer
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Sourav01112/chat-service/internal/export"
+)
+
+// parseCanonical reads a JSON export made by this service. The original
+// session ID is kept as the external ID, so an export imported back into the
+// account it came from is recognised as a duplicate too.
+func parseCanonical(data []byte) ([]*Conversation, error) {
+	var document export.Document
+	if err := decode(data, &document); err != nil {
+		return nil, err
+	}
+	if document.Version > export.FormatVersion {
+		return nil, fmt.Errorf("export format version %d is newer than supported version %d",
+			document.Version, export.FormatVersion)
+	}
+	if document.Session == nil || document.Session.ID == "" {
+		return nil, fmt.Errorf("export has no session")
+	}
+
+	session := document.Session
+	settings := session.Settings
+	conversation := &Conversation{
+		Source:     SourceCanonical,
+		ExternalID: session.ID,
+		Title:      session.Title,
+		Status:     session.Status,
+		Settings:   &settings,
+		CreatedAt:  session.CreatedAt,
+		UpdatedAt:  session.UpdatedAt,
+	}
+	if session.ActiveLeafID != nil {
+		conversation.ActiveLeafID = *session.ActiveLeafID
+	}
+
+	messages := document.Messages
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].OrderIndex < messages[j].OrderIndex
+	})
+
+	// A message always has a lower order index than its replies, so sorting
+	// puts parents first. Anything pointing at a message outside the export
+	// is attached to the root instead.
+	seen := make(map[string]bool, len(messages))
+	for _, message := range messages {
+		if message == nil || message.ID == "" {
+			continue
+		}
+
+		imported := &Message{
+			ExternalID: message.ID,
+			Type:       message.Type,
+			Content:    message.Content,
+			Metadata:   message.Metadata,
+			CreatedAt:  message.CreatedAt,
+		}
+		if message.ParentMessageID != nil && seen[*message.ParentMessageID] {
+			imported.ParentExternalID = *message.ParentMessageID
+		}
+
+		seen[message.ID] = true
+		conversation.Messages = append(conversation.Messages, imported)
+	}
+
+	if !seen[conversation.ActiveLeafID] {
+		conversation.ActiveLeafID = ""
+	}
+
+	return []*Conversation{conversation}, nil
+}
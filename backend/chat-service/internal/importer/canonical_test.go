@@ -0,0 +1,61 @@
+package importer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCanonical(t *testing.T) {
+	tests := []struct {
+		name      string
+		leaf      string
+		messages  string
+		wantEdges []string
+		wantLeaf  string
+	}{
+		{
+			name: "tree",
+			leaf: "a1",
+			messages: `
+				{"id": "a1", "parent_message_id": "u1", "type": "assistant", "content": "hello", "order_index": 2},
+				{"id": "u1", "type": "user", "content": "hi", "order_index": 1}`,
+			wantEdges: []string{"u1<", "a1<u1"},
+			wantLeaf:  "a1",
+		},
+		{
+			name: "parent missing from the export",
+			leaf: "a1",
+			messages: `
+				{"id": "u1", "parent_message_id": "dropped", "type": "user", "content": "hi", "order_index": 2},
+				{"id": "a1", "parent_message_id": "u1", "type": "assistant", "content": "hello", "order_index": 3}`,
+			wantEdges: []string{"u1<", "a1<u1"},
+			wantLeaf:  "a1",
+		},
+		{
+			name: "active leaf dropped",
+			leaf: "dropped",
+			messages: `
+				{"id": "u1", "type": "user", "content": "hi", "order_index": 1}`,
+			wantEdges: []string{"u1<"},
+			wantLeaf:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := `{"version": 1, "session": {"id": "s1", "title": "Test", "status": "active", "active_leaf_id": "` + tt.leaf + `"}, "messages": [` + tt.messages + `]}`
+			conversations, err := parseCanonical([]byte(data))
+			if err != nil {
+				t.Fatalf("parseCanonical failed: %v", err)
+			}
+
+			conversation := conversations[0]
+			if got := edges(conversation); !reflect.DeepEqual(got, tt.wantEdges) {
+				t.Errorf("messages = %v, want %v", got, tt.wantEdges)
+			}
+			if conversation.ActiveLeafID != tt.wantLeaf {
+				t.Errorf("ActiveLeafID = %q, want %q", conversation.ActiveLeafID, tt.wantLeaf)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Sourav01112/chat-service/internal/models"
+)
+
+// chatGPTConversation is one entry of ChatGPT's conversations.json. Messages
+// form a tree through the parent links in mapping; current_node is the leaf
+// the user was last looking at.
+type chatGPTConversation struct {
+	ID             string                 `json:"id"`
+	ConversationID string                 `json:"conversation_id"`
+	Title          string                 `json:"title"`
+	CreateTime     *float64               `json:"create_time"`
+	UpdateTime     *float64               `json:"update_time"`
+	CurrentNode    string                 `json:"current_node"`
+	Mapping        map[string]chatGPTNode `json:"mapping"`
+}
+
+type chatGPTNode struct {
+	ID      string          `json:"id"`
+	Parent  *string         `json:"parent"`
+	Message *chatGPTMessage `json:"message"`
+}
+
+type chatGPTMessage struct {
+	Author struct {
+		Role string `json:"role"`
+	} `json:"author"`
+	CreateTime *float64 `json:"create_time"`
+	Content    struct {
+		ContentType string            `json:"content_type"`
+		Parts       []json.RawMessage `json:"parts"`
+		Text        string            `json:"text"`
+	} `json:"content"`
+	Metadata struct {
+		ModelSlug string `json:"model_slug"`
+	} `json:"metadata"`
+}
+
+func parseChatGPT(data []byte) ([]*Conversation, error) {
+	var raw []chatGPTConversation
+	if err := decode(data, &raw); err != nil {
+		return nil, err
+	}
+
+	conversations := make([]*Conversation, 0, len(raw))
+	for _, entry := range raw {
+		conversation, err := entry.convert()
+		if err != nil {
+			return nil, err
+		}
+		conversations = append(conversations, conversation)
+	}
+
+	return conversations, nil
+}
+
+func (c chatGPTConversation) convert() (*Conversation, error) {
+	externalID := c.ConversationID
+	if externalID == "" {
+		externalID = c.ID
+	}
+	if externalID == "" {
+		return nil, fmt.Errorf("conversation %q has no id", c.Title)
+	}
+
+	conversation := &Conversation{
+		Source:     SourceChatGPT,
+		ExternalID: externalID,
+		Title:      c.Title,
+		Status:     models.SessionStatusActive,
+		CreatedAt:  unixTime(c.CreateTime, time.Now().UTC()),
+	}
+	conversation.UpdatedAt = unixTime(c.UpdateTime, conversation.CreatedAt)
+
+	// Nodes without visible text, such as the empty root, hidden system
+	// prompts and tool calls, are skipped and their replies attached to the
+	// nearest ancestor that is kept.
+	kept := make(map[string]*Message)
+	var keptAncestor func(id string, depth int) string
+	keptAncestor = func(id string, depth int) string {
+		node, ok := c.Mapping[id]
+		if !ok || depth > len(c.Mapping) {
+			return ""
+		}
+		if _, ok := kept[id]; ok {
+			return id
+		}
+		if node.Parent == nil {
+			return ""
+		}
+		return keptAncestor(*node.Parent, depth+1)
+	}
+
+	for _, id := range c.nodesParentsFirst() {
+		node := c.Mapping[id]
+		message := node.Message
+		if message == nil {
+			continue
+		}
+
+		messageType, ok := chatGPTRoles[message.Author.Role]
+		content := message.text()
+		if !ok || strings.TrimSpace(content) == "" {
+			continue
+		}
+
+		imported := &Message{
+			ExternalID: id,
+			Type:       messageType,
+			Content:    content,
+			CreatedAt:  unixTime(message.CreateTime, conversation.CreatedAt),
+			Metadata: models.MessageMetadata{
+				SourceCitations: map[string]string{},
+				Tags:            []string{"imported"},
+				ModelUsed:       message.Metadata.ModelSlug,
+				ProcessingSteps: []string{},
+			},
+		}
+		if node.Parent != nil {
+			imported.ParentExternalID = keptAncestor(*node.Parent, 0)
+		}
+
+		kept[id] = imported
+		conversation.Messages = append(conversation.Messages, imported)
+	}
+
+	conversation.ActiveLeafID = keptAncestor(c.CurrentNode, 0)
+
+	return conversation, nil
+}
+
+// nodesParentsFirst orders the mapping so every node follows its parent,
+// with siblings in the order they were written.
+func (c chatGPTConversation) nodesParentsFirst() []string {
+	children := make(map[string][]string)
+	var roots []string
+	for id, node := range c.Mapping {
+		if node.Parent == nil || !c.hasNode(*node.Parent) {
+			roots = append(roots, id)
+			continue
+		}
+		children[*node.Parent] = append(children[*node.Parent], id)
+	}
+
+	byTime := func(ids []string) {
+		sort.Slice(ids, func(i, j int) bool {
+			ti, tj := c.nodeTime(ids[i]), c.nodeTime(ids[j])
+			if ti != tj {
+				return ti < tj
+			}
+			return ids[i] < ids[j]
+		})
+	}
+
+	byTime(roots)
+	ordered := make([]string, 0, len(c.Mapping))
+	queue := roots
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		ordered = append(ordered, id)
+
+		next := children[id]
+		byTime(next)
+		queue = append(queue, next...)
+	}
+
+	return ordered
+}
+
+func (c chatGPTConversation) hasNode(id string) bool {
+	_, ok := c.Mapping[id]
+	return ok
+}
+
+func (c chatGPTConversation) nodeTime(id string) float64 {
+	if message := c.Mapping[id].Message; message != nil && message.CreateTime != nil {
+		return *message.CreateTime
+	}
+	return 0
+}
+
+var chatGPTRoles = map[string]models.MessageType{
+	"user":      models.MessageTypeUser,
+	"assistant": models.MessageTypeAssistant,
+	"system":    models.MessageTypeSystem,
+}
+
+// text joins the string parts of a message. Other parts, such as images,
+// have no text to keep.
+func (m *chatGPTMessage) text() string {
+	if m.Content.Text != "" {
+		return m.Content.Text
+	}
+
+	var parts []string
+	for _, raw := range m.Content.Parts {
+		var part string
+		if err := json.Unmarshal(raw, &part); err == nil && part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func unixTime(seconds *float64, fallback time.Time) time.Time {
+	if seconds == nil || *seconds <= 0 {
+		return fallback
+	}
+	whole, fraction := math.Modf(*seconds)
+	return time.Unix(int64(whole), int64(fraction*1e9)).UTC()
+}
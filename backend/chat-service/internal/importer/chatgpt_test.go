@@ -0,0 +1,139 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// edges lists each imported message as "id<parent", in import order.
+func edges(conversation *Conversation) []string {
+	var out []string
+	for _, message := range conversation.Messages {
+		out = append(out, message.ExternalID+"<"+message.ParentExternalID)
+	}
+	return out
+}
+
+func parseOneChatGPT(t *testing.T, mapping string, currentNode string) *Conversation {
+	t.Helper()
+
+	data := `[{"id": "c1", "title": "Test", "create_time": 1700000000, "current_node": "` + currentNode + `", "mapping": {` + mapping + `}}]`
+	conversations, err := parseChatGPT([]byte(data))
+	if err != nil {
+		t.Fatalf("parseChatGPT failed: %v", err)
+	}
+	if len(conversations) != 1 {
+		t.Fatalf("got %d conversations, want 1", len(conversations))
+	}
+	return conversations[0]
+}
+
+func TestChatGPTConvert(t *testing.T) {
+	tests := []struct {
+		name        string
+		mapping     string
+		currentNode string
+		wantEdges   []string
+		wantLeaf    string
+	}{
+		{
+			name: "linear",
+			mapping: `
+				"root": {"id": "root", "parent": null, "message": null},
+				"u1": {"id": "u1", "parent": "root", "message": {"author": {"role": "user"}, "create_time": 1, "content": {"parts": ["hi"]}}},
+				"a1": {"id": "a1", "parent": "u1", "message": {"author": {"role": "assistant"}, "create_time": 2, "content": {"parts": ["hello"]}}}`,
+			currentNode: "a1",
+			wantEdges:   []string{"u1<", "a1<u1"},
+			wantLeaf:    "a1",
+		},
+		{
+			name: "hidden and empty nodes are re-parented to their kept ancestor",
+			mapping: `
+				"root": {"id": "root", "parent": null, "message": null},
+				"sys": {"id": "sys", "parent": "root", "message": {"author": {"role": "system"}, "create_time": 1, "content": {"parts": [""]}}},
+				"u1": {"id": "u1", "parent": "sys", "message": {"author": {"role": "user"}, "create_time": 2, "content": {"parts": ["hi"]}}},
+				"tool": {"id": "tool", "parent": "u1", "message": {"author": {"role": "tool"}, "create_time": 3, "content": {"parts": ["search results"]}}},
+				"blank": {"id": "blank", "parent": "tool", "message": {"author": {"role": "assistant"}, "create_time": 4, "content": {"parts": ["  "]}}},
+				"a1": {"id": "a1", "parent": "blank", "message": {"author": {"role": "assistant"}, "create_time": 5, "content": {"parts": ["hello"]}}}`,
+			currentNode: "a1",
+			wantEdges:   []string{"u1<", "a1<u1"},
+			wantLeaf:    "a1",
+		},
+		{
+			name: "current node skipped",
+			mapping: `
+				"root": {"id": "root", "parent": null, "message": null},
+				"u1": {"id": "u1", "parent": "root", "message": {"author": {"role": "user"}, "create_time": 1, "content": {"parts": ["hi"]}}},
+				"tool": {"id": "tool", "parent": "u1", "message": {"author": {"role": "tool"}, "create_time": 2, "content": {"parts": ["working"]}}}`,
+			currentNode: "tool",
+			wantEdges:   []string{"u1<"},
+			wantLeaf:    "u1",
+		},
+		{
+			name: "current node missing",
+			mapping: `
+				"u1": {"id": "u1", "parent": null, "message": {"author": {"role": "user"}, "create_time": 1, "content": {"parts": ["hi"]}}}`,
+			currentNode: "gone",
+			wantEdges:   []string{"u1<"},
+			wantLeaf:    "",
+		},
+		{
+			name: "orphan parent",
+			mapping: `
+				"u1": {"id": "u1", "parent": "gone", "message": {"author": {"role": "user"}, "create_time": 1, "content": {"parts": ["hi"]}}},
+				"a1": {"id": "a1", "parent": "u1", "message": {"author": {"role": "assistant"}, "create_time": 2, "content": {"parts": ["hello"]}}}`,
+			currentNode: "a1",
+			wantEdges:   []string{"u1<", "a1<u1"},
+			wantLeaf:    "a1",
+		},
+		{
+			name: "siblings by time then id",
+			mapping: `
+				"u1": {"id": "u1", "parent": null, "message": {"author": {"role": "user"}, "create_time": 1, "content": {"parts": ["hi"]}}},
+				"late": {"id": "late", "parent": "u1", "message": {"author": {"role": "assistant"}, "create_time": 9, "content": {"parts": ["third"]}}},
+				"b": {"id": "b", "parent": "u1", "message": {"author": {"role": "assistant"}, "create_time": 5, "content": {"parts": ["second"]}}},
+				"a": {"id": "a", "parent": "u1", "message": {"author": {"role": "assistant"}, "create_time": 5, "content": {"parts": ["first"]}}}`,
+			currentNode: "b",
+			wantEdges:   []string{"u1<", "a<u1", "b<u1", "late<u1"},
+			wantLeaf:    "b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conversation := parseOneChatGPT(t, tt.mapping, tt.currentNode)
+
+			if got := edges(conversation); !reflect.DeepEqual(got, tt.wantEdges) {
+				t.Errorf("messages = %v, want %v", got, tt.wantEdges)
+			}
+			if conversation.ActiveLeafID != tt.wantLeaf {
+				t.Errorf("ActiveLeafID = %q, want %q", conversation.ActiveLeafID, tt.wantLeaf)
+			}
+		})
+	}
+}
+
+func TestChatGPTNodesParentsFirst(t *testing.T) {
+	conversation := parseOneChatGPT(t, `
+		"a2": {"id": "a2", "parent": "u1", "message": {"author": {"role": "assistant"}, "create_time": 3, "content": {"parts": ["later"]}}},
+		"u2": {"id": "u2", "parent": "a1", "message": {"author": {"role": "user"}, "create_time": 4, "content": {"parts": ["more"]}}},
+		"a1": {"id": "a1", "parent": "u1", "message": {"author": {"role": "assistant"}, "create_time": 2, "content": {"parts": ["sooner"]}}},
+		"u1": {"id": "u1", "parent": "root", "message": {"author": {"role": "user"}, "create_time": 1, "content": {"parts": ["hi"]}}},
+		"root": {"id": "root", "parent": null, "message": null}`, "u2")
+
+	// Every node follows its parent, whatever order the mapping lists them.
+	want := []string{"u1<", "a1<u1", "a2<u1", "u2<a1"}
+	if got := edges(conversation); !reflect.DeepEqual(got, want) {
+		t.Errorf("messages = %v, want %v", got, want)
+	}
+}
+
+func TestChatGPTText(t *testing.T) {
+	conversation := parseOneChatGPT(t, `
+		"u1": {"id": "u1", "parent": null, "message": {"author": {"role": "user"}, "create_time": 1, "content": {"parts": ["look", {"asset_pointer": "file-1"}, "at this"]}}}`, "u1")
+
+	if got := conversation.Messages[0].Content; got != strings.Join([]string{"look", "at this"}, "\n\n") {
+		t.Errorf("Content = %q, want the string parts joined", got)
+	}
+}
@@ -0,0 +1,82 @@
+// Package importer reads conversations exported from other tools, or from
+// chat-service itself, into a common shape the service can store.
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Sourav01112/chat-service/internal/models"
+)
+
+type Source string
+
+const (
+	SourceChatGPT   Source = "chatgpt"
+	SourceCanonical Source = "canonical"
+)
+
+func (s Source) String() string {
+	return string(s)
+}
+
+// Conversation is one imported session. ExternalID identifies it in the
+// source so importing the same file twice can be detected.
+type Conversation struct {
+	Source       Source
+	ExternalID   string
+	Title        string
+	Status       models.SessionStatus
+	Settings     *models.SessionSettings
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	ActiveLeafID string
+	Messages     []*Message
+}
+
+// Message is one imported message. IDs and parent links are the source's
+// own; parents always come before their replies.
+type Message struct {
+	ExternalID       string
+	ParentExternalID string
+	Type             models.MessageType
+	Content          string
+	Metadata         models.MessageMetadata
+	CreatedAt        time.Time
+}
+
+// Parse detects the format of data and reads every conversation in it. An
+// empty source means detect it from the content.
+func Parse(data []byte, source Source) ([]*Conversation, error) {
+	if source == "" {
+		source = detect(data)
+	}
+
+	switch source {
+	case SourceChatGPT:
+		return parseChatGPT(data)
+	case SourceCanonical:
+		return parseCanonical(data)
+	default:
+		return nil, fmt.Errorf("unsupported import format: %s", source)
+	}
+}
+
+// detect tells the formats apart by their top level: ChatGPT exports are an
+// array of conversations, ours a single document.
+func detect(data []byte) Source {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		return SourceChatGPT
+	}
+	return SourceCanonical
+}
+
+func decode(data []byte, v interface{}) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid import file: %w", err)
+	}
+	return nil
+}
@@ -1,7 +1,12 @@
 package models
 
 type MessageType string
+type MessageStatus string
 type SessionStatus string
+type EventType string
+type TitleSource string
+type ShareMode string
+type MemberRole string
 
 const (
     MessageTypeUser      MessageType = "user"
@@ -9,20 +14,85 @@ const (
     MessageTypeSystem    MessageType = "system"
 )
 
+const (
+    MessageStatusComplete   MessageStatus = "complete"
+    MessageStatusStreaming  MessageStatus = "streaming"
+    MessageStatusIncomplete MessageStatus = "incomplete"
+)
+
 const (
     SessionStatusActive   SessionStatus = "active"
     SessionStatusPaused   SessionStatus = "paused"
     SessionStatusArchived SessionStatus = "archived"
 )
 
+// A session's title is generated only while its source is default; titles
+// the user typed are never replaced.
+const (
+    TitleSourceDefault   TitleSource = "default"
+    TitleSourceGenerated TitleSource = "generated"
+    TitleSourceUser      TitleSource = "user"
+)
+
+// A snapshot share shows the session as it was when the link was created; a
+// live share follows its active branch.
+const (
+    ShareModeSnapshot ShareMode = "snapshot"
+    ShareModeLive     ShareMode = "live"
+)
+
+// Every session has exactly one owner. Editors can write to it, viewers can
+// only read it.
+const (
+    MemberRoleOwner  MemberRole = "owner"
+    MemberRoleEditor MemberRole = "editor"
+    MemberRoleViewer MemberRole = "viewer"
+)
+
+const (
+    EventTypeMessageCreated         EventType = "message_created"
+    EventTypeMessageUpdated         EventType = "message_updated"
+    EventTypeMessageDeleted         EventType = "message_deleted"
+    EventTypeMessageEdited          EventType = "message_edited"
+    EventTypeMessageRestored        EventType = "message_restored"
+    EventTypeTypingStarted          EventType = "typing_started"
+    EventTypeTypingStopped          EventType = "typing_stopped"
+    EventTypeSessionSettingsChanged EventType = "session_settings_changed"
+    EventTypeSessionStatusChanged   EventType = "session_status_changed"
+    EventTypeSessionUpdated         EventType = "session_updated"
+    EventTypeActiveBranchChanged    EventType = "active_branch_changed"
+    EventTypeMemberAdded            EventType = "member_added"
+    EventTypeMemberRemoved          EventType = "member_removed"
+)
+
 func (m MessageType) String() string {
     return string(m)
 }
 
+func (m MessageStatus) String() string {
+    return string(m)
+}
+
 func (s SessionStatus) String() string {
     return string(s)
 }
 
+func (e EventType) String() string {
+    return string(e)
+}
+
+func (t TitleSource) String() string {
+    return string(t)
+}
+
+func (m ShareMode) String() string {
+    return string(m)
+}
+
+func (r MemberRole) String() string {
+    return string(r)
+}
+
 func (m MessageType) IsValid() bool {
     switch m {
     case MessageTypeUser, MessageTypeAssistant, MessageTypeSystem:
@@ -32,6 +102,15 @@ func (m MessageType) IsValid() bool {
     }
 }
 
+func (m MessageStatus) IsValid() bool {
+    switch m {
+    case MessageStatusComplete, MessageStatusStreaming, MessageStatusIncomplete:
+        return true
+    default:
+        return false
+    }
+}
+
 func (s SessionStatus) IsValid() bool {
     switch s {
     case SessionStatusActive, SessionStatusPaused, SessionStatusArchived:
@@ -39,4 +118,22 @@ func (s SessionStatus) IsValid() bool {
     default:
         return false
     }
-}
\ No newline at end of file
+}
+
+func (m ShareMode) IsValid() bool {
+    switch m {
+    case ShareModeSnapshot, ShareModeLive:
+        return true
+    default:
+        return false
+    }
+}
+
+func (r MemberRole) IsValid() bool {
+    switch r {
+    case MemberRoleOwner, MemberRoleEditor, MemberRoleViewer:
+        return true
+    default:
+        return false
+    }
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// SessionEvent is a change to a session that is fanned out to watchers. ID is
+// assigned when the event is published and doubles as the resume cursor.
+type SessionEvent struct {
+	ID        string         `json:"id"`
+	SessionID string         `json:"session_id"`
+	Type      EventType      `json:"type"`
+	UserID    string         `json:"user_id,omitempty"`
+	MessageID string         `json:"message_id,omitempty"`
+	Message   *Message       `json:"message,omitempty"`
+	Session   *Session       `json:"session,omitempty"`
+	Member    *SessionMember `json:"member,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+func NewMessageEvent(eventType EventType, message *Message) *SessionEvent {
+	return &SessionEvent{
+		SessionID: message.SessionID,
+		Type:      eventType,
+		UserID:    message.UserID,
+		MessageID: message.ID,
+		Message:   message,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+func NewSessionEvent(eventType EventType, session *Session) *SessionEvent {
+	return &SessionEvent{
+		SessionID: session.ID,
+		Type:      eventType,
+		UserID:    session.UserID,
+		Session:   session,
+		CreatedAt: time.Now().UTC(),
+	}
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Operations an idempotency key can be given for. Keys are scoped to the
+// user and the operation, so the same key may be reused across them.
+const (
+	IdempotencyScopeCreateSession = "create_session"
+	IdempotencyScopeSendMessage   = "send_message"
+
+	// IdempotencyScopeSendMessageAndReply covers a user message and the
+	// reply to it, whether streamed or not.
+	IdempotencyScopeSendMessageAndReply = "send_message_and_reply"
+)
+
+// IdempotencyKey remembers the first request made with a key so that a retry
+// gets the same result. ResourceID is nil while that request is still
+// running; RequestHash tells a retry from a different request reusing the key.
+type IdempotencyKey struct {
+	UserID      string    `gorm:"type:uuid;primaryKey" json:"user_id"`
+	Scope       string    `gorm:"type:varchar(32);primaryKey" json:"scope"`
+	Key         string    `gorm:"size:255;primaryKey" json:"key"`
+	RequestHash string    `gorm:"size:64;not null" json:"request_hash"`
+	ResourceID  *string   `gorm:"type:uuid" json:"resource_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `gorm:"not null;index" json:"expires_at"`
+}
+
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
+
+// IsComplete reports whether the first request finished and left a result.
+func (k *IdempotencyKey) IsComplete() bool {
+	return k.ResourceID != nil
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// SessionMember gives a user a role in someone else's session. The owner has
+// a row too, written when the session is created.
+type SessionMember struct {
+	SessionID string     `gorm:"type:uuid;primaryKey" json:"session_id"`
+	UserID    string     `gorm:"type:uuid;primaryKey;index" json:"user_id"`
+	Role      MemberRole `gorm:"type:varchar(20);not null" json:"role"`
+	InvitedBy *string    `gorm:"type:uuid" json:"invited_by,omitempty"`
+	FolderID  *string    `gorm:"type:uuid" json:"folder_id,omitempty"`
+	Pinned    bool       `gorm:"not null;default:false" json:"pinned"`
+	Favorite  bool       `gorm:"not null;default:false" json:"favorite"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+func (SessionMember) TableName() string {
+	return "session_members"
+}
+
+// NewOwnerMember is the membership row every session starts with.
+func NewOwnerMember(session *Session) *SessionMember {
+	return &SessionMember{
+		SessionID: session.ID,
+		UserID:    session.UserID,
+		Role:      MemberRoleOwner,
+	}
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"fmt"
 	"strings"
 	"time"
 
@@ -20,6 +21,18 @@ type Message struct {
 	CreatedAt       time.Time       `gorm:"index" json:"created_at"`
 	ParentMessageID *string         `gorm:"type:uuid;index" json:"parent_message_id,omitempty"`
 	OrderIndex      int             `gorm:"not null;index" json:"order_index"`
+	Status          MessageStatus   `gorm:"type:varchar(20);default:'complete'" json:"status"`
+	EditedAt        *time.Time      `json:"edited_at,omitempty"`
+
+	// DeletedAt is set while the message is in the trash. Its replies are
+	// handed to its parent meanwhile; ReparentedIDs remembers which, so that
+	// restoring the message takes them back.
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+	ReparentedIDs MessageIDs     `gorm:"type:jsonb" json:"-"`
+
+	// SiblingIDs lists the alternatives sharing this message's parent,
+	// including itself, oldest first. Only set when there is more than one.
+	SiblingIDs []string `gorm:"-" json:"sibling_ids,omitempty"`
 
 	Session       Session   `gorm:"foreignKey:SessionID;references:ID" json:"session,omitempty"`
 	ParentMessage *Message  `gorm:"foreignKey:ParentMessageID;references:ID" json:"parent_message,omitempty"`
@@ -36,20 +49,40 @@ type MessageMetadata struct {
 	ProcessingSteps []string          `json:"processing_steps"`
 }
 
+// MessageTagPinned marks a message that must stay in the AI prompt however
+// old it gets.
+const MessageTagPinned = "pinned"
+
 func (m *Message) BeforeCreate(tx *gorm.DB) error {
 	if m.ID == "" {
 		m.ID = uuid.New().String()
 	}
 
-	if m.OrderIndex == 0 {
-		var maxOrder int
-		tx.Model(&Message{}).
-			Where("session_id = ?", m.SessionID).
-			Select("COALESCE(MAX(order_index), 0)").
-			Scan(&maxOrder)
-		m.OrderIndex = maxOrder + 1
+	return m.assignOrderIndex(tx)
+}
+
+// assignOrderIndex gives a new message the next position from the
+// session's last_order_index counter. The UPDATE locks the session row until
+// the insert commits, so concurrent sends to one session queue up instead of
+// reading the same MAX(order_index). Messages that arrive with a position
+// already, as imported and forked ones do, must move the counter themselves.
+func (m *Message) assignOrderIndex(tx *gorm.DB) error {
+	if m.OrderIndex != 0 {
+		return nil
+	}
+
+	var next int
+	err := tx.Raw(`UPDATE sessions SET last_order_index = last_order_index + 1
+		WHERE id = ? RETURNING last_order_index`, m.SessionID).
+		Scan(&next).Error
+	if err != nil {
+		return fmt.Errorf("failed to assign order index: %w", err)
+	}
+	if next == 0 {
+		return fmt.Errorf("failed to assign order index: session %s not found", m.SessionID)
 	}
 
+	m.OrderIndex = next
 	return nil
 }
 
@@ -82,6 +115,25 @@ func (m MessageMetadata) Value() (driver.Value, error) {
 	return json.Marshal(m)
 }
 
+// MessageIDs is a list of message IDs kept in a jsonb column. An empty list
+// is stored as NULL.
+type MessageIDs []string
+
+func (ids *MessageIDs) Scan(value interface{}) error {
+	if value == nil {
+		*ids = nil
+		return nil
+	}
+	return json.Unmarshal(value.([]byte), ids)
+}
+
+func (ids MessageIDs) Value() (driver.Value, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return json.Marshal([]string(ids))
+}
+
 func (m *Message) IsUserMessage() bool {
 	return m.Type == MessageTypeUser
 }
@@ -94,6 +146,10 @@ func (m *Message) IsSystemMessage() bool {
 	return m.Type == MessageTypeSystem
 }
 
+func (m *Message) IsComplete() bool {
+	return m.Status == "" || m.Status == MessageStatusComplete
+}
+
 func (m *Message) GetWordCount() int {
 	if m.Content == "" {
 		return 0
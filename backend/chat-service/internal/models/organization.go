@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Folder groups a user's sessions. Folders and tags belong to the user, not
+// the session, so members of a shared session each organize it their own
+// way.
+type Folder struct {
+	ID        string    `gorm:"type:uuid;primary_key" json:"id"`
+	UserID    string    `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name      string    `gorm:"size:100;not null" json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Tag struct {
+	ID        string    `gorm:"type:uuid;primary_key" json:"id"`
+	UserID    string    `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name      string    `gorm:"size:50;not null" json:"name"`
+	Color     string    `gorm:"size:20" json:"color,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SessionTag puts one of a user's tags on a session.
+type SessionTag struct {
+	SessionID string    `gorm:"type:uuid;primaryKey" json:"session_id"`
+	UserID    string    `gorm:"type:uuid;primaryKey" json:"user_id"`
+	TagID     string    `gorm:"type:uuid;primaryKey" json:"tag_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (f *Folder) BeforeCreate(tx *gorm.DB) error {
+	if f.ID == "" {
+		f.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (Folder) TableName() string {
+	return "folders"
+}
+
+func (t *Tag) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (Tag) TableName() string {
+	return "tags"
+}
+
+func (SessionTag) TableName() string {
+	return "session_tags"
+}
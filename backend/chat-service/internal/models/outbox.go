@@ -0,0 +1,121 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Outbox event types. They are the activity types user-service's analytics
+// count.
+const (
+	OutboxTypeSessionStart = "session_start"
+	OutboxTypeMessageSent  = "message_sent"
+)
+
+// outboxNamespace derives event IDs from what they describe, so writing the
+// same event twice yields the same ID.
+var outboxNamespace = uuid.MustParse("6f1c7a52-3b0e-4d1f-9c8e-2a5b7d9e4f10")
+
+// OutboxEvent is something chat-service owes user-service's analytics. It is
+// written in the same transaction as the change it describes and delivered
+// later, in ID order per session. DeliveredAt is set once user-service took
+// it; FailedAt once delivery was given up.
+type OutboxEvent struct {
+	ID            int64         `gorm:"primaryKey;autoIncrement" json:"id"`
+	EventID       string        `gorm:"type:uuid;not null;uniqueIndex" json:"event_id"`
+	SessionID     string        `gorm:"type:uuid;not null;index" json:"session_id"`
+	UserID        string        `gorm:"type:uuid;not null" json:"user_id"`
+	Type          string        `gorm:"type:varchar(50);not null" json:"type"`
+	Payload       OutboxPayload `gorm:"type:jsonb" json:"payload"`
+	Attempts      int           `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time     `gorm:"not null" json:"next_attempt_at"`
+	LastError     string        `gorm:"type:text" json:"last_error,omitempty"`
+	DeliveredAt   *time.Time    `json:"delivered_at,omitempty"`
+	FailedAt      *time.Time    `json:"failed_at,omitempty"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
+
+// OutboxPayload is the metadata sent along with an event.
+type OutboxPayload map[string]string
+
+func (p *OutboxPayload) Scan(value interface{}) error {
+	if value == nil {
+		*p = OutboxPayload{}
+		return nil
+	}
+	return json.Unmarshal(value.([]byte), p)
+}
+
+func (p OutboxPayload) Value() (driver.Value, error) {
+	if p == nil {
+		p = OutboxPayload{}
+	}
+	return json.Marshal(map[string]string(p))
+}
+
+// NewSessionStartEvent records that session was created, imported or forked.
+func NewSessionStartEvent(session *Session) *OutboxEvent {
+	return newOutboxEvent(OutboxTypeSessionStart, session.ID, session.ID, session.UserID, OutboxPayload{
+		"source": sessionSource(session),
+	})
+}
+
+// NewMessageSentEvent records a message once it is settled, with the tokens
+// it used. A streamed reply only counts when it stops streaming.
+func NewMessageSentEvent(message *Message) *OutboxEvent {
+	payload := OutboxPayload{
+		"message_id":   message.ID,
+		"message_type": message.Type.String(),
+		"token_count":  strconv.Itoa(message.Metadata.TokenCount),
+	}
+	if message.Metadata.ModelUsed != "" {
+		payload["model"] = message.Metadata.ModelUsed
+	}
+
+	return newOutboxEvent(OutboxTypeMessageSent, message.ID, message.SessionID, message.UserID, payload)
+}
+
+// NewCopiedMessageEvent records a message that arrived with an imported or
+// forked session. It counts as sent by the session's owner, who made the
+// copy, and its source tells it apart from messages written in the session.
+func NewCopiedMessageEvent(session *Session, message *Message) *OutboxEvent {
+	event := NewMessageSentEvent(message)
+	event.UserID = session.UserID
+	event.Payload["source"] = sessionSource(session)
+	return event
+}
+
+func sessionSource(session *Session) string {
+	switch {
+	case session.ImportSource != nil:
+		return "import"
+	case session.ForkedFromSessionID != nil:
+		return "fork"
+	default:
+		return "created"
+	}
+}
+
+func newOutboxEvent(eventType string, subjectID string, sessionID string, userID string, payload OutboxPayload) *OutboxEvent {
+	return &OutboxEvent{
+		EventID:       uuid.NewSHA1(outboxNamespace, []byte(eventType+":"+subjectID)).String(),
+		SessionID:     sessionID,
+		UserID:        userID,
+		Type:          eventType,
+		Payload:       payload,
+		NextAttemptAt: time.Now(),
+	}
+}
+
+// IsPending reports whether the event still has to be delivered.
+func (e *OutboxEvent) IsPending() bool {
+	return e.DeliveredAt == nil && e.FailedAt == nil
+}
@@ -0,0 +1,147 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type PageDirection string
+
+const (
+	PageDirectionOlder PageDirection = "older"
+	PageDirectionNewer PageDirection = "newer"
+)
+
+func (d PageDirection) IsValid() bool {
+	switch d {
+	case PageDirectionOlder, PageDirectionNewer:
+		return true
+	default:
+		return false
+	}
+}
+
+// MessageCursor marks a position in a session's message order. Pages are
+// read strictly before (older) or after (newer) it. Search cursors also carry
+// the rank, since search results are ordered by relevance first.
+type MessageCursor struct {
+	SessionID  string   `json:"s"`
+	OrderIndex int      `json:"o"`
+	Rank       *float32 `json:"r,omitempty"`
+}
+
+// SessionSort picks the time a user's sessions are listed by, most recent
+// first. Pinned sessions always come before the rest.
+type SessionSort string
+
+const (
+	SessionSortLastActivity SessionSort = "last_activity"
+	SessionSortCreatedAt    SessionSort = "created_at"
+)
+
+func (s SessionSort) IsValid() bool {
+	switch s {
+	case SessionSortLastActivity, SessionSortCreatedAt:
+		return true
+	default:
+		return false
+	}
+}
+
+// Column is the sessions column the sort orders by.
+func (s SessionSort) Column() string {
+	if s == SessionSortCreatedAt {
+		return "created_at"
+	}
+	return "last_activity"
+}
+
+func (s SessionSort) timeOf(session *Session) time.Time {
+	if s == SessionSortCreatedAt {
+		return session.CreatedAt
+	}
+	return session.LastActivity
+}
+
+// SessionCursor marks a position in a user's sessions ordered by (pinned,
+// time, id), where time is the sort's column. Sort is empty for
+// last_activity so cursors issued before sorting existed stay valid.
+type SessionCursor struct {
+	Sort   SessionSort `json:"o,omitempty"`
+	Pinned bool        `json:"p,omitempty"`
+	Time   time.Time   `json:"t"`
+	ID     string      `json:"i"`
+}
+
+func NewMessageCursor(message *Message) *MessageCursor {
+	return &MessageCursor{SessionID: message.SessionID, OrderIndex: message.OrderIndex}
+}
+
+func NewSearchCursor(result *SearchResult) *MessageCursor {
+	rank := result.Rank
+	return &MessageCursor{SessionID: result.Message.SessionID, OrderIndex: result.Message.OrderIndex, Rank: &rank}
+}
+
+func NewSessionCursor(session *Session, sort SessionSort) *SessionCursor {
+	cursor := &SessionCursor{Pinned: session.Pinned, Time: sort.timeOf(session), ID: session.ID}
+	if sort != SessionSortLastActivity {
+		cursor.Sort = sort
+	}
+	return cursor
+}
+
+// SortOrDefault returns the sort the cursor was issued for.
+func (c *SessionCursor) SortOrDefault() SessionSort {
+	if c.Sort == "" {
+		return SessionSortLastActivity
+	}
+	return c.Sort
+}
+
+func (c *MessageCursor) Encode() string {
+	return encodeCursor(c)
+}
+
+func (c *SessionCursor) Encode() string {
+	return encodeCursor(c)
+}
+
+func DecodeMessageCursor(token string) (*MessageCursor, error) {
+	var cursor MessageCursor
+	if err := decodeCursor(token, &cursor); err != nil {
+		return nil, err
+	}
+	if cursor.SessionID == "" {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return &cursor, nil
+}
+
+func DecodeSessionCursor(token string) (*SessionCursor, error) {
+	var cursor SessionCursor
+	if err := decodeCursor(token, &cursor); err != nil {
+		return nil, err
+	}
+	if cursor.ID == "" {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return &cursor, nil
+}
+
+func encodeCursor(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(token string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return fmt.Errorf("invalid cursor")
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid cursor")
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MessageRevision is the content a message had before an edit. Revisions are
+// numbered from 1 per message; the current content lives on the message.
+type MessageRevision struct {
+	ID        string          `gorm:"type:uuid;primary_key" json:"id"`
+	MessageID string          `gorm:"type:uuid;not null;index" json:"message_id"`
+	Revision  int             `gorm:"not null" json:"revision"`
+	Content   string          `gorm:"type:text;not null" json:"content"`
+	Metadata  MessageMetadata `gorm:"type:jsonb" json:"metadata"`
+	EditedBy  string          `gorm:"type:uuid;not null" json:"edited_by"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+func (r *MessageRevision) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (MessageRevision) TableName() string {
+	return "message_revisions"
+}
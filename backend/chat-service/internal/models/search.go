@@ -0,0 +1,101 @@
+package models
+
+import "strings"
+
+const DefaultTextSearchConfig = "english"
+
+// SearchResult is a message matched by full-text search together with its
+// relevance and a highlighted excerpt of the matching content.
+type SearchResult struct {
+	Message *Message `json:"message"`
+	Rank    float32  `json:"rank"`
+	Snippet string   `json:"snippet"`
+
+	// Set by cross-session search only.
+	SessionTitle  string        `json:"session_title,omitempty"`
+	SessionStatus SessionStatus `json:"session_status,omitempty"`
+	Before        []*Message    `json:"before,omitempty"`
+	After         []*Message    `json:"after,omitempty"`
+}
+
+// SessionSearchResults groups the cross-session search hits that fall in one
+// session, best match first.
+type SessionSearchResults struct {
+	SessionID     string          `json:"session_id"`
+	SessionTitle  string          `json:"session_title"`
+	SessionStatus SessionStatus   `json:"session_status"`
+	Results       []*SearchResult `json:"results"`
+}
+
+// GroupSearchResults groups results by session. Sessions are ordered by their
+// best hit, so ranked input keeps the most relevant conversation first.
+func GroupSearchResults(results []*SearchResult) []*SessionSearchResults {
+	var groups []*SessionSearchResults
+	bySession := make(map[string]*SessionSearchResults)
+
+	for _, result := range results {
+		group, ok := bySession[result.Message.SessionID]
+		if !ok {
+			group = &SessionSearchResults{
+				SessionID:     result.Message.SessionID,
+				SessionTitle:  result.SessionTitle,
+				SessionStatus: result.SessionStatus,
+			}
+			bySession[group.SessionID] = group
+			groups = append(groups, group)
+		}
+		group.Results = append(group.Results, result)
+	}
+
+	return groups
+}
+
+// textSearchConfigs maps user language codes to the PostgreSQL text search
+// configurations shipped with a stock install.
+var textSearchConfigs = map[string]string{
+	"ar": "arabic",
+	"da": "danish",
+	"de": "german",
+	"el": "greek",
+	"en": "english",
+	"es": "spanish",
+	"fi": "finnish",
+	"fr": "french",
+	"ga": "irish",
+	"hu": "hungarian",
+	"id": "indonesian",
+	"it": "italian",
+	"lt": "lithuanian",
+	"ne": "nepali",
+	"nl": "dutch",
+	"no": "norwegian",
+	"nb": "norwegian",
+	"nn": "norwegian",
+	"pt": "portuguese",
+	"ro": "romanian",
+	"ru": "russian",
+	"sv": "swedish",
+	"ta": "tamil",
+	"tr": "turkish",
+}
+
+// TextSearchConfig resolves a language preference such as "en" or "pt-BR" to
+// a text search configuration. Languages without stemming support fall back
+// to "simple", and an empty preference to English.
+func TextSearchConfig(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if language == "" {
+		return DefaultTextSearchConfig
+	}
+
+	if base, _, found := strings.Cut(language, "-"); found {
+		language = base
+	} else if base, _, found := strings.Cut(language, "_"); found {
+		language = base
+	}
+
+	if config, ok := textSearchConfigs[language]; ok {
+		return config
+	}
+	return "simple"
+}
@@ -13,11 +13,43 @@ type Session struct {
 	ID           string          `gorm:"type:uuid;primary_key" json:"id"`
 	UserID       string          `gorm:"type:uuid;not null;index" json:"user_id"`
 	Title        string          `gorm:"size:200;not null" json:"title"`
+	TitleSource  TitleSource     `gorm:"type:varchar(20);default:'user'" json:"title_source"`
 	Status       SessionStatus   `gorm:"type:varchar(20);default:'active'" json:"status"`
 	Settings     SessionSettings `gorm:"type:jsonb" json:"settings"`
 	CreatedAt    time.Time       `json:"created_at"`
 	UpdatedAt    time.Time       `json:"updated_at"`
 	LastActivity time.Time       `gorm:"index" json:"last_activity"`
+	ActiveLeafID *string         `gorm:"type:uuid" json:"active_leaf_id,omitempty"`
+
+	// ArchivedAt is when the session was archived, by its owner, by idle
+	// session retention or by going to the trash.
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+
+	// TrashedAt is when the session was deleted. Only deleted sessions are
+	// in the trash, and they are purged once it is older than the trash
+	// retention period.
+	TrashedAt *time.Time `json:"trashed_at,omitempty"`
+
+	// ImportSource and ImportExternalID identify where an imported session
+	// came from, so importing it again is recognised. Both are nil for
+	// sessions created here.
+	ImportSource     *string `gorm:"type:varchar(20)" json:"import_source,omitempty"`
+	ImportExternalID *string `gorm:"size:255" json:"import_external_id,omitempty"`
+
+	// ForkedFromSessionID and ForkedFromMessageID record the session and
+	// message a fork was copied from. Metadata lists the forks made from
+	// this session in turn.
+	ForkedFromSessionID *string         `gorm:"type:uuid;index" json:"forked_from_session_id,omitempty"`
+	ForkedFromMessageID *string         `gorm:"type:uuid" json:"forked_from_message_id,omitempty"`
+	Metadata            SessionMetadata `gorm:"type:jsonb" json:"metadata"`
+
+	// Pinned, Favorite, FolderID and Tags are how the user listing the
+	// session has organized it. They live on that user's membership and are
+	// only filled in by session lists and OrganizeSession.
+	Pinned   bool    `gorm:"->" json:"pinned"`
+	Favorite bool    `gorm:"->" json:"favorite"`
+	FolderID *string `gorm:"->" json:"folder_id,omitempty"`
+	Tags     []*Tag  `gorm:"-" json:"tags,omitempty"`
 
 	Messages []Message `gorm:"foreignKey:SessionID;constraint:OnDelete:CASCADE" json:"messages,omitempty"`
 }
@@ -31,6 +63,21 @@ type SessionSettings struct {
 	SystemPrompt    string   `json:"system_prompt"`
 }
 
+// SessionMetadata is what the service records about a session beyond its
+// settings. It is only ever appended to, through the repository, so saving a
+// session never writes it.
+type SessionMetadata struct {
+	Forks []SessionFork `json:"forks,omitempty"`
+}
+
+// SessionFork is one fork made from a session.
+type SessionFork struct {
+	SessionID string    `json:"session_id"`
+	MessageID string    `json:"message_id"`
+	UserID    string    `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 func (s *Session) BeforeCreate(tx *gorm.DB) error {
 	if s.ID == "" {
 		s.ID = uuid.New().String()
@@ -57,14 +104,48 @@ func (s SessionSettings) Value() (driver.Value, error) {
 	return json.Marshal(s)
 }
 
+func (m *SessionMetadata) Scan(value interface{}) error {
+	if value == nil {
+		*m = SessionMetadata{}
+		return nil
+	}
+	return json.Unmarshal(value.([]byte), m)
+}
+
+func (m SessionMetadata) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// SetStatus changes the session's status, keeping ArchivedAt in step. A
+// session that leaves archived also leaves the trash.
+func (s *Session) SetStatus(status SessionStatus) {
+	switch {
+	case status != SessionStatusArchived:
+		s.ArchivedAt = nil
+		s.TrashedAt = nil
+	case s.Status != SessionStatusArchived || s.ArchivedAt == nil:
+		now := time.Now()
+		s.ArchivedAt = &now
+	}
+	s.Status = status
+}
+
 func (s *Session) UpdateLastActivity() {
 	s.LastActivity = time.Now()
 }
 
+// DefaultSessionTitle is shown until a title is generated for a session
+// created without one.
+const DefaultSessionTitle = "New chat"
+
 func (s *Session) IsActive() bool {
 	return s.Status == SessionStatusActive
 }
 
+func (s *Session) HasDefaultTitle() bool {
+	return s.TitleSource == TitleSourceDefault
+}
+
 func GetDefaultSettings() SessionSettings {
 	return SessionSettings{
 		AIPersona:       "assistant",
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ShareLink gives anyone holding Token read-only access to a session. Only
+// snapshot links carry a Snapshot; live links read the session each time.
+type ShareLink struct {
+	ID               string         `gorm:"type:uuid;primary_key" json:"id"`
+	SessionID        string         `gorm:"type:uuid;not null;index" json:"session_id"`
+	UserID           string         `gorm:"type:uuid;not null" json:"user_id"`
+	Token            string         `gorm:"size:64;not null;uniqueIndex" json:"token"`
+	Mode             ShareMode      `gorm:"type:varchar(20);not null" json:"mode"`
+	IncludeCitations bool           `gorm:"not null" json:"include_citations"`
+	Snapshot         *ShareSnapshot `gorm:"type:jsonb" json:"-"`
+	ViewCount        int64          `gorm:"not null;default:0" json:"view_count"`
+	ExpiresAt        *time.Time     `json:"expires_at,omitempty"`
+	RevokedAt        *time.Time     `json:"revoked_at,omitempty"`
+	LastViewedAt     *time.Time     `json:"last_viewed_at,omitempty"`
+	CreatedAt        time.Time      `json:"created_at"`
+}
+
+// SharedMessage is a message as shown through a share link. It leaves out
+// who wrote it and everything else only the owner should see.
+type SharedMessage struct {
+	ID              string            `json:"id"`
+	Content         string            `json:"content"`
+	Type            MessageType       `json:"type"`
+	CreatedAt       time.Time         `json:"created_at"`
+	ParentMessageID *string           `json:"parent_message_id,omitempty"`
+	OrderIndex      int               `json:"order_index"`
+	ModelUsed       string            `json:"model_used,omitempty"`
+	SourceCitations map[string]string `json:"source_citations,omitempty"`
+}
+
+// ShareSnapshot is the active branch of a session at the time a snapshot
+// link was created.
+type ShareSnapshot struct {
+	Title     string           `json:"title"`
+	CreatedAt time.Time        `json:"created_at"`
+	Messages  []*SharedMessage `json:"messages"`
+}
+
+// SharedSession is what a share link shows.
+type SharedSession struct {
+	Title     string
+	Mode      ShareMode
+	CreatedAt time.Time
+	SharedAt  time.Time
+	ViewCount int64
+	Messages  []*SharedMessage
+}
+
+func (l *ShareLink) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == "" {
+		l.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (ShareLink) TableName() string {
+	return "share_links"
+}
+
+// IsUsable reports whether the link may still be opened at now.
+func (l *ShareLink) IsUsable(now time.Time) bool {
+	if l.RevokedAt != nil {
+		return false
+	}
+	return l.ExpiresAt == nil || now.Before(*l.ExpiresAt)
+}
+
+// NewSharedMessage copies what a share link may show of message. System
+// messages are never shared, so callers skip them before calling this.
+func NewSharedMessage(message *Message, includeCitations bool) *SharedMessage {
+	shared := &SharedMessage{
+		ID:              message.ID,
+		Content:         message.Content,
+		Type:            message.Type,
+		CreatedAt:       message.CreatedAt,
+		ParentMessageID: message.ParentMessageID,
+		OrderIndex:      message.OrderIndex,
+		ModelUsed:       message.Metadata.ModelUsed,
+	}
+	if includeCitations && len(message.Metadata.SourceCitations) > 0 {
+		shared.SourceCitations = message.Metadata.SourceCitations
+	}
+	return shared
+}
+
+func (s *ShareSnapshot) Scan(value interface{}) error {
+	if value == nil {
+		*s = ShareSnapshot{}
+		return nil
+	}
+	return json.Unmarshal(value.([]byte), s)
+}
+
+func (s ShareSnapshot) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
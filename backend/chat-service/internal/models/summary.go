@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SessionSummary condenses a session's branch from the root up to and
+// including ThroughMessageID, so prompts can carry it instead of the raw
+// turns. Each new summary of a session gets the next Version. Editing or
+// deleting a message the summary covers sets InvalidatedAt, and the summary
+// is then only kept for history.
+type SessionSummary struct {
+	ID                string     `gorm:"type:uuid;primary_key" json:"id"`
+	SessionID         string     `gorm:"type:uuid;not null;index" json:"session_id"`
+	Version           int        `gorm:"not null" json:"version"`
+	ThroughMessageID  string     `gorm:"type:uuid;not null" json:"through_message_id"`
+	ThroughOrderIndex int        `gorm:"not null" json:"through_order_index"`
+	Content           string     `gorm:"type:text;not null" json:"content"`
+	MessageCount      int        `gorm:"not null" json:"message_count"`
+	TokenCount        int        `json:"token_count"`
+	ModelUsed         string     `gorm:"size:100" json:"model_used"`
+	CreatedAt         time.Time  `json:"created_at"`
+	InvalidatedAt     *time.Time `json:"invalidated_at,omitempty"`
+}
+
+func (s *SessionSummary) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (SessionSummary) TableName() string {
+	return "session_summaries"
+}
+
+func (s *SessionSummary) IsValid() bool {
+	return s.InvalidatedAt == nil
+}
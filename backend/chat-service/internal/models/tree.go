@@ -0,0 +1,40 @@
+package models
+
+// MessageNode is a message in a session's conversation tree. Active marks the
+// nodes on the path from the root to the session's active leaf.
+type MessageNode struct {
+	Message  *Message       `json:"message"`
+	Active   bool           `json:"active"`
+	Children []*MessageNode `json:"children,omitempty"`
+}
+
+// BuildMessageTree links messages into trees through ParentMessageID. The
+// messages must be ordered by order_index so parents come before children;
+// messages whose parent is missing become roots.
+func BuildMessageTree(messages []*Message, activeLeafID string) []*MessageNode {
+	var roots []*MessageNode
+	nodes := make(map[string]*MessageNode, len(messages))
+
+	for _, message := range messages {
+		node := &MessageNode{Message: message}
+		nodes[message.ID] = node
+
+		if message.ParentMessageID != nil {
+			if parent, ok := nodes[*message.ParentMessageID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+
+	for node := nodes[activeLeafID]; node != nil; {
+		node.Active = true
+		if node.Message.ParentMessageID == nil {
+			break
+		}
+		node = nodes[*node.Message.ParentMessageID]
+	}
+
+	return roots
+}
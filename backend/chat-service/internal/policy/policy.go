@@ -0,0 +1,52 @@
+// Package policy decides what a session member may do. The service looks up
+// the caller's role and asks here, so the rules live in one place rather
+// than in every query.
+package policy
+
+import "github.com/Sourav01112/chat-service/internal/models"
+
+type Action string
+
+const (
+	// ActionRead covers everything that only looks at a session: history,
+	// search, the message tree, exports and watching for events.
+	ActionRead Action = "read"
+
+	// ActionWrite covers adding to a session: sending, regenerating and
+	// editing messages, switching branches and typing indicators.
+	ActionWrite Action = "write"
+
+	// ActionManage covers the session itself: its title and settings,
+	// deleting it, sharing it and its members.
+	ActionManage Action = "manage"
+)
+
+func (a Action) String() string {
+	return string(a)
+}
+
+// Allows reports whether a member with role may perform action. An empty
+// role means the user is not a member and is allowed nothing.
+func Allows(role models.MemberRole, action Action) bool {
+	switch role {
+	case models.MemberRoleOwner:
+		return true
+	case models.MemberRoleEditor:
+		return action == ActionRead || action == ActionWrite
+	case models.MemberRoleViewer:
+		return action == ActionRead
+	default:
+		return false
+	}
+}
+
+// CanModifyMessage reports whether userID, holding role, may edit or delete
+// message. Editors may only change messages they wrote or replies they
+// asked for, both of which carry their user ID; the owner may change any
+// message.
+func CanModifyMessage(role models.MemberRole, userID string, message *models.Message) bool {
+	if !Allows(role, ActionWrite) {
+		return false
+	}
+	return role == models.MemberRoleOwner || message.UserID == userID
+}
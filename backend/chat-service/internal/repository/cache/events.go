@@ -0,0 +1,191 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/Sourav01112/chat-service/internal/models"
+	"github.com/Sourav01112/chat-service/internal/repository"
+)
+
+// eventRepository fans session events out over Redis pub/sub so every replica
+// sees them, and keeps a capped Redis stream per session so a reconnecting
+// watcher can replay what it missed. Stream entry IDs are the event IDs.
+type eventRepository struct {
+	rdb       *redis.Client
+	maxLen    int64
+	retention time.Duration
+	log       *zap.Logger
+}
+
+func NewEventRepository(rdb *redis.Client, maxLen int64, retention time.Duration, log *zap.Logger) repository.EventRepository {
+	return &eventRepository{
+		rdb:       rdb,
+		maxLen:    maxLen,
+		retention: retention,
+		log:       log,
+	}
+}
+
+func eventsStreamKey(sessionID string) string {
+	return fmt.Sprintf("events:%s", sessionID)
+}
+
+func eventsChannelKey(sessionID string) string {
+	return fmt.Sprintf("events:channel:%s", sessionID)
+}
+
+func (r *eventRepository) Publish(ctx context.Context, event *models.SessionEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	streamKey := eventsStreamKey(event.SessionID)
+
+	id, err := r.rdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: streamKey,
+		MaxLen: r.maxLen,
+		Approx: true,
+		Values: map[string]interface{}{"event": data},
+	}).Result()
+	if err != nil {
+		r.log.Error("Failed to append event", zap.Error(err), zap.String("session_id", event.SessionID))
+		return fmt.Errorf("failed to append event: %w", err)
+	}
+	r.rdb.Expire(ctx, streamKey, r.retention)
+
+	event.ID = id
+	data, err = json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	if err := r.rdb.Publish(ctx, eventsChannelKey(event.SessionID), data).Err(); err != nil {
+		r.log.Error("Failed to publish event", zap.Error(err), zap.String("session_id", event.SessionID))
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+
+	return nil
+}
+
+func (r *eventRepository) Subscribe(ctx context.Context, sessionID string, cursor string) (<-chan *models.SessionEvent, error) {
+	// Subscribe before reading the backlog so nothing published in between
+	// is lost; duplicates are dropped by comparing IDs below.
+	pubsub := r.rdb.Subscribe(ctx, eventsChannelKey(sessionID))
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		r.log.Error("Failed to subscribe to events", zap.Error(err), zap.String("session_id", sessionID))
+		return nil, fmt.Errorf("failed to subscribe to events: %w", err)
+	}
+
+	var backlog []*models.SessionEvent
+	if cursor != "" {
+		entries, err := r.rdb.XRange(ctx, eventsStreamKey(sessionID), "("+cursor, "+").Result()
+		if err != nil {
+			pubsub.Close()
+			r.log.Error("Failed to replay events", zap.Error(err), zap.String("session_id", sessionID))
+			return nil, fmt.Errorf("failed to replay events: %w", err)
+		}
+
+		for _, entry := range entries {
+			event, err := decodeStreamEvent(entry)
+			if err != nil {
+				r.log.Warn("Skipping undecodable event", zap.Error(err), zap.String("event_id", entry.ID))
+				continue
+			}
+			backlog = append(backlog, event)
+		}
+	}
+
+	events := make(chan *models.SessionEvent, 64)
+
+	go func() {
+		defer close(events)
+		defer pubsub.Close()
+
+		last := cursor
+		deliver := func(event *models.SessionEvent) bool {
+			if last != "" && !eventIDAfter(event.ID, last) {
+				return true
+			}
+			select {
+			case events <- event:
+				last = event.ID
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for _, event := range backlog {
+			if !deliver(event) {
+				return
+			}
+		}
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+
+				var event models.SessionEvent
+				if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+					r.log.Warn("Skipping undecodable event", zap.Error(err), zap.String("session_id", sessionID))
+					continue
+				}
+
+				if !deliver(&event) {
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+func decodeStreamEvent(entry redis.XMessage) (*models.SessionEvent, error) {
+	raw, ok := entry.Values["event"].(string)
+	if !ok {
+		return nil, fmt.Errorf("event payload missing")
+	}
+
+	var event models.SessionEvent
+	if err := json.Unmarshal([]byte(raw), &event); err != nil {
+		return nil, err
+	}
+	event.ID = entry.ID
+
+	return &event, nil
+}
+
+// eventIDAfter reports whether stream ID a sorts after b. IDs have the form
+// "<milliseconds>-<sequence>".
+func eventIDAfter(a, b string) bool {
+	aMs, aSeq := splitEventID(a)
+	bMs, bSeq := splitEventID(b)
+	if aMs != bMs {
+		return aMs > bMs
+	}
+	return aSeq > bSeq
+}
+
+func splitEventID(id string) (uint64, uint64) {
+	msPart, seqPart, _ := strings.Cut(id, "-")
+	ms, _ := strconv.ParseUint(msPart, 10, 64)
+	seq, _ := strconv.ParseUint(seqPart, 10, 64)
+	return ms, seq
+}
@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/Sourav01112/chat-service/internal/repository"
+)
+
+// lockRepository hands out Redis locks shared by every replica. Each holder
+// gets a random token, and only the holder of the current token can extend or
+// release a lock, so a replica whose lock expired cannot undo its successor's.
+type lockRepository struct {
+	rdb *redis.Client
+	log *zap.Logger
+}
+
+func NewLockRepository(rdb *redis.Client, log *zap.Logger) repository.LockRepository {
+	return &lockRepository{
+		rdb: rdb,
+		log: log,
+	}
+}
+
+func lockKey(name string) string {
+	return fmt.Sprintf("lock:%s", name)
+}
+
+// extendLockScript resets the expiry of a lock if it still holds the token.
+var extendLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// releaseLockScript deletes a lock if it still holds the token.
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+func (r *lockRepository) Acquire(ctx context.Context, name string, ttl time.Duration) (string, bool, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", false, fmt.Errorf("failed to generate lock token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+
+	acquired, err := r.rdb.SetNX(ctx, lockKey(name), token, ttl).Result()
+	if err != nil {
+		r.log.Error("Failed to acquire lock", zap.Error(err), zap.String("lock", name))
+		return "", false, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	if !acquired {
+		return "", false, nil
+	}
+
+	return token, true, nil
+}
+
+func (r *lockRepository) Extend(ctx context.Context, name string, token string, ttl time.Duration) (bool, error) {
+	extended, err := extendLockScript.Run(ctx, r.rdb, []string{lockKey(name)}, token, ttl.Milliseconds()).Int()
+	if err != nil {
+		r.log.Error("Failed to extend lock", zap.Error(err), zap.String("lock", name))
+		return false, fmt.Errorf("failed to extend lock: %w", err)
+	}
+
+	return extended == 1, nil
+}
+
+func (r *lockRepository) Release(ctx context.Context, name string, token string) error {
+	if err := releaseLockScript.Run(ctx, r.rdb, []string{lockKey(name)}, token).Err(); err != nil {
+		r.log.Error("Failed to release lock", zap.Error(err), zap.String("lock", name))
+		return fmt.Errorf("failed to release lock: %w", err)
+	}
+
+	return nil
+}
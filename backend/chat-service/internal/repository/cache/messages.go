@@ -0,0 +1,296 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/Sourav01112/chat-service/internal/models"
+	"github.com/Sourav01112/chat-service/internal/repository"
+)
+
+// The end of a session's active branch is kept as a list of messages,
+// oldest first, under recentMessagesKey. recentMessagesMetaKey holds the
+// branch's leaf, its total length and a version that every change bumps, so
+// that a patch computed from a stale read is never written.
+
+func recentMessagesKey(sessionID string) string {
+	return fmt.Sprintf("messages:%s", sessionID)
+}
+
+func recentMessagesMetaKey(sessionID string) string {
+	return fmt.Sprintf("messages:%s:meta", sessionID)
+}
+
+// appendMessageScript adds a message to the end of the cached branch if it
+// continues it, and drops the cached branch if it does not.
+var appendMessageScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[2]) == 0 then
+	return 0
+end
+if redis.call("HGET", KEYS[2], "leaf") ~= ARGV[1] then
+	redis.call("DEL", KEYS[1], KEYS[2])
+	return -1
+end
+redis.call("RPUSH", KEYS[1], ARGV[2])
+redis.call("LTRIM", KEYS[1], -tonumber(ARGV[4]), -1)
+redis.call("HSET", KEYS[2], "leaf", ARGV[3])
+redis.call("HINCRBY", KEYS[2], "total", 1)
+redis.call("HINCRBY", KEYS[2], "version", 1)
+redis.call("PEXPIRE", KEYS[1], ARGV[5])
+redis.call("PEXPIRE", KEYS[2], ARGV[5])
+return 1`)
+
+// rewriteMessagesScript replaces the cached branch if it is still at the
+// version it was read at, and drops it otherwise.
+var rewriteMessagesScript = redis.NewScript(`
+if redis.call("HGET", KEYS[2], "version") ~= ARGV[1] then
+	redis.call("DEL", KEYS[1], KEYS[2])
+	return 0
+end
+local ttl = redis.call("PTTL", KEYS[2])
+redis.call("DEL", KEYS[1])
+if #ARGV > 3 then
+	redis.call("RPUSH", KEYS[1], unpack(ARGV, 4))
+end
+redis.call("HSET", KEYS[2], "leaf", ARGV[2], "total", ARGV[3])
+redis.call("HINCRBY", KEYS[2], "version", 1)
+if ttl > 0 then
+	redis.call("PEXPIRE", KEYS[1], ttl)
+end
+return 1`)
+
+// cachedMessages is the cached branch as read, with the version it was at.
+type cachedMessages struct {
+	repository.RecentMessages
+	version string
+}
+
+func (r *cacheRepository) SetRecentMessages(ctx context.Context, sessionID string, recent *repository.RecentMessages, ttl time.Duration) error {
+	messages := recent.Messages
+	if len(messages) > r.recentMessages {
+		messages = messages[len(messages)-r.recentMessages:]
+	}
+
+	elements, err := marshalMessages(messages)
+	if err != nil {
+		r.log.Error("Failed to marshal messages", zap.Error(err), zap.String("session_id", sessionID))
+		return fmt.Errorf("failed to marshal messages: %w", err)
+	}
+
+	listKey := recentMessagesKey(sessionID)
+	metaKey := recentMessagesMetaKey(sessionID)
+
+	_, err = r.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, listKey, metaKey)
+		if len(elements) > 0 {
+			pipe.RPush(ctx, listKey, elements...)
+			pipe.Expire(ctx, listKey, ttl)
+		}
+		pipe.HSet(ctx, metaKey, "leaf", leafValue(recent.LeafID), "total", recent.Total, "version", 0)
+		pipe.Expire(ctx, metaKey, ttl)
+		return nil
+	})
+	if err != nil {
+		r.log.Error("Failed to cache messages", zap.Error(err), zap.String("session_id", sessionID))
+		return fmt.Errorf("failed to cache messages: %w", err)
+	}
+
+	return nil
+}
+
+func (r *cacheRepository) GetRecentMessages(ctx context.Context, sessionID string) (*repository.RecentMessages, error) {
+	cached, err := r.readRecentMessages(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return &cached.RecentMessages, nil
+}
+
+// AppendRecentMessage adds a new message to the cached branch. If the
+// message starts a new branch instead, the cached one is dropped.
+func (r *cacheRepository) AppendRecentMessage(ctx context.Context, message *models.Message, ttl time.Duration) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		r.log.Error("Failed to marshal message", zap.Error(err), zap.String("message_id", message.ID))
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	err = appendMessageScript.Run(ctx, r.rdb,
+		[]string{recentMessagesKey(message.SessionID), recentMessagesMetaKey(message.SessionID)},
+		leafValue(message.ParentMessageID), data, message.ID, r.recentMessages, ttl.Milliseconds()).Err()
+	if err != nil {
+		r.log.Error("Failed to append cached message", zap.Error(err), zap.String("session_id", message.SessionID))
+		return fmt.Errorf("failed to append cached message: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateRecentMessage replaces a cached message with its new content. Its
+// siblings cannot have changed, so the cached ones are kept.
+func (r *cacheRepository) UpdateRecentMessage(ctx context.Context, message *models.Message) error {
+	cached, err := r.readRecentMessages(ctx, message.SessionID)
+	if err != nil {
+		return nil
+	}
+
+	i := indexOfMessage(cached.Messages, message.ID)
+	if i < 0 {
+		return nil
+	}
+
+	updated := *message
+	updated.SiblingIDs = cached.Messages[i].SiblingIDs
+	cached.Messages[i] = &updated
+
+	return r.rewriteRecentMessages(ctx, message.SessionID, cached)
+}
+
+// RemoveRecentMessage takes a deleted message out of the cached branch. Its
+// reply on the branch now answers its parent. When the deletion changes
+// which alternatives a cached message has, the cached branch is dropped.
+func (r *cacheRepository) RemoveRecentMessage(ctx context.Context, message *models.Message) error {
+	cached, err := r.readRecentMessages(ctx, message.SessionID)
+	if err != nil {
+		return nil
+	}
+
+	i := indexOfMessage(cached.Messages, message.ID)
+	if i < 0 {
+		for _, other := range cached.Messages {
+			for _, siblingID := range other.SiblingIDs {
+				if siblingID == message.ID {
+					return r.DeleteRecentMessages(ctx, message.SessionID)
+				}
+			}
+		}
+		return nil
+	}
+
+	if len(cached.Messages[i].SiblingIDs) > 0 {
+		return r.DeleteRecentMessages(ctx, message.SessionID)
+	}
+
+	if i+1 < len(cached.Messages) {
+		reply := *cached.Messages[i+1]
+		if len(reply.SiblingIDs) > 0 {
+			return r.DeleteRecentMessages(ctx, message.SessionID)
+		}
+		reply.ParentMessageID = message.ParentMessageID
+		cached.Messages[i+1] = &reply
+	}
+
+	cached.Messages = append(cached.Messages[:i], cached.Messages[i+1:]...)
+	cached.Total--
+	if cached.LeafID != nil && *cached.LeafID == message.ID {
+		cached.LeafID = message.ParentMessageID
+	}
+
+	return r.rewriteRecentMessages(ctx, message.SessionID, cached)
+}
+
+func (r *cacheRepository) DeleteRecentMessages(ctx context.Context, sessionID string) error {
+	err := r.rdb.Del(ctx, recentMessagesKey(sessionID), recentMessagesMetaKey(sessionID)).Err()
+	if err != nil {
+		r.log.Error("Failed to delete cached messages", zap.Error(err), zap.String("session_id", sessionID))
+		return fmt.Errorf("failed to delete cached messages: %w", err)
+	}
+
+	return nil
+}
+
+func (r *cacheRepository) readRecentMessages(ctx context.Context, sessionID string) (*cachedMessages, error) {
+	var meta *redis.MapStringStringCmd
+	var list *redis.StringSliceCmd
+
+	_, err := r.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		meta = pipe.HGetAll(ctx, recentMessagesMetaKey(sessionID))
+		list = pipe.LRange(ctx, recentMessagesKey(sessionID), 0, -1)
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		r.log.Error("Failed to get cached messages", zap.Error(err), zap.String("session_id", sessionID))
+		return nil, fmt.Errorf("failed to get cached messages: %w", err)
+	}
+
+	fields := meta.Val()
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("messages not found in cache")
+	}
+
+	total, err := strconv.ParseInt(fields["total"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse cached messages: %w", err)
+	}
+
+	cached := &cachedMessages{version: fields["version"]}
+	cached.Total = total
+	if leaf := fields["leaf"]; leaf != "" {
+		cached.LeafID = &leaf
+	}
+
+	for _, element := range list.Val() {
+		var message models.Message
+		if err := json.Unmarshal([]byte(element), &message); err != nil {
+			r.log.Error("Failed to unmarshal cached message", zap.Error(err), zap.String("session_id", sessionID))
+			return nil, fmt.Errorf("failed to unmarshal cached messages: %w", err)
+		}
+		cached.Messages = append(cached.Messages, &message)
+	}
+
+	return cached, nil
+}
+
+func (r *cacheRepository) rewriteRecentMessages(ctx context.Context, sessionID string, cached *cachedMessages) error {
+	elements, err := marshalMessages(cached.Messages)
+	if err != nil {
+		r.log.Error("Failed to marshal messages", zap.Error(err), zap.String("session_id", sessionID))
+		return fmt.Errorf("failed to marshal messages: %w", err)
+	}
+
+	args := append([]interface{}{cached.version, leafValue(cached.LeafID), cached.Total}, elements...)
+	err = rewriteMessagesScript.Run(ctx, r.rdb,
+		[]string{recentMessagesKey(sessionID), recentMessagesMetaKey(sessionID)},
+		args...).Err()
+	if err != nil {
+		r.log.Error("Failed to patch cached messages", zap.Error(err), zap.String("session_id", sessionID))
+		return fmt.Errorf("failed to patch cached messages: %w", err)
+	}
+
+	return nil
+}
+
+func marshalMessages(messages []*models.Message) ([]interface{}, error) {
+	elements := make([]interface{}, len(messages))
+	for i, message := range messages {
+		data, err := json.Marshal(message)
+		if err != nil {
+			return nil, err
+		}
+		elements[i] = data
+	}
+	return elements, nil
+}
+
+func indexOfMessage(messages []*models.Message, messageID string) int {
+	for i, message := range messages {
+		if message.ID == messageID {
+			return i
+		}
+	}
+	return -1
+}
+
+// leafValue stores a nil leaf, a branch with no messages, as "".
+func leafValue(leafID *string) string {
+	if leafID == nil {
+		return ""
+	}
+	return *leafID
+}
@@ -16,12 +16,17 @@ import (
 type cacheRepository struct {
 	rdb *redis.Client
 	log *zap.Logger
+
+	// recentMessages is how many messages of a session's active branch
+	// are kept.
+	recentMessages int
 }
 
-func NewCacheRepository(rdb *redis.Client, log *zap.Logger) repository.CacheRepository {
+func NewCacheRepository(rdb *redis.Client, recentMessages int, log *zap.Logger) repository.CacheRepository {
 	return &cacheRepository{
-		rdb: rdb,
-		log: log,
+		rdb:            rdb,
+		log:            log,
+		recentMessages: recentMessages,
 	}
 }
 
@@ -78,44 +83,49 @@ func (r *cacheRepository) DeleteSession(ctx context.Context, sessionID string) e
 	return nil
 }
 
-func (r *cacheRepository) SetRecentMessages(ctx context.Context, sessionID string, messages []*models.Message, ttl time.Duration) error {
-	key := fmt.Sprintf("messages:%s", sessionID)
+// setSessionScript replaces a cached session if it is still the one the
+// patch was computed from, and drops it otherwise. The expiry is kept.
+var setSessionScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	redis.call("SET", KEYS[1], ARGV[2], "KEEPTTL")
+	return 1
+end
+redis.call("DEL", KEYS[1])
+return 0`)
+
+// PatchSession applies patch to the cached session, if there is one.
+func (r *cacheRepository) PatchSession(ctx context.Context, sessionID string, patch func(session *models.Session)) error {
+	key := fmt.Sprintf("session:%s", sessionID)
 
-	fmt.Println("called messages REDISSS")
-	data, err := json.Marshal(messages)
+	data, err := r.rdb.Get(ctx, key).Result()
 	if err != nil {
-		r.log.Error("Failed to marshal messages", zap.Error(err), zap.String("session_id", sessionID))
-		return fmt.Errorf("failed to marshal messages: %w", err)
+		if err == redis.Nil {
+			return nil
+		}
+		r.log.Error("Failed to get cached session", zap.Error(err), zap.String("session_id", sessionID))
+		return fmt.Errorf("failed to get cached session: %w", err)
 	}
 
-	err = r.rdb.Set(ctx, key, data, ttl).Err()
-	if err != nil {
-		r.log.Error("Failed to cache messages", zap.Error(err), zap.String("session_id", sessionID))
-		return fmt.Errorf("failed to cache messages: %w", err)
+	var session models.Session
+	if err := json.Unmarshal([]byte(data), &session); err != nil {
+		_ = r.rdb.Del(ctx, key).Err()
+		return fmt.Errorf("failed to unmarshal cached session: %w", err)
 	}
 
-	return nil
-}
-
-func (r *cacheRepository) GetRecentMessages(ctx context.Context, sessionID string) ([]*models.Message, error) {
-	key := fmt.Sprintf("messages:%s", sessionID)
+	patch(&session)
 
-	data, err := r.rdb.Get(ctx, key).Result()
+	patched, err := json.Marshal(&session)
 	if err != nil {
-		if err == redis.Nil {
-			return nil, fmt.Errorf("messages not found in cache")
-		}
-		r.log.Error("Failed to get cached messages", zap.Error(err), zap.String("session_id", sessionID))
-		return nil, fmt.Errorf("failed to get cached messages: %w", err)
+		r.log.Error("Failed to marshal session", zap.Error(err), zap.String("session_id", sessionID))
+		return fmt.Errorf("failed to marshal session: %w", err)
 	}
 
-	var messages []*models.Message
-	if err := json.Unmarshal([]byte(data), &messages); err != nil {
-		r.log.Error("Failed to unmarshal cached messages", zap.Error(err), zap.String("session_id", sessionID))
-		return nil, fmt.Errorf("failed to unmarshal cached messages: %w", err)
+	if err := setSessionScript.Run(ctx, r.rdb, []string{key}, data, patched).Err(); err != nil {
+		r.log.Error("Failed to patch cached session", zap.Error(err), zap.String("session_id", sessionID))
+		return fmt.Errorf("failed to patch cached session: %w", err)
 	}
 
-	return messages, nil
+	return nil
 }
 
 func (r *cacheRepository) SetTypingStatus(ctx context.Context, sessionID, userID string, isTyping bool, ttl time.Duration) error {
@@ -156,11 +166,13 @@ func (r *cacheRepository) GetTypingUsers(ctx context.Context, sessionID string)
 	return users, nil
 }
 
+// InvalidateSessionCache drops the cached session and its messages. Who is
+// typing is left alone; it expires on its own.
 func (r *cacheRepository) InvalidateSessionCache(ctx context.Context, sessionID string) error {
 	keys := []string{
 		fmt.Sprintf("session:%s", sessionID),
-		fmt.Sprintf("messages:%s", sessionID),
-		fmt.Sprintf("typing:%s", sessionID),
+		recentMessagesKey(sessionID),
+		recentMessagesMetaKey(sessionID),
 	}
 
 	err := r.rdb.Del(ctx, keys...).Err()
@@ -171,3 +183,48 @@ func (r *cacheRepository) InvalidateSessionCache(ctx context.Context, sessionID
 
 	return nil
 }
+
+// SetIdempotencyKey caches a key until it expires.
+func (r *cacheRepository) SetIdempotencyKey(ctx context.Context, key *models.IdempotencyKey) error {
+	ttl := time.Until(key.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(key)
+	if err != nil {
+		r.log.Error("Failed to marshal idempotency key", zap.Error(err), zap.String("user_id", key.UserID))
+		return fmt.Errorf("failed to marshal idempotency key: %w", err)
+	}
+
+	err = r.rdb.Set(ctx, idempotencyCacheKey(key.UserID, key.Scope, key.Key), data, ttl).Err()
+	if err != nil {
+		r.log.Error("Failed to cache idempotency key", zap.Error(err), zap.String("user_id", key.UserID))
+		return fmt.Errorf("failed to cache idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+func (r *cacheRepository) GetIdempotencyKey(ctx context.Context, userID, scope, key string) (*models.IdempotencyKey, error) {
+	data, err := r.rdb.Get(ctx, idempotencyCacheKey(userID, scope, key)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, fmt.Errorf("idempotency key not found in cache")
+		}
+		r.log.Error("Failed to get cached idempotency key", zap.Error(err), zap.String("user_id", userID))
+		return nil, fmt.Errorf("failed to get cached idempotency key: %w", err)
+	}
+
+	var cached models.IdempotencyKey
+	if err := json.Unmarshal([]byte(data), &cached); err != nil {
+		r.log.Error("Failed to unmarshal cached idempotency key", zap.Error(err), zap.String("user_id", userID))
+		return nil, fmt.Errorf("failed to unmarshal cached idempotency key: %w", err)
+	}
+
+	return &cached, nil
+}
+
+func idempotencyCacheKey(userID, scope, key string) string {
+	return fmt.Sprintf("idempotency:%s:%s:%s", scope, userID, key)
+}
@@ -7,34 +7,217 @@ import (
     "github.com/Sourav01112/chat-service/internal/models"
 )
 
+// MessageFilter narrows a session's history. Zero fields do not filter; Tags
+// matches messages whose metadata carries every listed tag, and LeafID keeps
+// only the branch ending at that message.
+type MessageFilter struct {
+    FromDate *time.Time
+    ToDate   *time.Time
+    Types    []models.MessageType
+    Tags     []string
+    LeafID   string
+}
+
+func (f MessageFilter) IsEmpty() bool {
+    return f.FromDate == nil && f.ToDate == nil && len(f.Types) == 0 && len(f.Tags) == 0 && f.LeafID == ""
+}
+
+// UserSearchFilter narrows a search across all of a user's sessions. An empty
+// SessionStatuses searches every session that is not archived.
+type UserSearchFilter struct {
+    MessageFilter
+    SessionStatuses []models.SessionStatus
+}
+
+// SessionFilter narrows and orders a user's session list. Zero fields do not
+// filter; an empty Statuses lists every session that is not archived, and an
+// empty Sort orders by last activity.
+type SessionFilter struct {
+    Statuses      []models.SessionStatus
+    FolderID      string
+    TagID         string
+    FavoritesOnly bool
+    TitlePrefix   string
+    Sort          models.SessionSort
+}
+
+// SessionOrganization changes how one member has organized a session. Nil
+// fields are left alone; an empty FolderID takes the session out of its
+// folder.
+type SessionOrganization struct {
+    FolderID *string
+    Pinned   *bool
+    Favorite *bool
+}
+
+// MessageContext is the conversation around a message along its branch,
+// oldest first on either side.
+type MessageContext struct {
+    Before []*models.Message
+    After  []*models.Message
+}
+
+// RecentMessages is the cached end of a session's active branch: the last
+// messages up to LeafID, oldest first, out of Total on the whole branch.
+type RecentMessages struct {
+    LeafID   *string
+    Total    int64
+    Messages []*models.Message
+}
+
 type SessionRepository interface {
     Create(ctx context.Context, session *models.Session) error
-    GetByID(ctx context.Context, sessionID string, userID string) (*models.Session, error)
-    GetByUserID(ctx context.Context, userID string, limit, offset int) ([]*models.Session, int64, error)
+    GetByID(ctx context.Context, sessionID string) (*models.Session, error)
+    GetByUserID(ctx context.Context, userID string, filter SessionFilter, limit, offset int) ([]*models.Session, int64, error)
+    GetPageByUserID(ctx context.Context, userID string, filter SessionFilter, cursor *models.SessionCursor, direction models.PageDirection, limit int) ([]*models.Session, bool, error)
+    CountByUserID(ctx context.Context, userID string, filter SessionFilter) (int64, error)
     Update(ctx context.Context, session *models.Session) error
-    Delete(ctx context.Context, sessionID string, userID string) error
+    SetGeneratedTitle(ctx context.Context, sessionID string, title string) (bool, error)
+    Delete(ctx context.Context, sessionID string) error
+    Restore(ctx context.Context, sessionID string) error
+    GetTrashedByUserID(ctx context.Context, userID string, limit, offset int) ([]*models.Session, int64, error)
+    PurgeTrashed(ctx context.Context, cutoff time.Time, limit int) (int64, error)
+    CountTrashed(ctx context.Context, cutoff time.Time) (int64, error)
+    CountIdle(ctx context.Context, statuses []models.SessionStatus, idleSince time.Time) (int64, error)
+    SetIdleStatus(ctx context.Context, statuses []models.SessionStatus, idleSince time.Time, status models.SessionStatus, limit int) ([]string, error)
+    GetOwnerIDs(ctx context.Context, afterUserID string, limit int) ([]string, error)
     UpdateLastActivity(ctx context.Context, sessionID string) error
+    SetActiveLeaf(ctx context.Context, sessionID string, messageID *string) error
     GetActiveSessionsCount(ctx context.Context, userID string) (int64, error)
+    Import(ctx context.Context, session *models.Session, messages []*models.Message) (bool, error)
+    Fork(ctx context.Context, session *models.Session, messages []*models.Message, fork models.SessionFork) error
 }
 
 type MessageRepository interface {
     Create(ctx context.Context, message *models.Message) error
     GetByID(ctx context.Context, messageID string) (*models.Message, error)
-    GetBySessionID(ctx context.Context, sessionID string, limit, offset int) ([]*models.Message, int64, error)
+    GetBySessionID(ctx context.Context, sessionID string, filter MessageFilter, limit, offset int) ([]*models.Message, int64, error)
+    GetPageBySessionID(ctx context.Context, sessionID string, filter MessageFilter, cursor *models.MessageCursor, direction models.PageDirection, limit int) ([]*models.Message, bool, error)
     Update(ctx context.Context, message *models.Message) error
-    Delete(ctx context.Context, messageID string, userID string) error
+    UpdateContent(ctx context.Context, message *models.Message) error
+    Edit(ctx context.Context, messageID string, content string, editedBy string) (*models.Message, *models.MessageRevision, error)
+    GetRevisions(ctx context.Context, messageID string) ([]*models.MessageRevision, error)
+    DeleteDescendants(ctx context.Context, messageID string) ([]string, error)
+    CountBySessionID(ctx context.Context, sessionID string, filter MessageFilter) (int64, error)
+    GetAllBySessionID(ctx context.Context, sessionID string) ([]*models.Message, error)
+    GetPath(ctx context.Context, leafID string, limit int) ([]*models.Message, error)
+    GetPathAfter(ctx context.Context, leafID string, afterOrderIndex int, limit int) ([]*models.Message, error)
+    GetChildIDs(ctx context.Context, sessionID string, parentIDs []string) (map[string][]string, error)
+    GetLatestLeafID(ctx context.Context, messageID string) (string, error)
+    Delete(ctx context.Context, messageID string) error
+    GetDeletedByID(ctx context.Context, messageID string) (*models.Message, error)
+    GetDeletedBySessionID(ctx context.Context, sessionID string, limit, offset int) ([]*models.Message, int64, error)
+    Restore(ctx context.Context, messageID string) (*models.Message, error)
+    PurgeDeleted(ctx context.Context, cutoff time.Time, limit int) (int64, error)
+    CountDeleted(ctx context.Context, cutoff time.Time) (int64, error)
+    CountOlderForOwner(ctx context.Context, ownerID string, cutoff time.Time) (int64, error)
+    PurgeOlderForOwner(ctx context.Context, ownerID string, cutoff time.Time, limit int) (int64, []string, error)
     GetLastMessages(ctx context.Context, sessionID string, count int) ([]*models.Message, error)
-    SearchInSession(ctx context.Context, sessionID string, query string, limit, offset int) ([]*models.Message, int64, error)
+    SearchInSession(ctx context.Context, sessionID string, query string, searchConfig string, limit, offset int) ([]*models.SearchResult, int64, error)
+    SearchPageInSession(ctx context.Context, sessionID string, query string, searchConfig string, cursor *models.MessageCursor, direction models.PageDirection, limit int) ([]*models.SearchResult, bool, error)
+    CountSearchResults(ctx context.Context, sessionID string, query string, searchConfig string) (int64, error)
+    SearchByUserID(ctx context.Context, userID string, query string, searchConfig string, filter UserSearchFilter, limit, offset int) ([]*models.SearchResult, int64, error)
+    GetContext(ctx context.Context, messageIDs []string, size int) (map[string]*MessageContext, error)
     GetMessageCount(ctx context.Context, sessionID string) (int64, error)
 }
 
+type SummaryRepository interface {
+    Create(ctx context.Context, summary *models.SessionSummary) error
+    GetLatestForLeaf(ctx context.Context, sessionID string, leafID string) (*models.SessionSummary, error)
+    GetBySessionID(ctx context.Context, sessionID string) ([]*models.SessionSummary, error)
+    InvalidateFrom(ctx context.Context, sessionID string, orderIndex int) ([]*models.SessionSummary, error)
+}
+
+type MemberRepository interface {
+    Add(ctx context.Context, member *models.SessionMember) error
+    Get(ctx context.Context, sessionID string, userID string) (*models.SessionMember, error)
+    GetRole(ctx context.Context, sessionID string, userID string) (models.MemberRole, error)
+    GetBySessionID(ctx context.Context, sessionID string) ([]*models.SessionMember, error)
+    Remove(ctx context.Context, sessionID string, userID string) error
+    Organize(ctx context.Context, sessionID string, userID string, organization SessionOrganization) error
+}
+
+type FolderRepository interface {
+    Create(ctx context.Context, folder *models.Folder) error
+    GetByID(ctx context.Context, folderID string, userID string) (*models.Folder, error)
+    GetByUserID(ctx context.Context, userID string) ([]*models.Folder, error)
+    Update(ctx context.Context, folder *models.Folder) error
+    Delete(ctx context.Context, folderID string, userID string) error
+}
+
+type TagRepository interface {
+    Create(ctx context.Context, tag *models.Tag) error
+    GetByID(ctx context.Context, tagID string, userID string) (*models.Tag, error)
+    GetByUserID(ctx context.Context, userID string) ([]*models.Tag, error)
+    Update(ctx context.Context, tag *models.Tag) error
+    Delete(ctx context.Context, tagID string, userID string) error
+    SetSessionTags(ctx context.Context, sessionID string, userID string, tagIDs []string) error
+    GetBySessionIDs(ctx context.Context, userID string, sessionIDs []string) (map[string][]*models.Tag, error)
+}
+
+type ShareLinkRepository interface {
+    Create(ctx context.Context, link *models.ShareLink) error
+    GetByToken(ctx context.Context, token string) (*models.ShareLink, error)
+    RecordView(ctx context.Context, linkID string) (int64, error)
+    Revoke(ctx context.Context, token string, userID string) error
+}
+
+// IdempotencyRepository holds the keys callers attach to requests that must
+// not run twice. Reserve claims a key for a new request; if the key is
+// already held and has not expired, nothing changes and the holder is
+// returned instead.
+type IdempotencyRepository interface {
+    Reserve(ctx context.Context, key *models.IdempotencyKey) (*models.IdempotencyKey, error)
+    Complete(ctx context.Context, key *models.IdempotencyKey) error
+    Release(ctx context.Context, key *models.IdempotencyKey) error
+    PurgeExpired(ctx context.Context, limit int) (int64, error)
+    CountExpired(ctx context.Context) (int64, error)
+}
+
+// OutboxRepository reads the events other repositories write to the outbox
+// alongside their changes. GetPending returns due events in ID order, never
+// one whose session has an earlier event still waiting for a retry.
+type OutboxRepository interface {
+    GetPending(ctx context.Context, limit int) ([]*models.OutboxEvent, error)
+    MarkDelivered(ctx context.Context, id int64) error
+    MarkFailed(ctx context.Context, event *models.OutboxEvent) error
+    PurgeDelivered(ctx context.Context, cutoff time.Time, limit int) (int64, error)
+    CountDelivered(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// CacheRepository caches sessions and the end of their active branch.
+// Changes are written through: new messages are appended, edited and deleted
+// ones patched in place, and a patch that cannot be applied safely drops the
+// cached messages instead. InvalidateSessionCache is for changes to the
+// session itself.
 type CacheRepository interface {
     SetSession(ctx context.Context, session *models.Session, ttl time.Duration) error
     GetSession(ctx context.Context, sessionID string) (*models.Session, error)
     DeleteSession(ctx context.Context, sessionID string) error
-    SetRecentMessages(ctx context.Context, sessionID string, messages []*models.Message, ttl time.Duration) error
-    GetRecentMessages(ctx context.Context, sessionID string) ([]*models.Message, error)
+    PatchSession(ctx context.Context, sessionID string, patch func(session *models.Session)) error
+    SetRecentMessages(ctx context.Context, sessionID string, recent *RecentMessages, ttl time.Duration) error
+    GetRecentMessages(ctx context.Context, sessionID string) (*RecentMessages, error)
+    AppendRecentMessage(ctx context.Context, message *models.Message, ttl time.Duration) error
+    UpdateRecentMessage(ctx context.Context, message *models.Message) error
+    RemoveRecentMessage(ctx context.Context, message *models.Message) error
+    DeleteRecentMessages(ctx context.Context, sessionID string) error
     SetTypingStatus(ctx context.Context, sessionID, userID string, isTyping bool, ttl time.Duration) error
     GetTypingUsers(ctx context.Context, sessionID string) ([]string, error)
     InvalidateSessionCache(ctx context.Context, sessionID string) error
+    SetIdempotencyKey(ctx context.Context, key *models.IdempotencyKey) error
+    GetIdempotencyKey(ctx context.Context, userID, scope, key string) (*models.IdempotencyKey, error)
+}
+
+// LockRepository provides locks shared by every replica of the service.
+// Acquire returns a token that identifies the holder; it reports false when
+// someone else holds the lock. Locks expire after ttl unless extended.
+type LockRepository interface {
+    Acquire(ctx context.Context, name string, ttl time.Duration) (string, bool, error)
+    Extend(ctx context.Context, name string, token string, ttl time.Duration) (bool, error)
+    Release(ctx context.Context, name string, token string) error
+}
+
+type EventRepository interface {
+    Publish(ctx context.Context, event *models.SessionEvent) error
+    Subscribe(ctx context.Context, sessionID string, cursor string) (<-chan *models.SessionEvent, error)
 }
\ No newline at end of file
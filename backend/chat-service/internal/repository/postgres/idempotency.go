@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Sourav01112/chat-service/internal/models"
+	"github.com/Sourav01112/chat-service/internal/repository"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type idempotencyRepository struct {
+	db  *gorm.DB
+	log *zap.Logger
+}
+
+func NewIdempotencyRepository(db *gorm.DB, log *zap.Logger) repository.IdempotencyRepository {
+	return &idempotencyRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// Reserve inserts key, taking over a previous holder only once it has
+// expired. When someone else holds the key the holder is returned and key
+// is left unsaved; otherwise the result is nil.
+func (r *idempotencyRepository) Reserve(ctx context.Context, key *models.IdempotencyKey) (*models.IdempotencyKey, error) {
+	result := r.db.WithContext(ctx).Exec(`INSERT INTO idempotency_keys
+			(user_id, scope, key, request_hash, resource_id, created_at, expires_at)
+		VALUES (?, ?, ?, ?, NULL, NOW(), ?)
+		ON CONFLICT (user_id, scope, key) DO UPDATE SET
+			request_hash = EXCLUDED.request_hash,
+			resource_id = NULL,
+			created_at = EXCLUDED.created_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= NOW()`,
+		key.UserID, key.Scope, key.Key, key.RequestHash, key.ExpiresAt)
+	if result.Error != nil {
+		r.log.Error("Failed to reserve idempotency key",
+			zap.Error(result.Error),
+			zap.String("user_id", key.UserID),
+			zap.String("scope", key.Scope))
+		return nil, fmt.Errorf("failed to reserve idempotency key: %w", result.Error)
+	}
+
+	if result.RowsAffected > 0 {
+		return nil, nil
+	}
+
+	var holder models.IdempotencyKey
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND scope = ? AND key = ?", key.UserID, key.Scope, key.Key).
+		First(&holder).Error
+	if err != nil {
+		r.log.Error("Failed to get idempotency key",
+			zap.Error(err),
+			zap.String("user_id", key.UserID),
+			zap.String("scope", key.Scope))
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	return &holder, nil
+}
+
+// Complete records the resource the request behind key produced.
+func (r *idempotencyRepository) Complete(ctx context.Context, key *models.IdempotencyKey) error {
+	err := r.db.WithContext(ctx).
+		Model(&models.IdempotencyKey{}).
+		Where("user_id = ? AND scope = ? AND key = ? AND request_hash = ?", key.UserID, key.Scope, key.Key, key.RequestHash).
+		Update("resource_id", key.ResourceID).Error
+	if err != nil {
+		r.log.Error("Failed to complete idempotency key",
+			zap.Error(err),
+			zap.String("user_id", key.UserID),
+			zap.String("scope", key.Scope))
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// Release gives up a key whose request failed, so a retry can run it again.
+// Completed keys are kept.
+func (r *idempotencyRepository) Release(ctx context.Context, key *models.IdempotencyKey) error {
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND scope = ? AND key = ? AND request_hash = ? AND resource_id IS NULL",
+			key.UserID, key.Scope, key.Key, key.RequestHash).
+		Delete(&models.IdempotencyKey{}).Error
+	if err != nil {
+		r.log.Error("Failed to release idempotency key",
+			zap.Error(err),
+			zap.String("user_id", key.UserID),
+			zap.String("scope", key.Scope))
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// PurgeExpired deletes up to limit expired keys.
+func (r *idempotencyRepository) PurgeExpired(ctx context.Context, limit int) (int64, error) {
+	result := r.db.WithContext(ctx).Exec(`
+		DELETE FROM idempotency_keys WHERE (user_id, scope, key) IN (
+			SELECT user_id, scope, key FROM idempotency_keys
+			WHERE expires_at <= NOW()
+			LIMIT ?
+		)`, limit)
+	if result.Error != nil {
+		r.log.Error("Failed to purge idempotency keys", zap.Error(result.Error))
+		return 0, fmt.Errorf("failed to purge idempotency keys: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// CountExpired counts the keys PurgeExpired would delete.
+func (r *idempotencyRepository) CountExpired(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.IdempotencyKey{}).
+		Where("expires_at <= NOW()").
+		Count(&count).Error
+
+	if err != nil {
+		r.log.Error("Failed to count expired idempotency keys", zap.Error(err))
+		return 0, fmt.Errorf("failed to count idempotency keys: %w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,185 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Sourav01112/chat-service/internal/models"
+	"github.com/Sourav01112/chat-service/internal/repository"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// memberSessionsSQL matches the sessions a user owns or is a member of. It
+// takes the user ID twice.
+const memberSessionsSQL = "(sessions.user_id = ? OR sessions.id IN (SELECT session_id FROM session_members WHERE user_id = ?))"
+
+type memberRepository struct {
+	db  *gorm.DB
+	log *zap.Logger
+}
+
+func NewMemberRepository(db *gorm.DB, log *zap.Logger) repository.MemberRepository {
+	return &memberRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// Add makes userID a member of the session or changes the role of an
+// existing member. The owner's row is never changed this way.
+func (r *memberRepository) Add(ctx context.Context, member *models.SessionMember) error {
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "session_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"role", "invited_by", "updated_at"}),
+			Where: clause.Where{Exprs: []clause.Expression{
+				clause.Neq{Column: clause.Column{Table: "session_members", Name: "role"}, Value: models.MemberRoleOwner},
+			}},
+		}).
+		Create(member)
+
+	if result.Error != nil {
+		r.log.Error("Failed to add session member",
+			zap.Error(result.Error),
+			zap.String("session_id", member.SessionID),
+			zap.String("user_id", member.UserID))
+		return fmt.Errorf("failed to add session member: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("cannot change the role of the session owner")
+	}
+
+	r.log.Info("Session member added",
+		zap.String("session_id", member.SessionID),
+		zap.String("user_id", member.UserID),
+		zap.String("role", member.Role.String()))
+	return nil
+}
+
+func (r *memberRepository) Get(ctx context.Context, sessionID string, userID string) (*models.SessionMember, error) {
+	var member models.SessionMember
+
+	err := r.db.WithContext(ctx).
+		Where("session_id = ? AND user_id = ?", sessionID, userID).
+		First(&member).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("session member not found")
+		}
+		r.log.Error("Failed to get session member",
+			zap.Error(err),
+			zap.String("session_id", sessionID),
+			zap.String("user_id", userID))
+		return nil, fmt.Errorf("failed to get session member: %w", err)
+	}
+
+	return &member, nil
+}
+
+// GetRole returns the user's role in the session, or "" when they are not a
+// member.
+func (r *memberRepository) GetRole(ctx context.Context, sessionID string, userID string) (models.MemberRole, error) {
+	var member models.SessionMember
+
+	err := r.db.WithContext(ctx).
+		Select("role").
+		Where("session_id = ? AND user_id = ?", sessionID, userID).
+		First(&member).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil
+		}
+		r.log.Error("Failed to get session member",
+			zap.Error(err),
+			zap.String("session_id", sessionID),
+			zap.String("user_id", userID))
+		return "", fmt.Errorf("failed to get session member: %w", err)
+	}
+
+	return member.Role, nil
+}
+
+func (r *memberRepository) GetBySessionID(ctx context.Context, sessionID string) ([]*models.SessionMember, error) {
+	var members []*models.SessionMember
+
+	err := r.db.WithContext(ctx).
+		Where("session_id = ?", sessionID).
+		Order("created_at ASC").
+		Find(&members).Error
+	if err != nil {
+		r.log.Error("Failed to get session members",
+			zap.Error(err),
+			zap.String("session_id", sessionID))
+		return nil, fmt.Errorf("failed to get session members: %w", err)
+	}
+
+	return members, nil
+}
+
+// Remove takes userID out of the session. The owner cannot be removed.
+func (r *memberRepository) Remove(ctx context.Context, sessionID string, userID string) error {
+	result := r.db.WithContext(ctx).
+		Where("session_id = ? AND user_id = ? AND role != ?", sessionID, userID, models.MemberRoleOwner).
+		Delete(&models.SessionMember{})
+
+	if result.Error != nil {
+		r.log.Error("Failed to remove session member",
+			zap.Error(result.Error),
+			zap.String("session_id", sessionID),
+			zap.String("user_id", userID))
+		return fmt.Errorf("failed to remove session member: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("session member not found")
+	}
+
+	r.log.Info("Session member removed",
+		zap.String("session_id", sessionID),
+		zap.String("user_id", userID))
+	return nil
+}
+
+// Organize applies organization to the user's membership of the session.
+func (r *memberRepository) Organize(ctx context.Context, sessionID string, userID string, organization repository.SessionOrganization) error {
+	updates := map[string]interface{}{}
+	if organization.FolderID != nil {
+		if *organization.FolderID == "" {
+			updates["folder_id"] = nil
+		} else {
+			updates["folder_id"] = *organization.FolderID
+		}
+	}
+	if organization.Pinned != nil {
+		updates["pinned"] = *organization.Pinned
+	}
+	if organization.Favorite != nil {
+		updates["favorite"] = *organization.Favorite
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+
+	result := r.db.WithContext(ctx).
+		Model(&models.SessionMember{}).
+		Where("session_id = ? AND user_id = ?", sessionID, userID).
+		Updates(updates)
+
+	if result.Error != nil {
+		r.log.Error("Failed to organize session",
+			zap.Error(result.Error),
+			zap.String("session_id", sessionID),
+			zap.String("user_id", userID))
+		return fmt.Errorf("failed to organize session: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("session member not found")
+	}
+
+	return nil
+}
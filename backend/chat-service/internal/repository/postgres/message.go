@@ -2,16 +2,26 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"regexp"
+	"time"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/Sourav01112/chat-service/internal/models"
 	"github.com/Sourav01112/chat-service/internal/repository"
 )
 
+var errMessageNotFound = errors.New("message not found")
+
+// errParentDeleted refuses to restore a message under a parent that is in
+// the trash.
+var errParentDeleted = errors.New("the message this replies to is in the trash; restore it first")
+
 type messageRepository struct {
 	db  *gorm.DB
 	log *zap.Logger
@@ -24,8 +34,19 @@ func NewMessageRepository(db *gorm.DB, log *zap.Logger) repository.MessageReposi
 	}
 }
 
+// Create stores a message. Unless it is still streaming, the message_sent
+// event for it is written to the outbox in the same transaction.
 func (r *messageRepository) Create(ctx context.Context, message *models.Message) error {
-	if err := r.db.WithContext(ctx).Create(message).Error; err != nil {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(message).Error; err != nil {
+			return err
+		}
+		if message.Status == models.MessageStatusStreaming {
+			return nil
+		}
+		return addOutboxEvent(tx, models.NewMessageSentEvent(message))
+	})
+	if err != nil {
 		r.log.Error("Failed to create message",
 			zap.Error(err),
 			zap.String("session_id", message.SessionID))
@@ -59,13 +80,13 @@ func (r *messageRepository) GetByID(ctx context.Context, messageID string) (*mod
 	return &message, nil
 }
 
-func (r *messageRepository) GetBySessionID(ctx context.Context, sessionID string, limit, offset int) ([]*models.Message, int64, error) {
+func (r *messageRepository) GetBySessionID(ctx context.Context, sessionID string, filter repository.MessageFilter, limit, offset int) ([]*models.Message, int64, error) {
 	var messages []*models.Message
 	var total int64
 
-	if err := r.db.WithContext(ctx).
+	if err := applyMessageFilter(r.db.WithContext(ctx).
 		Model(&models.Message{}).
-		Where("session_id = ?", sessionID).
+		Where("session_id = ?", sessionID), filter).
 		Count(&total).Error; err != nil {
 		r.log.Error("Failed to count messages",
 			zap.Error(err),
@@ -73,8 +94,8 @@ func (r *messageRepository) GetBySessionID(ctx context.Context, sessionID string
 		return nil, 0, fmt.Errorf("failed to count messages: %w", err)
 	}
 
-	err := r.db.WithContext(ctx).
-		Where("session_id = ?", sessionID).
+	err := applyMessageFilter(r.db.WithContext(ctx).
+		Where("session_id = ?", sessionID), filter).
 		Order("order_index ASC, created_at ASC").
 		Limit(limit).
 		Offset(offset).
@@ -90,6 +111,35 @@ func (r *messageRepository) GetBySessionID(ctx context.Context, sessionID string
 	return messages, total, nil
 }
 
+// GetPageBySessionID reads one page of messages strictly before (older) or
+// after (newer) cursor. Messages come back in ascending order_index either
+// way. A nil cursor starts from the latest message for older pages and the
+// first message for newer ones. The bool reports whether more messages follow
+// in that direction.
+func (r *messageRepository) GetPageBySessionID(ctx context.Context, sessionID string, filter repository.MessageFilter, cursor *models.MessageCursor, direction models.PageDirection, limit int) ([]*models.Message, bool, error) {
+	var messages []*models.Message
+
+	query := keysetMessages(applyMessageFilter(r.db.WithContext(ctx).Where("session_id = ?", sessionID), filter), cursor, direction)
+
+	if err := query.Limit(limit + 1).Find(&messages).Error; err != nil {
+		r.log.Error("Failed to get session messages page",
+			zap.Error(err),
+			zap.String("session_id", sessionID))
+		return nil, false, fmt.Errorf("failed to get messages: %w", err)
+	}
+
+	hasMore := len(messages) > limit
+	if hasMore {
+		messages = messages[:limit]
+	}
+
+	if direction != models.PageDirectionNewer {
+		reverseMessages(messages)
+	}
+
+	return messages, hasMore, nil
+}
+
 func (r *messageRepository) Update(ctx context.Context, message *models.Message) error {
 	result := r.db.WithContext(ctx).Save(message)
 	if result.Error != nil {
@@ -107,34 +157,589 @@ func (r *messageRepository) Update(ctx context.Context, message *models.Message)
 	return nil
 }
 
-func (r *messageRepository) Delete(ctx context.Context, messageID string, userID string) error {
+// UpdateContent writes only content, metadata and status, so it is safe to
+// call repeatedly while a reply is still being streamed. Once the message
+// stops streaming its message_sent event goes to the outbox; writing it again
+// later changes nothing.
+func (r *messageRepository) UpdateContent(ctx context.Context, message *models.Message) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.
+			Model(&models.Message{}).
+			Where("id = ?", message.ID).
+			Updates(map[string]interface{}{
+				"content":  message.Content,
+				"metadata": message.Metadata,
+				"status":   message.Status,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errMessageNotFound
+		}
+
+		if message.Status == models.MessageStatusStreaming {
+			return nil
+		}
+		return addOutboxEvent(tx, models.NewMessageSentEvent(message))
+	})
+
+	if errors.Is(err, errMessageNotFound) {
+		return err
+	}
+	if err != nil {
+		r.log.Error("Failed to update message content",
+			zap.Error(err),
+			zap.String("message_id", message.ID))
+		return fmt.Errorf("failed to update message content: %w", err)
+	}
+
+	return nil
+}
+
+// Edit replaces a message's content and records what it replaced as the next
+// revision. The message row is locked so concurrent edits number their
+// revisions in the order they are applied.
+func (r *messageRepository) Edit(ctx context.Context, messageID string, content string, editedBy string) (*models.Message, *models.MessageRevision, error) {
+	var message models.Message
+	var revision *models.MessageRevision
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", messageID).
+			First(&message).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("message not found")
+			}
+			return err
+		}
+
+		var latest int
+		if err := tx.Model(&models.MessageRevision{}).
+			Where("message_id = ?", messageID).
+			Select("COALESCE(MAX(revision), 0)").
+			Scan(&latest).Error; err != nil {
+			return err
+		}
+
+		revision = &models.MessageRevision{
+			MessageID: messageID,
+			Revision:  latest + 1,
+			Content:   message.Content,
+			Metadata:  message.Metadata,
+			EditedBy:  editedBy,
+		}
+		if err := tx.Create(revision).Error; err != nil {
+			return err
+		}
+
+		editedAt := time.Now().UTC()
+		if err := tx.Model(&message).Updates(map[string]interface{}{
+			"content":   content,
+			"edited_at": editedAt,
+		}).Error; err != nil {
+			return err
+		}
+
+		message.Content = content
+		message.EditedAt = &editedAt
+		return nil
+	})
+
+	if err != nil {
+		r.log.Error("Failed to edit message",
+			zap.Error(err),
+			zap.String("message_id", messageID))
+		return nil, nil, fmt.Errorf("failed to edit message: %w", err)
+	}
+
+	r.log.Info("Message edited successfully",
+		zap.String("message_id", messageID),
+		zap.Int("revision", revision.Revision))
+	return &message, revision, nil
+}
+
+func (r *messageRepository) GetRevisions(ctx context.Context, messageID string) ([]*models.MessageRevision, error) {
+	var revisions []*models.MessageRevision
+
+	err := r.db.WithContext(ctx).
+		Where("message_id = ?", messageID).
+		Order("revision ASC").
+		Find(&revisions).Error
+
+	if err != nil {
+		r.log.Error("Failed to get message revisions",
+			zap.Error(err),
+			zap.String("message_id", messageID))
+		return nil, fmt.Errorf("failed to get message revisions: %w", err)
+	}
+
+	return revisions, nil
+}
+
+// DeleteDescendants removes every reply beneath a message, on all branches,
+// and returns their IDs.
+func (r *messageRepository) DeleteDescendants(ctx context.Context, messageID string) ([]string, error) {
+	var deleted []models.Message
+
+	// Replies dropped by an edit are gone for good rather than trashed; the
+	// edit's revision keeps what they answered.
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}}}).
+		Where("id IN ("+subtreeSQL+") AND id != ?", messageID, messageID).
+		Delete(&deleted).Error
+
+	if err != nil {
+		r.log.Error("Failed to delete replies",
+			zap.Error(err),
+			zap.String("message_id", messageID))
+		return nil, fmt.Errorf("failed to delete messages: %w", err)
+	}
+
+	ids := make([]string, len(deleted))
+	for i, message := range deleted {
+		ids[i] = message.ID
+	}
+
+	r.log.Info("Replies deleted",
+		zap.String("message_id", messageID),
+		zap.Int("count", len(ids)))
+	return ids, nil
+}
+
+func (r *messageRepository) CountBySessionID(ctx context.Context, sessionID string, filter repository.MessageFilter) (int64, error) {
+	var count int64
+
+	err := applyMessageFilter(r.db.WithContext(ctx).
+		Model(&models.Message{}).
+		Where("session_id = ?", sessionID), filter).
+		Count(&count).Error
+
+	if err != nil {
+		r.log.Error("Failed to count messages",
+			zap.Error(err),
+			zap.String("session_id", sessionID))
+		return 0, fmt.Errorf("failed to count messages: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *messageRepository) GetAllBySessionID(ctx context.Context, sessionID string) ([]*models.Message, error) {
+	var messages []*models.Message
+
+	err := r.db.WithContext(ctx).
+		Where("session_id = ?", sessionID).
+		Order("order_index ASC, created_at ASC").
+		Find(&messages).Error
+
+	if err != nil {
+		r.log.Error("Failed to get session messages",
+			zap.Error(err),
+			zap.String("session_id", sessionID))
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+// GetPath returns up to limit messages ending at leafID, following parent
+// links towards the root, oldest first.
+func (r *messageRepository) GetPath(ctx context.Context, leafID string, limit int) ([]*models.Message, error) {
+	return r.GetPathAfter(ctx, leafID, -1, limit)
+}
+
+// GetPathAfter is GetPath stopping at the first message at or before
+// afterOrderIndex, which leaves the turns a summary through that position
+// has not covered.
+func (r *messageRepository) GetPathAfter(ctx context.Context, leafID string, afterOrderIndex int, limit int) ([]*models.Message, error) {
+	var messages []*models.Message
+
+	err := r.db.WithContext(ctx).Raw(`
+		WITH RECURSIVE path AS (
+			SELECT messages.*, 1 AS depth FROM messages WHERE id = ? AND deleted_at IS NULL AND order_index > ?
+			UNION ALL
+			SELECT m.*, p.depth + 1 FROM messages m
+			JOIN path p ON m.id = p.parent_message_id
+			WHERE p.depth < ? AND m.order_index > ?
+		)
+		SELECT * FROM path ORDER BY depth DESC`, leafID, afterOrderIndex, limit, afterOrderIndex).
+		Scan(&messages).Error
+
+	if err != nil {
+		r.log.Error("Failed to get message path",
+			zap.Error(err),
+			zap.String("leaf_id", leafID))
+		return nil, fmt.Errorf("failed to get message path: %w", err)
+	}
+
+	return messages, nil
+}
+
+// GetChildIDs returns the IDs of each parent's children, oldest first. The
+// empty parent ID stands for the session's root messages.
+func (r *messageRepository) GetChildIDs(ctx context.Context, sessionID string, parentIDs []string) (map[string][]string, error) {
+	var rows []struct {
+		ID              string
+		ParentMessageID *string
+	}
+
+	var ids []string
+	includeRoots := false
+	for _, parentID := range parentIDs {
+		if parentID == "" {
+			includeRoots = true
+		} else {
+			ids = append(ids, parentID)
+		}
+	}
+
+	query := r.db.WithContext(ctx).
+		Model(&models.Message{}).
+		Select("id, parent_message_id").
+		Where("session_id = ?", sessionID)
+	if includeRoots {
+		query = query.Where("parent_message_id IN ? OR parent_message_id IS NULL", ids)
+	} else {
+		query = query.Where("parent_message_id IN ?", ids)
+	}
+
+	if err := query.Order("order_index ASC").Scan(&rows).Error; err != nil {
+		r.log.Error("Failed to get child messages",
+			zap.Error(err),
+			zap.String("session_id", sessionID))
+		return nil, fmt.Errorf("failed to get child messages: %w", err)
+	}
+
+	children := make(map[string][]string)
+	for _, row := range rows {
+		parentID := ""
+		if row.ParentMessageID != nil {
+			parentID = *row.ParentMessageID
+		}
+		children[parentID] = append(children[parentID], row.ID)
+	}
+
+	return children, nil
+}
+
+// GetLatestLeafID returns the newest message in the subtree under messageID,
+// which is always a leaf since replies are numbered after their parent.
+func (r *messageRepository) GetLatestLeafID(ctx context.Context, messageID string) (string, error) {
+	var leafID string
+
+	err := r.db.WithContext(ctx).
+		Model(&models.Message{}).
+		Select("id").
+		Where("id IN ("+subtreeSQL+")", messageID).
+		Order("order_index DESC").
+		Limit(1).
+		Scan(&leafID).Error
+
+	if err != nil {
+		r.log.Error("Failed to get latest leaf",
+			zap.Error(err),
+			zap.String("message_id", messageID))
+		return "", fmt.Errorf("failed to get latest leaf: %w", err)
+	}
+
+	if leafID == "" {
+		return "", fmt.Errorf("message not found")
+	}
+
+	return leafID, nil
+}
+
+// Delete removes a message; whether the caller may do so is checked by the
+// service.
+func (r *messageRepository) Delete(ctx context.Context, messageID string) error {
 	var message models.Message
 	err := r.db.WithContext(ctx).
-		Joins("JOIN sessions ON messages.session_id = sessions.id").
-		Where("messages.id = ? AND sessions.user_id = ?", messageID, userID).
+		Where("id = ?", messageID).
 		First(&message).Error
 
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return fmt.Errorf("message not found or not owned by user")
+			return fmt.Errorf("message not found")
 		}
-		return fmt.Errorf("failed to verify message ownership: %w", err)
+		return fmt.Errorf("failed to get message: %w", err)
 	}
 
-	result := r.db.WithContext(ctx).Delete(&models.Message{}, messageID)
-	if result.Error != nil {
+	// Hand the message's replies to its parent so deleting a message from the
+	// middle of a branch does not cut the branch in two. Replies already in
+	// the trash move too, so they can be restored under the right parent.
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var replies []string
+		if err := tx.Unscoped().
+			Model(&models.Message{}).
+			Where("parent_message_id = ?", messageID).
+			Pluck("id", &replies).Error; err != nil {
+			return err
+		}
+
+		if len(replies) > 0 {
+			if err := tx.Unscoped().
+				Model(&models.Message{}).
+				Where("id IN ?", replies).
+				Update("parent_message_id", message.ParentMessageID).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&message).
+			Updates(map[string]interface{}{
+				"deleted_at":     time.Now(),
+				"reparented_ids": models.MessageIDs(replies),
+			}).Error
+	})
+	if err != nil {
 		r.log.Error("Failed to delete message",
-			zap.Error(result.Error),
+			zap.Error(err),
 			zap.String("message_id", messageID))
-		return fmt.Errorf("failed to delete message: %w", result.Error)
+		return fmt.Errorf("failed to delete message: %w", err)
 	}
 
-	r.log.Info("Message deleted successfully",
-		zap.String("message_id", messageID),
-		zap.String("user_id", userID))
+	r.log.Info("Message deleted successfully", zap.String("message_id", messageID))
 	return nil
 }
 
+// GetDeletedByID loads a message that is in the trash.
+func (r *messageRepository) GetDeletedByID(ctx context.Context, messageID string) (*models.Message, error) {
+	var message models.Message
+
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Where("id = ? AND deleted_at IS NOT NULL", messageID).
+		First(&message).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("message not found in trash")
+		}
+		r.log.Error("Failed to get deleted message", zap.Error(err), zap.String("message_id", messageID))
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+
+	return &message, nil
+}
+
+// GetDeletedBySessionID lists a session's messages that are in the trash,
+// most recently deleted first.
+func (r *messageRepository) GetDeletedBySessionID(ctx context.Context, sessionID string, limit, offset int) ([]*models.Message, int64, error) {
+	var messages []*models.Message
+	var total int64
+
+	query := r.db.WithContext(ctx).
+		Unscoped().
+		Model(&models.Message{}).
+		Where("session_id = ? AND deleted_at IS NOT NULL", sessionID)
+
+	if err := query.Count(&total).Error; err != nil {
+		r.log.Error("Failed to count deleted messages", zap.Error(err), zap.String("session_id", sessionID))
+		return nil, 0, fmt.Errorf("failed to count messages: %w", err)
+	}
+
+	err := query.
+		Order("deleted_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&messages).Error
+	if err != nil {
+		r.log.Error("Failed to get deleted messages", zap.Error(err), zap.String("session_id", sessionID))
+		return nil, 0, fmt.Errorf("failed to get messages: %w", err)
+	}
+
+	return messages, total, nil
+}
+
+// Restore takes a message out of the trash and puts it back under its
+// parent, taking back the replies Delete handed over unless they have moved
+// since. The parent must not be in the trash itself.
+func (r *messageRepository) Restore(ctx context.Context, messageID string) (*models.Message, error) {
+	var message models.Message
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND deleted_at IS NOT NULL", messageID).
+			First(&message).Error; err != nil {
+			return err
+		}
+
+		if message.ParentMessageID != nil {
+			var parents int64
+			if err := tx.Model(&models.Message{}).
+				Where("id = ?", *message.ParentMessageID).
+				Count(&parents).Error; err != nil {
+				return err
+			}
+			if parents == 0 {
+				return errParentDeleted
+			}
+		}
+
+		if len(message.ReparentedIDs) > 0 {
+			if err := tx.Unscoped().
+				Model(&models.Message{}).
+				Where("id IN ? AND parent_message_id IS NOT DISTINCT FROM ?", []string(message.ReparentedIDs), message.ParentMessageID).
+				Update("parent_message_id", message.ID).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Unscoped().
+			Model(&message).
+			Updates(map[string]interface{}{
+				"deleted_at":     nil,
+				"reparented_ids": nil,
+			}).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("message not found in trash")
+		}
+		if errors.Is(err, errParentDeleted) {
+			return nil, err
+		}
+		r.log.Error("Failed to restore message",
+			zap.Error(err),
+			zap.String("message_id", messageID))
+		return nil, fmt.Errorf("failed to restore message: %w", err)
+	}
+
+	message.DeletedAt = gorm.DeletedAt{}
+	message.ReparentedIDs = nil
+
+	r.log.Info("Message restored successfully", zap.String("message_id", messageID))
+	return &message, nil
+}
+
+// PurgeDeleted permanently deletes up to limit messages that went to the
+// trash before cutoff and returns how many it deleted.
+func (r *messageRepository) PurgeDeleted(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	result := r.db.WithContext(ctx).Exec(`
+		DELETE FROM messages WHERE id IN (
+			SELECT id FROM messages
+			WHERE deleted_at < ?
+			LIMIT ?
+		)`, cutoff, limit)
+
+	if result.Error != nil {
+		r.log.Error("Failed to purge deleted messages", zap.Error(result.Error))
+		return 0, fmt.Errorf("failed to purge messages: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// CountDeleted counts the messages PurgeDeleted would delete.
+func (r *messageRepository) CountDeleted(ctx context.Context, cutoff time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Model(&models.Message{}).
+		Where("deleted_at < ?", cutoff).
+		Count(&count).Error
+
+	if err != nil {
+		r.log.Error("Failed to count deleted messages", zap.Error(err))
+		return 0, fmt.Errorf("failed to count messages: %w", err)
+	}
+
+	return count, nil
+}
+
+// ownedSessionsSQL selects the IDs of the sessions a user owns.
+const ownedSessionsSQL = `SELECT id FROM sessions WHERE user_id = ?`
+
+// expiredMessagesSQL selects, with their depth, the messages in sessions a
+// user owns that were created before a cutoff and whose ancestors all were
+// too. Purging only these takes whole prefixes off a branch, so every
+// message left keeps its path back to a root. It takes the owner and the
+// cutoff twice.
+const expiredMessagesSQL = `
+	WITH RECURSIVE expired AS (
+		SELECT id, 0 AS depth FROM messages
+		WHERE session_id IN (` + ownedSessionsSQL + `) AND parent_message_id IS NULL AND created_at < ?
+		UNION ALL
+		SELECT m.id, e.depth + 1 FROM messages m
+		JOIN expired e ON m.parent_message_id = e.id
+		WHERE m.created_at < ?
+	)`
+
+// CountOlderForOwner counts the messages PurgeOlderForOwner would delete.
+func (r *messageRepository) CountOlderForOwner(ctx context.Context, ownerID string, cutoff time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Raw(expiredMessagesSQL+` SELECT COUNT(*) FROM expired`, ownerID, cutoff, cutoff).
+		Scan(&count).Error
+
+	if err != nil {
+		r.log.Error("Failed to count expired messages", zap.Error(err), zap.String("user_id", ownerID))
+		return 0, fmt.Errorf("failed to count messages: %w", err)
+	}
+
+	return count, nil
+}
+
+// PurgeOlderForOwner permanently deletes up to limit messages created before
+// cutoff in sessions ownerID owns, whether or not they are in the trash, and
+// invalidates the summaries that covered them. It returns how many it
+// deleted and the sessions they came from.
+//
+// Only whole prefixes of a branch are deleted, shallowest first, so the
+// first message left on a branch becomes its root and an old message with a
+// newer ancestor is kept. A session whose active branch expired entirely is
+// left without one.
+func (r *messageRepository) PurgeOlderForOwner(ctx context.Context, ownerID string, cutoff time.Time, limit int) (int64, []string, error) {
+	var purged []struct {
+		SessionID  string
+		OrderIndex int
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw(expiredMessagesSQL+`
+			DELETE FROM messages WHERE id IN (
+				SELECT id FROM expired ORDER BY depth LIMIT ?
+			)
+			RETURNING session_id, order_index`, ownerID, cutoff, cutoff, limit).
+			Scan(&purged).Error; err != nil {
+			return err
+		}
+
+		from := make(map[string]int)
+		for _, message := range purged {
+			if index, ok := from[message.SessionID]; !ok || message.OrderIndex < index {
+				from[message.SessionID] = message.OrderIndex
+			}
+		}
+
+		for sessionID, orderIndex := range from {
+			if err := tx.Model(&models.SessionSummary{}).
+				Where("session_id = ? AND through_order_index >= ? AND invalidated_at IS NULL", sessionID, orderIndex).
+				Update("invalidated_at", time.Now().UTC()).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		r.log.Error("Failed to purge expired messages", zap.Error(err), zap.String("user_id", ownerID))
+		return 0, nil, fmt.Errorf("failed to purge messages: %w", err)
+	}
+
+	seen := make(map[string]bool, len(purged))
+	var sessionIDs []string
+	for _, message := range purged {
+		if !seen[message.SessionID] {
+			seen[message.SessionID] = true
+			sessionIDs = append(sessionIDs, message.SessionID)
+		}
+	}
+
+	return int64(len(purged)), sessionIDs, nil
+}
+
 func (r *messageRepository) GetLastMessages(ctx context.Context, sessionID string, count int) ([]*models.Message, error) {
 	var messages []*models.Message
 
@@ -151,22 +756,20 @@ func (r *messageRepository) GetLastMessages(ctx context.Context, sessionID strin
 		return nil, fmt.Errorf("failed to get last messages: %w", err)
 	}
 
-	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
-		messages[i], messages[j] = messages[j], messages[i]
-	}
+	reverseMessages(messages)
 
 	return messages, nil
 }
 
-func (r *messageRepository) SearchInSession(ctx context.Context, sessionID string, query string, limit, offset int) ([]*models.Message, int64, error) {
-	var messages []*models.Message
+func (r *messageRepository) SearchInSession(ctx context.Context, sessionID string, query string, searchConfig string, limit, offset int) ([]*models.SearchResult, int64, error) {
+	var rows []searchRow
 	var total int64
 
-	searchQuery := "%" + query + "%"
+	expr := newSearchExpressions(searchConfig)
 
 	if err := r.db.WithContext(ctx).
 		Model(&models.Message{}).
-		Where("session_id = ? AND content ILIKE ?", sessionID, searchQuery).
+		Where("session_id = ? AND "+expr.match, sessionID, query).
 		Count(&total).Error; err != nil {
 		r.log.Error("Failed to count search results",
 			zap.Error(err),
@@ -176,11 +779,13 @@ func (r *messageRepository) SearchInSession(ctx context.Context, sessionID strin
 	}
 
 	err := r.db.WithContext(ctx).
-		Where("session_id = ? AND content ILIKE ?", sessionID, searchQuery).
-		Order("created_at DESC").
+		Table("messages").
+		Select("messages.*, "+expr.rank+" AS rank, "+expr.headline+" AS snippet", query, query).
+		Where("session_id = ? AND deleted_at IS NULL AND "+expr.match, sessionID, query).
+		Order("rank DESC, order_index DESC").
 		Limit(limit).
 		Offset(offset).
-		Find(&messages).Error
+		Scan(&rows).Error
 
 	if err != nil {
 		r.log.Error("Failed to search messages",
@@ -190,7 +795,80 @@ func (r *messageRepository) SearchInSession(ctx context.Context, sessionID strin
 		return nil, 0, fmt.Errorf("failed to search messages: %w", err)
 	}
 
-	return messages, total, nil
+	return searchResults(rows), total, nil
+}
+
+// SearchPageInSession is the keyset counterpart of SearchInSession. Results
+// are ordered by (rank, order_index) descending; older pages continue down
+// the ranking and newer pages walk back up it.
+func (r *messageRepository) SearchPageInSession(ctx context.Context, sessionID string, query string, searchConfig string, cursor *models.MessageCursor, direction models.PageDirection, limit int) ([]*models.SearchResult, bool, error) {
+	var rows []searchRow
+
+	expr := newSearchExpressions(searchConfig)
+
+	db := r.db.WithContext(ctx).
+		Table("messages").
+		Select("messages.*, "+expr.rank+" AS rank, "+expr.headline+" AS snippet", query, query).
+		Where("session_id = ? AND deleted_at IS NULL AND "+expr.match, sessionID, query)
+
+	if cursor != nil && cursor.Rank == nil {
+		return nil, false, fmt.Errorf("invalid search cursor")
+	}
+
+	if direction == models.PageDirectionNewer {
+		if cursor != nil {
+			db = db.Where("("+expr.rank+", order_index) > (?, ?)", query, *cursor.Rank, cursor.OrderIndex)
+		}
+		db = db.Order("rank ASC, order_index ASC")
+	} else {
+		if cursor != nil {
+			db = db.Where("("+expr.rank+", order_index) < (?, ?)", query, *cursor.Rank, cursor.OrderIndex)
+		}
+		db = db.Order("rank DESC, order_index DESC")
+	}
+
+	if err := db.Limit(limit + 1).Scan(&rows).Error; err != nil {
+		r.log.Error("Failed to search messages page",
+			zap.Error(err),
+			zap.String("session_id", sessionID),
+			zap.String("query", query))
+		return nil, false, fmt.Errorf("failed to search messages: %w", err)
+	}
+
+	hasMore := len(rows) > limit
+	if hasMore {
+		rows = rows[:limit]
+	}
+
+	results := searchResults(rows)
+	if direction == models.PageDirectionNewer {
+		for i, j := 0, len(results)-1; i < j; i, j = i+1, j-1 {
+			results[i], results[j] = results[j], results[i]
+		}
+	}
+
+	return results, hasMore, nil
+}
+
+func (r *messageRepository) CountSearchResults(ctx context.Context, sessionID string, query string, searchConfig string) (int64, error) {
+	var count int64
+
+	expr := newSearchExpressions(searchConfig)
+
+	err := r.db.WithContext(ctx).
+		Model(&models.Message{}).
+		Where("session_id = ? AND "+expr.match, sessionID, query).
+		Count(&count).Error
+
+	if err != nil {
+		r.log.Error("Failed to count search results",
+			zap.Error(err),
+			zap.String("session_id", sessionID),
+			zap.String("query", query))
+		return 0, fmt.Errorf("failed to count search results: %w", err)
+	}
+
+	return count, nil
 }
 
 func (r *messageRepository) GetMessageCount(ctx context.Context, sessionID string) (int64, error) {
@@ -209,3 +887,243 @@ func (r *messageRepository) GetMessageCount(ctx context.Context, sessionID strin
 
 	return count, nil
 }
+
+func (r *messageRepository) SearchByUserID(ctx context.Context, userID string, query string, searchConfig string, filter repository.UserSearchFilter, limit, offset int) ([]*models.SearchResult, int64, error) {
+	var rows []searchRow
+	var total int64
+
+	expr := newSearchExpressions(searchConfig)
+
+	scope := func(db *gorm.DB) *gorm.DB {
+		db = db.Joins("JOIN sessions ON sessions.id = messages.session_id").
+			Where(memberSessionsSQL+" AND messages.deleted_at IS NULL AND "+expr.match, userID, userID, query)
+		if len(filter.SessionStatuses) > 0 {
+			db = db.Where("sessions.status IN ?", filter.SessionStatuses)
+		} else {
+			db = db.Where("sessions.status != ?", models.SessionStatusArchived)
+		}
+		return applyMessageFilter(db, filter.MessageFilter)
+	}
+
+	if err := r.db.WithContext(ctx).
+		Model(&models.Message{}).
+		Scopes(scope).
+		Count(&total).Error; err != nil {
+		r.log.Error("Failed to count search results",
+			zap.Error(err),
+			zap.String("user_id", userID),
+			zap.String("query", query))
+		return nil, 0, fmt.Errorf("failed to count search results: %w", err)
+	}
+
+	err := r.db.WithContext(ctx).
+		Table("messages").
+		Select("messages.*, sessions.title AS session_title, sessions.status AS session_status, "+
+			expr.rank+" AS rank, "+expr.headline+" AS snippet", query, query).
+		Scopes(scope).
+		Order("rank DESC, messages.created_at DESC, messages.id").
+		Limit(limit).
+		Offset(offset).
+		Scan(&rows).Error
+
+	if err != nil {
+		r.log.Error("Failed to search user messages",
+			zap.Error(err),
+			zap.String("user_id", userID),
+			zap.String("query", query))
+		return nil, 0, fmt.Errorf("failed to search messages: %w", err)
+	}
+
+	return searchResults(rows), total, nil
+}
+
+// contextRow is a message found around a hit, with its distance from it:
+// negative before the hit and positive after.
+type contextRow struct {
+	models.Message
+	HitID    string
+	Distance int
+}
+
+// GetContext returns up to size messages either side of each of messageIDs
+// in one query. Before a message come its ancestors. After it come its
+// replies, following the session's active branch where the message is on
+// it and the newest reply where it is not, so sibling branches never mix
+// in.
+func (r *messageRepository) GetContext(ctx context.Context, messageIDs []string, size int) (map[string]*repository.MessageContext, error) {
+	contexts := make(map[string]*repository.MessageContext, len(messageIDs))
+	if len(messageIDs) == 0 || size <= 0 {
+		return contexts, nil
+	}
+
+	var rows []contextRow
+	err := r.db.WithContext(ctx).Raw(`
+		WITH RECURSIVE active AS (
+			SELECT active_leaf_id AS id FROM sessions
+			WHERE id IN (SELECT session_id FROM messages WHERE id IN @ids) AND active_leaf_id IS NOT NULL
+			UNION
+			SELECT m.parent_message_id FROM active a
+			JOIN messages m ON m.id = a.id
+			WHERE m.parent_message_id IS NOT NULL
+		),
+		before AS (
+			SELECT id AS hit_id, parent_message_id AS id, 1 AS distance FROM messages
+			WHERE id IN @ids AND parent_message_id IS NOT NULL
+			UNION ALL
+			SELECT b.hit_id, m.parent_message_id, b.distance + 1 FROM before b
+			JOIN messages m ON m.id = b.id
+			WHERE b.distance < @size AND m.parent_message_id IS NOT NULL
+		),
+		after AS (
+			SELECT id AS hit_id, id, 0 AS distance FROM messages WHERE id IN @ids
+			UNION ALL
+			SELECT a.hit_id, reply.id, a.distance + 1 FROM after a
+			CROSS JOIN LATERAL (
+				SELECT m.id FROM messages m
+				WHERE m.parent_message_id = a.id AND m.deleted_at IS NULL
+				ORDER BY (m.id IN (SELECT id FROM active)) DESC, m.order_index DESC
+				LIMIT 1
+			) reply
+			WHERE a.distance < @size
+		),
+		around AS (
+			SELECT hit_id, id, -distance AS distance FROM before
+			UNION ALL
+			SELECT hit_id, id, distance FROM after WHERE distance > 0
+		)
+		SELECT messages.*, around.hit_id, around.distance FROM around
+		JOIN messages ON messages.id = around.id
+		WHERE messages.deleted_at IS NULL
+		ORDER BY around.hit_id, around.distance`,
+		map[string]interface{}{"ids": messageIDs, "size": size}).
+		Scan(&rows).Error
+
+	if err != nil {
+		r.log.Error("Failed to get message context", zap.Error(err), zap.Int("messages", len(messageIDs)))
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+
+	for i := range rows {
+		row := &rows[i]
+		around, ok := contexts[row.HitID]
+		if !ok {
+			around = &repository.MessageContext{}
+			contexts[row.HitID] = around
+		}
+		if row.Distance < 0 {
+			around.Before = append(around.Before, &row.Message)
+		} else {
+			around.After = append(around.After, &row.Message)
+		}
+	}
+
+	return contexts, nil
+}
+
+type searchRow struct {
+	models.Message
+	Rank          float32
+	Snippet       string
+	SessionTitle  string
+	SessionStatus models.SessionStatus
+}
+
+func searchResults(rows []searchRow) []*models.SearchResult {
+	results := make([]*models.SearchResult, len(rows))
+	for i := range rows {
+		results[i] = &models.SearchResult{
+			Message: &rows[i].Message,
+			Rank:    rows[i].Rank,
+			Snippet: rows[i].Snippet,
+
+			SessionTitle:  rows[i].SessionTitle,
+			SessionStatus: rows[i].SessionStatus,
+		}
+	}
+	return results
+}
+
+// searchExpressions holds the full-text SQL fragments for one text search
+// configuration. Each takes the raw user query as its only parameter. The
+// configuration is inlined rather than bound so that for English the match
+// expression is the one idx_messages_content_search was built on.
+type searchExpressions struct {
+	match    string
+	rank     string
+	headline string
+}
+
+func newSearchExpressions(searchConfig string) searchExpressions {
+	if !validSearchConfig.MatchString(searchConfig) {
+		searchConfig = models.DefaultTextSearchConfig
+	}
+
+	document := fmt.Sprintf("to_tsvector('%s', content)", searchConfig)
+	query := fmt.Sprintf("websearch_to_tsquery('%s', ?)", searchConfig)
+
+	return searchExpressions{
+		match:    fmt.Sprintf("%s @@ %s", document, query),
+		rank:     fmt.Sprintf("ts_rank(%s, %s)", document, query),
+		headline: fmt.Sprintf("ts_headline('%s', content, %s, 'StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2')", searchConfig, query),
+	}
+}
+
+var validSearchConfig = regexp.MustCompile(`^[a-z_]+$`)
+
+// ancestorsSQL selects the IDs of a message and everything above it.
+const ancestorsSQL = `WITH RECURSIVE ancestors AS (
+	SELECT id, parent_message_id FROM messages WHERE id = ?
+	UNION ALL
+	SELECT m.id, m.parent_message_id FROM messages m JOIN ancestors a ON m.id = a.parent_message_id
+) SELECT id FROM ancestors`
+
+// subtreeSQL selects the IDs of a message and everything below it.
+const subtreeSQL = `WITH RECURSIVE subtree AS (
+	SELECT id FROM messages WHERE id = ?
+	UNION ALL
+	SELECT m.id FROM messages m JOIN subtree t ON m.parent_message_id = t.id
+) SELECT id FROM subtree`
+
+// applyMessageFilter qualifies its columns so it also applies to queries that
+// join sessions.
+func applyMessageFilter(query *gorm.DB, filter repository.MessageFilter) *gorm.DB {
+	if filter.FromDate != nil {
+		query = query.Where("messages.created_at >= ?", *filter.FromDate)
+	}
+	if filter.ToDate != nil {
+		query = query.Where("messages.created_at <= ?", *filter.ToDate)
+	}
+	if len(filter.Types) > 0 {
+		query = query.Where("messages.type IN ?", filter.Types)
+	}
+	if len(filter.Tags) > 0 {
+		tags, _ := json.Marshal(filter.Tags)
+		query = query.Where("messages.metadata->'tags' @> ?::jsonb", string(tags))
+	}
+	if filter.LeafID != "" {
+		query = query.Where("messages.id IN ("+ancestorsSQL+")", filter.LeafID)
+	}
+	return query
+}
+
+// keysetMessages restricts query to messages strictly older or newer than
+// cursor and orders them walking away from it.
+func keysetMessages(query *gorm.DB, cursor *models.MessageCursor, direction models.PageDirection) *gorm.DB {
+	if direction == models.PageDirectionNewer {
+		if cursor != nil {
+			query = query.Where("order_index > ?", cursor.OrderIndex)
+		}
+		return query.Order("order_index ASC, created_at ASC")
+	}
+
+	if cursor != nil {
+		query = query.Where("order_index < ?", cursor.OrderIndex)
+	}
+	return query.Order("order_index DESC, created_at DESC")
+}
+
+func reverseMessages(messages []*models.Message) {
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+}
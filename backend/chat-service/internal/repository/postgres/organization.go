@@ -0,0 +1,323 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Sourav01112/chat-service/internal/models"
+	"github.com/Sourav01112/chat-service/internal/repository"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type folderRepository struct {
+	db  *gorm.DB
+	log *zap.Logger
+}
+
+func NewFolderRepository(db *gorm.DB, log *zap.Logger) repository.FolderRepository {
+	return &folderRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+func (r *folderRepository) Create(ctx context.Context, folder *models.Folder) error {
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(folder)
+
+	if result.Error != nil {
+		r.log.Error("Failed to create folder",
+			zap.Error(result.Error),
+			zap.String("user_id", folder.UserID))
+		return fmt.Errorf("failed to create folder: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("folder %q already exists", folder.Name)
+	}
+
+	r.log.Info("Folder created",
+		zap.String("folder_id", folder.ID),
+		zap.String("user_id", folder.UserID))
+	return nil
+}
+
+func (r *folderRepository) GetByID(ctx context.Context, folderID string, userID string) (*models.Folder, error) {
+	var folder models.Folder
+
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", folderID, userID).
+		First(&folder).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("folder not found")
+		}
+		r.log.Error("Failed to get folder", zap.Error(err), zap.String("folder_id", folderID))
+		return nil, fmt.Errorf("failed to get folder: %w", err)
+	}
+
+	return &folder, nil
+}
+
+func (r *folderRepository) GetByUserID(ctx context.Context, userID string) ([]*models.Folder, error) {
+	var folders []*models.Folder
+
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("name ASC").
+		Find(&folders).Error
+	if err != nil {
+		r.log.Error("Failed to get folders", zap.Error(err), zap.String("user_id", userID))
+		return nil, fmt.Errorf("failed to get folders: %w", err)
+	}
+
+	return folders, nil
+}
+
+func (r *folderRepository) Update(ctx context.Context, folder *models.Folder) error {
+	var taken int64
+	if err := r.db.WithContext(ctx).
+		Model(&models.Folder{}).
+		Where("user_id = ? AND name = ? AND id != ?", folder.UserID, folder.Name, folder.ID).
+		Count(&taken).Error; err != nil {
+		return fmt.Errorf("failed to update folder: %w", err)
+	}
+	if taken > 0 {
+		return fmt.Errorf("folder %q already exists", folder.Name)
+	}
+
+	result := r.db.WithContext(ctx).
+		Model(&models.Folder{}).
+		Where("id = ? AND user_id = ?", folder.ID, folder.UserID).
+		Update("name", folder.Name)
+
+	if result.Error != nil {
+		r.log.Error("Failed to update folder", zap.Error(result.Error), zap.String("folder_id", folder.ID))
+		return fmt.Errorf("failed to update folder: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("folder not found")
+	}
+
+	return nil
+}
+
+// Delete removes a folder. Its sessions are kept and simply leave it.
+func (r *folderRepository) Delete(ctx context.Context, folderID string, userID string) error {
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", folderID, userID).
+		Delete(&models.Folder{})
+
+	if result.Error != nil {
+		r.log.Error("Failed to delete folder", zap.Error(result.Error), zap.String("folder_id", folderID))
+		return fmt.Errorf("failed to delete folder: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("folder not found")
+	}
+
+	r.log.Info("Folder deleted",
+		zap.String("folder_id", folderID),
+		zap.String("user_id", userID))
+	return nil
+}
+
+type tagRepository struct {
+	db  *gorm.DB
+	log *zap.Logger
+}
+
+func NewTagRepository(db *gorm.DB, log *zap.Logger) repository.TagRepository {
+	return &tagRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+func (r *tagRepository) Create(ctx context.Context, tag *models.Tag) error {
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(tag)
+
+	if result.Error != nil {
+		r.log.Error("Failed to create tag",
+			zap.Error(result.Error),
+			zap.String("user_id", tag.UserID))
+		return fmt.Errorf("failed to create tag: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("tag %q already exists", tag.Name)
+	}
+
+	r.log.Info("Tag created",
+		zap.String("tag_id", tag.ID),
+		zap.String("user_id", tag.UserID))
+	return nil
+}
+
+func (r *tagRepository) GetByID(ctx context.Context, tagID string, userID string) (*models.Tag, error) {
+	var tag models.Tag
+
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", tagID, userID).
+		First(&tag).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("tag not found")
+		}
+		r.log.Error("Failed to get tag", zap.Error(err), zap.String("tag_id", tagID))
+		return nil, fmt.Errorf("failed to get tag: %w", err)
+	}
+
+	return &tag, nil
+}
+
+func (r *tagRepository) GetByUserID(ctx context.Context, userID string) ([]*models.Tag, error) {
+	var tags []*models.Tag
+
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("name ASC").
+		Find(&tags).Error
+	if err != nil {
+		r.log.Error("Failed to get tags", zap.Error(err), zap.String("user_id", userID))
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+func (r *tagRepository) Update(ctx context.Context, tag *models.Tag) error {
+	var taken int64
+	if err := r.db.WithContext(ctx).
+		Model(&models.Tag{}).
+		Where("user_id = ? AND name = ? AND id != ?", tag.UserID, tag.Name, tag.ID).
+		Count(&taken).Error; err != nil {
+		return fmt.Errorf("failed to update tag: %w", err)
+	}
+	if taken > 0 {
+		return fmt.Errorf("tag %q already exists", tag.Name)
+	}
+
+	result := r.db.WithContext(ctx).
+		Model(&models.Tag{}).
+		Where("id = ? AND user_id = ?", tag.ID, tag.UserID).
+		Updates(map[string]interface{}{"name": tag.Name, "color": tag.Color})
+
+	if result.Error != nil {
+		r.log.Error("Failed to update tag", zap.Error(result.Error), zap.String("tag_id", tag.ID))
+		return fmt.Errorf("failed to update tag: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("tag not found")
+	}
+
+	return nil
+}
+
+// Delete removes a tag and takes it off every session it was on.
+func (r *tagRepository) Delete(ctx context.Context, tagID string, userID string) error {
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", tagID, userID).
+		Delete(&models.Tag{})
+
+	if result.Error != nil {
+		r.log.Error("Failed to delete tag", zap.Error(result.Error), zap.String("tag_id", tagID))
+		return fmt.Errorf("failed to delete tag: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("tag not found")
+	}
+
+	r.log.Info("Tag deleted",
+		zap.String("tag_id", tagID),
+		zap.String("user_id", userID))
+	return nil
+}
+
+// SetSessionTags replaces the tags userID has put on the session. Every tag
+// must belong to userID; otherwise nothing changes.
+func (r *tagRepository) SetSessionTags(ctx context.Context, sessionID string, userID string, tagIDs []string) error {
+	unique := make(map[string]bool, len(tagIDs))
+	for _, tagID := range tagIDs {
+		unique[tagID] = true
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("session_id = ? AND user_id = ?", sessionID, userID).
+			Delete(&models.SessionTag{}).Error; err != nil {
+			return err
+		}
+		if len(unique) == 0 {
+			return nil
+		}
+
+		result := tx.Exec(`INSERT INTO session_tags (session_id, user_id, tag_id, created_at)
+			SELECT ?, ?, id, NOW() FROM tags WHERE user_id = ? AND id IN ?`,
+			sessionID, userID, userID, tagIDs)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != int64(len(unique)) {
+			return errTagNotFound
+		}
+		return nil
+	})
+
+	if errors.Is(err, errTagNotFound) {
+		return err
+	}
+	if err != nil {
+		r.log.Error("Failed to set session tags",
+			zap.Error(err),
+			zap.String("session_id", sessionID),
+			zap.String("user_id", userID))
+		return fmt.Errorf("failed to set session tags: %w", err)
+	}
+
+	return nil
+}
+
+var errTagNotFound = errors.New("tag not found")
+
+// GetBySessionIDs returns the tags userID has put on each of the sessions,
+// keyed by session ID.
+func (r *tagRepository) GetBySessionIDs(ctx context.Context, userID string, sessionIDs []string) (map[string][]*models.Tag, error) {
+	tagged := make(map[string][]*models.Tag)
+	if len(sessionIDs) == 0 {
+		return tagged, nil
+	}
+
+	var rows []struct {
+		SessionID string
+		models.Tag
+	}
+
+	err := r.db.WithContext(ctx).
+		Table("session_tags").
+		Select("session_tags.session_id, tags.*").
+		Joins("JOIN tags ON tags.id = session_tags.tag_id").
+		Where("session_tags.user_id = ? AND session_tags.session_id IN ?", userID, sessionIDs).
+		Order("tags.name ASC").
+		Scan(&rows).Error
+	if err != nil {
+		r.log.Error("Failed to get session tags", zap.Error(err), zap.String("user_id", userID))
+		return nil, fmt.Errorf("failed to get session tags: %w", err)
+	}
+
+	for i := range rows {
+		tag := rows[i].Tag
+		tagged[rows[i].SessionID] = append(tagged[rows[i].SessionID], &tag)
+	}
+
+	return tagged, nil
+}
@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Sourav01112/chat-service/internal/models"
+	"github.com/Sourav01112/chat-service/internal/repository"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type outboxRepository struct {
+	db  *gorm.DB
+	log *zap.Logger
+}
+
+func NewOutboxRepository(db *gorm.DB, log *zap.Logger) repository.OutboxRepository {
+	return &outboxRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// addOutboxEvent writes event within tx, the transaction making the change it
+// describes. An event that was already written is left alone.
+func addOutboxEvent(tx *gorm.DB, event *models.OutboxEvent) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "event_id"}},
+		DoNothing: true,
+	}).Create(event).Error
+}
+
+// addOutboxEvents writes several events within tx, in batches.
+func addOutboxEvents(tx *gorm.DB, events []*models.OutboxEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "event_id"}},
+		DoNothing: true,
+	}).CreateInBatches(events, importBatchSize).Error
+}
+
+func (r *outboxRepository) GetPending(ctx context.Context, limit int) ([]*models.OutboxEvent, error) {
+	var events []*models.OutboxEvent
+
+	err := r.db.WithContext(ctx).
+		Where("delivered_at IS NULL AND failed_at IS NULL AND next_attempt_at <= NOW()").
+		Where(`NOT EXISTS (
+			SELECT 1 FROM outbox_events earlier
+			WHERE earlier.session_id = outbox_events.session_id
+			  AND earlier.id < outbox_events.id
+			  AND earlier.delivered_at IS NULL AND earlier.failed_at IS NULL
+			  AND earlier.next_attempt_at > NOW()
+		)`).
+		Order("id ASC").
+		Limit(limit).
+		Find(&events).Error
+	if err != nil {
+		r.log.Error("Failed to get pending outbox events", zap.Error(err))
+		return nil, fmt.Errorf("failed to get pending outbox events: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *outboxRepository) MarkDelivered(ctx context.Context, id int64) error {
+	err := r.db.WithContext(ctx).
+		Model(&models.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"delivered_at": time.Now(),
+			"last_error":   "",
+		}).Error
+	if err != nil {
+		r.log.Error("Failed to mark outbox event delivered", zap.Error(err), zap.Int64("id", id))
+		return fmt.Errorf("failed to mark outbox event delivered: %w", err)
+	}
+
+	return nil
+}
+
+// MarkFailed records a failed delivery: the attempt count, when to try
+// again, the error and, once delivery is given up, FailedAt.
+func (r *outboxRepository) MarkFailed(ctx context.Context, event *models.OutboxEvent) error {
+	err := r.db.WithContext(ctx).
+		Model(&models.OutboxEvent{}).
+		Where("id = ?", event.ID).
+		Updates(map[string]interface{}{
+			"attempts":        event.Attempts,
+			"next_attempt_at": event.NextAttemptAt,
+			"last_error":      event.LastError,
+			"failed_at":       event.FailedAt,
+		}).Error
+	if err != nil {
+		r.log.Error("Failed to record outbox delivery failure", zap.Error(err), zap.Int64("id", event.ID))
+		return fmt.Errorf("failed to record outbox delivery failure: %w", err)
+	}
+
+	return nil
+}
+
+func (r *outboxRepository) PurgeDelivered(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	result := r.db.WithContext(ctx).Exec(`
+		DELETE FROM outbox_events WHERE id IN (
+			SELECT id FROM outbox_events
+			WHERE delivered_at < ?
+			LIMIT ?
+		)`, cutoff, limit)
+
+	if result.Error != nil {
+		r.log.Error("Failed to purge delivered outbox events", zap.Error(result.Error))
+		return 0, fmt.Errorf("failed to purge outbox events: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// CountDelivered counts the events PurgeDelivered would delete.
+func (r *outboxRepository) CountDelivered(ctx context.Context, cutoff time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.OutboxEvent{}).
+		Where("delivered_at < ?", cutoff).
+		Count(&count).Error
+
+	if err != nil {
+		r.log.Error("Failed to count delivered outbox events", zap.Error(err))
+		return 0, fmt.Errorf("failed to count outbox events: %w", err)
+	}
+
+	return count, nil
+}
@@ -2,16 +2,26 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/Sourav01112/chat-service/internal/models"
 	"github.com/Sourav01112/chat-service/internal/repository"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// importBatchSize is how many messages an import or fork inserts per
+// statement.
+const importBatchSize = 200
+
+// errAlreadyImported rolls back an import that would duplicate a session.
+var errAlreadyImported = errors.New("session already imported")
+
 type sessionRepository struct {
 	db  *gorm.DB
 	log *zap.Logger
@@ -24,8 +34,18 @@ func NewSessionRepository(db *gorm.DB, log *zap.Logger) repository.SessionReposi
 	}
 }
 
+// Create stores the session together with its owner's membership.
 func (r *sessionRepository) Create(ctx context.Context, session *models.Session) error {
-	if err := r.db.WithContext(ctx).Create(session).Error; err != nil {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(session).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(models.NewOwnerMember(session)).Error; err != nil {
+			return err
+		}
+		return addOutboxEvent(tx, models.NewSessionStartEvent(session))
+	})
+	if err != nil {
 		r.log.Error("Failed to create session",
 			zap.Error(err),
 			zap.String("user_id", session.UserID))
@@ -38,44 +58,73 @@ func (r *sessionRepository) Create(ctx context.Context, session *models.Session)
 	return nil
 }
 
-func (r *sessionRepository) GetByID(ctx context.Context, sessionID string, userID string) (*models.Session, error) {
+// GetByID loads a session whoever owns it; access is checked by the service.
+func (r *sessionRepository) GetByID(ctx context.Context, sessionID string) (*models.Session, error) {
 	var session models.Session
 
-	query := r.db.WithContext(ctx).Where("id = ?", sessionID)
-	if userID != "" {
-		query = query.Where("user_id = ?", userID)
-	}
-
-	err := query.First(&session).Error
+	err := r.db.WithContext(ctx).Where("id = ?", sessionID).First(&session).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fmt.Errorf("session not found")
 		}
 		r.log.Error("Failed to get session",
 			zap.Error(err),
-			zap.String("session_id", sessionID),
-			zap.String("user_id", userID))
+			zap.String("session_id", sessionID))
 		return nil, fmt.Errorf("failed to get session: %w", err)
 	}
 
 	return &session, nil
 }
 
-func (r *sessionRepository) GetByUserID(ctx context.Context, userID string, limit, offset int) ([]*models.Session, int64, error) {
+// userSessions selects the sessions userID is a member of that match filter,
+// along with how the user has organized them.
+func userSessions(db *gorm.DB, userID string, filter repository.SessionFilter) *gorm.DB {
+	db = db.Model(&models.Session{}).
+		Joins("JOIN session_members ON session_members.session_id = sessions.id AND session_members.user_id = ?", userID)
+
+	if len(filter.Statuses) > 0 {
+		db = db.Where("sessions.status IN ?", filter.Statuses)
+	} else {
+		db = db.Where("sessions.status != ?", models.SessionStatusArchived)
+	}
+	if filter.FolderID != "" {
+		db = db.Where("session_members.folder_id = ?", filter.FolderID)
+	}
+	if filter.TagID != "" {
+		db = db.Where("EXISTS (SELECT 1 FROM session_tags WHERE session_tags.session_id = sessions.id AND session_tags.user_id = ? AND session_tags.tag_id = ?)",
+			userID, filter.TagID)
+	}
+	if filter.FavoritesOnly {
+		db = db.Where("session_members.favorite")
+	}
+	if filter.TitlePrefix != "" {
+		db = db.Where("sessions.title ILIKE ?", likePrefix(filter.TitlePrefix))
+	}
+
+	return db
+}
+
+const userSessionColumns = "sessions.*, session_members.pinned, session_members.favorite, session_members.folder_id"
+
+// likePrefix escapes prefix for use in a LIKE pattern matching anything that
+// starts with it.
+func likePrefix(prefix string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix) + "%"
+}
+
+func (r *sessionRepository) GetByUserID(ctx context.Context, userID string, filter repository.SessionFilter, limit, offset int) ([]*models.Session, int64, error) {
 	var sessions []*models.Session
 	var total int64
 
-	if err := r.db.WithContext(ctx).
-		Model(&models.Session{}).
-		Where("user_id = ? AND status != ?", userID, models.SessionStatusArchived).
-		Count(&total).Error; err != nil {
+	if err := userSessions(r.db.WithContext(ctx), userID, filter).Count(&total).Error; err != nil {
 		r.log.Error("Failed to count user sessions", zap.Error(err), zap.String("user_id", userID))
 		return nil, 0, fmt.Errorf("failed to count sessions: %w", err)
 	}
 
-	err := r.db.WithContext(ctx).
-		Where("user_id = ? AND status != ?", userID, models.SessionStatusArchived).
-		Order("last_activity DESC").
+	column := "sessions." + filter.Sort.Column()
+	err := userSessions(r.db.WithContext(ctx), userID, filter).
+		Select(userSessionColumns).
+		Order("session_members.pinned DESC, " + column + " DESC, sessions.id DESC").
 		Limit(limit).
 		Offset(offset).
 		Find(&sessions).Error
@@ -88,8 +137,78 @@ func (r *sessionRepository) GetByUserID(ctx context.Context, userID string, limi
 	return sessions, total, nil
 }
 
+// GetPageByUserID reads one page of sessions in (pinned, time, id) DESC
+// order, where time is the filter's sort column, starting strictly after
+// cursor in the given direction. A nil cursor starts from the first pinned,
+// most recent session for older pages and the least recent unpinned one for
+// newer ones. The bool reports whether more sessions follow in that
+// direction.
+func (r *sessionRepository) GetPageByUserID(ctx context.Context, userID string, filter repository.SessionFilter, cursor *models.SessionCursor, direction models.PageDirection, limit int) ([]*models.Session, bool, error) {
+	var sessions []*models.Session
+
+	column := "sessions." + filter.Sort.Column()
+	key := "(session_members.pinned, " + column + ", sessions.id)"
+
+	query := userSessions(r.db.WithContext(ctx), userID, filter).Select(userSessionColumns)
+
+	if direction == models.PageDirectionNewer {
+		if cursor != nil {
+			query = query.Where(key+" > (?, ?, ?)", cursor.Pinned, cursor.Time, cursor.ID)
+		}
+		query = query.Order("session_members.pinned ASC, " + column + " ASC, sessions.id ASC")
+	} else {
+		if cursor != nil {
+			query = query.Where(key+" < (?, ?, ?)", cursor.Pinned, cursor.Time, cursor.ID)
+		}
+		query = query.Order("session_members.pinned DESC, " + column + " DESC, sessions.id DESC")
+	}
+
+	if err := query.Limit(limit + 1).Find(&sessions).Error; err != nil {
+		r.log.Error("Failed to get user sessions page", zap.Error(err), zap.String("user_id", userID))
+		return nil, false, fmt.Errorf("failed to get sessions: %w", err)
+	}
+
+	hasMore := len(sessions) > limit
+	if hasMore {
+		sessions = sessions[:limit]
+	}
+
+	if direction == models.PageDirectionNewer {
+		for i, j := 0, len(sessions)-1; i < j; i, j = i+1, j-1 {
+			sessions[i], sessions[j] = sessions[j], sessions[i]
+		}
+	}
+
+	return sessions, hasMore, nil
+}
+
+func (r *sessionRepository) CountByUserID(ctx context.Context, userID string, filter repository.SessionFilter) (int64, error) {
+	var count int64
+	err := userSessions(r.db.WithContext(ctx), userID, filter).Count(&count).Error
+
+	if err != nil {
+		r.log.Error("Failed to count user sessions", zap.Error(err), zap.String("user_id", userID))
+		return 0, fmt.Errorf("failed to count sessions: %w", err)
+	}
+
+	return count, nil
+}
+
+// Update writes the fields a user can change on a session. The active leaf,
+// last activity and metadata are moved on by their own methods, so a session
+// read before one of them ran is still safe to update.
 func (r *sessionRepository) Update(ctx context.Context, session *models.Session) error {
-	result := r.db.WithContext(ctx).Save(session)
+	result := r.db.WithContext(ctx).
+		Model(&models.Session{}).
+		Where("id = ?", session.ID).
+		Updates(map[string]interface{}{
+			"title":        session.Title,
+			"title_source": session.TitleSource,
+			"status":       session.Status,
+			"settings":     session.Settings,
+			"archived_at":  session.ArchivedAt,
+			"trashed_at":   session.TrashedAt,
+		})
 	if result.Error != nil {
 		r.log.Error("Failed to update session",
 			zap.Error(result.Error),
@@ -105,11 +224,38 @@ func (r *sessionRepository) Update(ctx context.Context, session *models.Session)
 	return nil
 }
 
-func (r *sessionRepository) Delete(ctx context.Context, sessionID string, userID string) error {
+// SetGeneratedTitle gives the session title if it still has the default one,
+// and reports whether it did.
+func (r *sessionRepository) SetGeneratedTitle(ctx context.Context, sessionID string, title string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.Session{}).
+		Where("id = ? AND title_source = ?", sessionID, models.TitleSourceDefault).
+		Updates(map[string]interface{}{
+			"title":        title,
+			"title_source": models.TitleSourceGenerated,
+		})
+
+	if result.Error != nil {
+		r.log.Error("Failed to set generated session title",
+			zap.Error(result.Error),
+			zap.String("session_id", sessionID))
+		return false, fmt.Errorf("failed to set session title: %w", result.Error)
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// Delete moves a session to the trash. It stays there, restorable, until
+// PurgeTrashed removes it.
+func (r *sessionRepository) Delete(ctx context.Context, sessionID string) error {
 	result := r.db.WithContext(ctx).
 		Model(&models.Session{}).
-		Where("id = ? AND user_id = ?", sessionID, userID).
-		Update("status", models.SessionStatusArchived)
+		Where("id = ?", sessionID).
+		Updates(map[string]interface{}{
+			"status":      models.SessionStatusArchived,
+			"archived_at": gorm.Expr("COALESCE(archived_at, NOW())"),
+			"trashed_at":  gorm.Expr("COALESCE(trashed_at, NOW())"),
+		})
 
 	if result.Error != nil {
 		r.log.Error("Failed to delete session",
@@ -119,15 +265,172 @@ func (r *sessionRepository) Delete(ctx context.Context, sessionID string, userID
 	}
 
 	if result.RowsAffected == 0 {
-		return fmt.Errorf("session not found or not owned by user")
+		return fmt.Errorf("session not found")
+	}
+
+	r.log.Info("Session deleted successfully", zap.String("session_id", sessionID))
+	return nil
+}
+
+// Restore takes a session out of the trash.
+func (r *sessionRepository) Restore(ctx context.Context, sessionID string) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.Session{}).
+		Where("id = ? AND status = ? AND trashed_at IS NOT NULL", sessionID, models.SessionStatusArchived).
+		Updates(map[string]interface{}{
+			"status":      models.SessionStatusActive,
+			"archived_at": nil,
+			"trashed_at":  nil,
+		})
+
+	if result.Error != nil {
+		r.log.Error("Failed to restore session",
+			zap.Error(result.Error),
+			zap.String("session_id", sessionID))
+		return fmt.Errorf("failed to restore session: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("session not found in trash")
 	}
 
-	r.log.Info("Session deleted successfully",
-		zap.String("session_id", sessionID),
-		zap.String("user_id", userID))
+	r.log.Info("Session restored successfully", zap.String("session_id", sessionID))
 	return nil
 }
 
+// GetTrashedByUserID lists the sessions userID owns that are in the trash,
+// most recently deleted first.
+func (r *sessionRepository) GetTrashedByUserID(ctx context.Context, userID string, limit, offset int) ([]*models.Session, int64, error) {
+	var sessions []*models.Session
+	var total int64
+
+	query := r.db.WithContext(ctx).
+		Model(&models.Session{}).
+		Where("user_id = ? AND trashed_at IS NOT NULL", userID)
+
+	if err := query.Count(&total).Error; err != nil {
+		r.log.Error("Failed to count trashed sessions", zap.Error(err), zap.String("user_id", userID))
+		return nil, 0, fmt.Errorf("failed to count sessions: %w", err)
+	}
+
+	err := query.
+		Order("trashed_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&sessions).Error
+	if err != nil {
+		r.log.Error("Failed to get trashed sessions", zap.Error(err), zap.String("user_id", userID))
+		return nil, 0, fmt.Errorf("failed to get sessions: %w", err)
+	}
+
+	return sessions, total, nil
+}
+
+// PurgeTrashed permanently deletes up to limit sessions deleted before
+// cutoff, along with everything that belongs to them, and returns how many
+// it deleted. Sessions that were only archived are never purged.
+func (r *sessionRepository) PurgeTrashed(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	result := r.db.WithContext(ctx).Exec(`
+		DELETE FROM sessions WHERE id IN (
+			SELECT id FROM sessions
+			WHERE trashed_at < ?
+			LIMIT ?
+		)`, cutoff, limit)
+
+	if result.Error != nil {
+		r.log.Error("Failed to purge trashed sessions", zap.Error(result.Error))
+		return 0, fmt.Errorf("failed to purge sessions: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// CountTrashed counts the sessions PurgeTrashed would delete.
+func (r *sessionRepository) CountTrashed(ctx context.Context, cutoff time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.Session{}).
+		Where("trashed_at < ?", cutoff).
+		Count(&count).Error
+
+	if err != nil {
+		r.log.Error("Failed to count trashed sessions", zap.Error(err))
+		return 0, fmt.Errorf("failed to count sessions: %w", err)
+	}
+
+	return count, nil
+}
+
+// CountIdle counts the sessions in one of statuses with no activity since
+// idleSince.
+func (r *sessionRepository) CountIdle(ctx context.Context, statuses []models.SessionStatus, idleSince time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.Session{}).
+		Where("status IN ? AND last_activity < ?", statuses, idleSince).
+		Count(&count).Error
+
+	if err != nil {
+		r.log.Error("Failed to count idle sessions", zap.Error(err))
+		return 0, fmt.Errorf("failed to count sessions: %w", err)
+	}
+
+	return count, nil
+}
+
+// SetIdleStatus moves up to limit sessions in one of statuses with no
+// activity since idleSince to status, and returns their IDs. Archiving them
+// does not put them in the trash.
+func (r *sessionRepository) SetIdleStatus(ctx context.Context, statuses []models.SessionStatus, idleSince time.Time, status models.SessionStatus, limit int) ([]string, error) {
+	var ids []string
+
+	archivedAt := gorm.Expr("NULL")
+	if status == models.SessionStatusArchived {
+		archivedAt = gorm.Expr("NOW()")
+	}
+
+	err := r.db.WithContext(ctx).Raw(`
+		UPDATE sessions SET status = ?, archived_at = ?, updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM sessions
+			WHERE status IN ? AND last_activity < ?
+			LIMIT ?
+		)
+		RETURNING id`, status, archivedAt, statuses, idleSince, limit).
+		Scan(&ids).Error
+
+	if err != nil {
+		r.log.Error("Failed to update idle sessions",
+			zap.Error(err),
+			zap.String("status", status.String()))
+		return nil, fmt.Errorf("failed to update idle sessions: %w", err)
+	}
+
+	return ids, nil
+}
+
+// GetOwnerIDs lists, in order, up to limit distinct users who own a session,
+// starting after afterUserID.
+func (r *sessionRepository) GetOwnerIDs(ctx context.Context, afterUserID string, limit int) ([]string, error) {
+	var ids []string
+
+	query := r.db.WithContext(ctx).
+		Model(&models.Session{}).
+		Distinct("user_id").
+		Order("user_id ASC").
+		Limit(limit)
+	if afterUserID != "" {
+		query = query.Where("user_id > ?", afterUserID)
+	}
+
+	if err := query.Pluck("user_id", &ids).Error; err != nil {
+		r.log.Error("Failed to get session owners", zap.Error(err))
+		return nil, fmt.Errorf("failed to get session owners: %w", err)
+	}
+
+	return ids, nil
+}
+
 func (r *sessionRepository) UpdateLastActivity(ctx context.Context, sessionID string) error {
 	result := r.db.WithContext(ctx).
 		Model(&models.Session{}).
@@ -144,6 +447,28 @@ func (r *sessionRepository) UpdateLastActivity(ctx context.Context, sessionID st
 	return nil
 }
 
+// SetActiveLeaf records the message at the end of the session's active
+// branch. A nil messageID clears it.
+func (r *sessionRepository) SetActiveLeaf(ctx context.Context, sessionID string, messageID *string) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.Session{}).
+		Where("id = ?", sessionID).
+		Update("active_leaf_id", messageID)
+
+	if result.Error != nil {
+		r.log.Error("Failed to set active leaf",
+			zap.Error(result.Error),
+			zap.String("session_id", sessionID))
+		return fmt.Errorf("failed to set active leaf: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("session not found")
+	}
+
+	return nil
+}
+
 func (r *sessionRepository) GetActiveSessionsCount(ctx context.Context, userID string) (int64, error) {
 	var count int64
 	err := r.db.WithContext(ctx).
@@ -158,3 +483,140 @@ func (r *sessionRepository) GetActiveSessionsCount(ctx context.Context, userID s
 
 	return count, nil
 }
+
+// Import stores an imported session with its messages in one transaction.
+// Messages must come parents first with their IDs, order indexes and
+// timestamps already set. It returns false, storing nothing, when the user
+// has already imported a session with the same source and external ID.
+func (r *sessionRepository) Import(ctx context.Context, session *models.Session, messages []*models.Message) (bool, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return createWithMessages(tx, session, messages, clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "import_source"}, {Name: "import_external_id"}},
+			DoNothing: true,
+		})
+	})
+
+	if errors.Is(err, errAlreadyImported) {
+		return false, nil
+	}
+	if err != nil {
+		r.log.Error("Failed to import session",
+			zap.Error(err),
+			zap.String("user_id", session.UserID))
+		return false, fmt.Errorf("failed to import session: %w", err)
+	}
+
+	r.log.Info("Session imported successfully",
+		zap.String("session_id", session.ID),
+		zap.String("user_id", session.UserID),
+		zap.Int("message_count", len(messages)))
+	return true, nil
+}
+
+// Fork stores a session copied from another together with its messages, and
+// appends fork to the metadata of the session it was copied from, all in one
+// transaction. Messages must come parents first with their IDs and order
+// indexes already set.
+func (r *sessionRepository) Fork(ctx context.Context, session *models.Session, messages []*models.Message, fork models.SessionFork) error {
+	if session.ForkedFromSessionID == nil {
+		return fmt.Errorf("failed to fork session: no source session")
+	}
+	sourceID := *session.ForkedFromSessionID
+
+	forks, err := json.Marshal([]models.SessionFork{fork})
+	if err != nil {
+		return fmt.Errorf("failed to fork session: %w", err)
+	}
+
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := createWithMessages(tx, session, messages); err != nil {
+			return err
+		}
+
+		result := tx.Exec(`
+			UPDATE sessions
+			SET metadata = jsonb_set(COALESCE(metadata, '{}'::jsonb), '{forks}',
+				COALESCE(metadata->'forks', '[]'::jsonb) || ?::jsonb)
+			WHERE id = ?`, string(forks), sourceID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("session not found")
+		}
+		return nil
+	})
+	if err != nil {
+		r.log.Error("Failed to fork session",
+			zap.Error(err),
+			zap.String("source_session_id", sourceID),
+			zap.String("user_id", session.UserID))
+		return fmt.Errorf("failed to fork session: %w", err)
+	}
+
+	r.log.Info("Session forked successfully",
+		zap.String("session_id", session.ID),
+		zap.String("source_session_id", sourceID),
+		zap.Int("message_count", len(messages)))
+	return nil
+}
+
+// createWithMessages inserts a session, its owner's membership and its
+// messages, with a session_start event and a message_sent event for each
+// settled message, so analytics count a copied session like one written
+// here. The session is inserted with the given clauses; if they make the
+// insert a no-op, it returns errAlreadyImported.
+func createWithMessages(tx *gorm.DB, session *models.Session, messages []*models.Message, clauses ...clause.Expression) error {
+	// The active leaf references a message, so it can only be set once the
+	// messages exist.
+	activeLeafID := session.ActiveLeafID
+	session.ActiveLeafID = nil
+	defer func() { session.ActiveLeafID = activeLeafID }()
+
+	result := tx.Omit(clause.Associations).Clauses(clauses...).Create(session)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errAlreadyImported
+	}
+
+	if err := tx.Create(models.NewOwnerMember(session)).Error; err != nil {
+		return err
+	}
+
+	if err := addOutboxEvent(tx, models.NewSessionStartEvent(session)); err != nil {
+		return err
+	}
+
+	if len(messages) > 0 {
+		if err := tx.Omit(clause.Associations).CreateInBatches(messages, importBatchSize).Error; err != nil {
+			return err
+		}
+
+		events := make([]*models.OutboxEvent, 0, len(messages))
+		for _, message := range messages {
+			if message.Status != models.MessageStatusStreaming {
+				events = append(events, models.NewCopiedMessageEvent(session, message))
+			}
+		}
+		if err := addOutboxEvents(tx, events); err != nil {
+			return err
+		}
+
+		// The copied messages bring their own order_index, so move the
+		// session's counter past them in one go.
+		if err := tx.Exec(`UPDATE sessions SET last_order_index = (
+			SELECT COALESCE(MAX(order_index), 0) FROM messages WHERE session_id = ?
+		) WHERE id = ?`, session.ID, session.ID).Error; err != nil {
+			return err
+		}
+	}
+
+	if activeLeafID != nil {
+		if err := tx.Model(session).UpdateColumn("active_leaf_id", activeLeafID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Sourav01112/chat-service/internal/models"
+	"github.com/Sourav01112/chat-service/internal/repository"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type shareLinkRepository struct {
+	db  *gorm.DB
+	log *zap.Logger
+}
+
+func NewShareLinkRepository(db *gorm.DB, log *zap.Logger) repository.ShareLinkRepository {
+	return &shareLinkRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+func (r *shareLinkRepository) Create(ctx context.Context, link *models.ShareLink) error {
+	if err := r.db.WithContext(ctx).Create(link).Error; err != nil {
+		r.log.Error("Failed to create share link",
+			zap.Error(err),
+			zap.String("session_id", link.SessionID))
+		return fmt.Errorf("failed to create share link: %w", err)
+	}
+
+	r.log.Info("Share link created",
+		zap.String("share_link_id", link.ID),
+		zap.String("session_id", link.SessionID),
+		zap.String("mode", link.Mode.String()))
+	return nil
+}
+
+func (r *shareLinkRepository) GetByToken(ctx context.Context, token string) (*models.ShareLink, error) {
+	var link models.ShareLink
+
+	err := r.db.WithContext(ctx).
+		Where("token = ?", token).
+		First(&link).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("share link not found")
+		}
+		r.log.Error("Failed to get share link", zap.Error(err))
+		return nil, fmt.Errorf("failed to get share link: %w", err)
+	}
+
+	return &link, nil
+}
+
+// RecordView counts one more view of the link and returns the new total.
+func (r *shareLinkRepository) RecordView(ctx context.Context, linkID string) (int64, error) {
+	var link models.ShareLink
+
+	result := r.db.WithContext(ctx).
+		Model(&link).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "view_count"}}}).
+		Where("id = ?", linkID).
+		Updates(map[string]interface{}{
+			"view_count":     gorm.Expr("view_count + 1"),
+			"last_viewed_at": time.Now(),
+		})
+	if result.Error != nil {
+		r.log.Error("Failed to record share link view",
+			zap.Error(result.Error),
+			zap.String("share_link_id", linkID))
+		return 0, fmt.Errorf("failed to record share link view: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return 0, fmt.Errorf("share link not found")
+	}
+
+	return link.ViewCount, nil
+}
+
+func (r *shareLinkRepository) Revoke(ctx context.Context, token string, userID string) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.ShareLink{}).
+		Where("token = ? AND user_id = ? AND revoked_at IS NULL", token, userID).
+		Update("revoked_at", time.Now())
+
+	if result.Error != nil {
+		r.log.Error("Failed to revoke share link", zap.Error(result.Error))
+		return fmt.Errorf("failed to revoke share link: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("share link not found or not owned by user")
+	}
+
+	r.log.Info("Share link revoked", zap.String("user_id", userID))
+	return nil
+}
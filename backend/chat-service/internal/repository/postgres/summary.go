@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Sourav01112/chat-service/internal/models"
+	"github.com/Sourav01112/chat-service/internal/repository"
+)
+
+type summaryRepository struct {
+	db  *gorm.DB
+	log *zap.Logger
+}
+
+func NewSummaryRepository(db *gorm.DB, log *zap.Logger) repository.SummaryRepository {
+	return &summaryRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// Create stores summary as the session's next version. The session row is
+// locked so concurrent summaries number their versions in order.
+func (r *summaryRepository) Create(ctx context.Context, summary *models.SessionSummary) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").
+			Where("id = ?", summary.SessionID).
+			First(&models.Session{}).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("session not found")
+			}
+			return err
+		}
+
+		var latest int
+		if err := tx.Model(&models.SessionSummary{}).
+			Where("session_id = ?", summary.SessionID).
+			Select("COALESCE(MAX(version), 0)").
+			Scan(&latest).Error; err != nil {
+			return err
+		}
+
+		summary.Version = latest + 1
+		return tx.Create(summary).Error
+	})
+
+	if err != nil {
+		r.log.Error("Failed to create session summary",
+			zap.Error(err),
+			zap.String("session_id", summary.SessionID))
+		return fmt.Errorf("failed to create session summary: %w", err)
+	}
+
+	r.log.Info("Session summary created",
+		zap.String("session_id", summary.SessionID),
+		zap.Int("version", summary.Version),
+		zap.String("through_message_id", summary.ThroughMessageID))
+	return nil
+}
+
+// GetLatestForLeaf returns the valid summary reaching furthest along the
+// branch ending at leafID, or nil when there is none.
+func (r *summaryRepository) GetLatestForLeaf(ctx context.Context, sessionID string, leafID string) (*models.SessionSummary, error) {
+	var summaries []*models.SessionSummary
+
+	err := r.db.WithContext(ctx).
+		Where("session_id = ? AND invalidated_at IS NULL", sessionID).
+		Where("through_message_id IN ("+ancestorsSQL+")", leafID).
+		Order("through_order_index DESC, version DESC").
+		Limit(1).
+		Find(&summaries).Error
+
+	if err != nil {
+		r.log.Error("Failed to get session summary",
+			zap.Error(err),
+			zap.String("session_id", sessionID))
+		return nil, fmt.Errorf("failed to get session summary: %w", err)
+	}
+
+	if len(summaries) == 0 {
+		return nil, nil
+	}
+	return summaries[0], nil
+}
+
+func (r *summaryRepository) GetBySessionID(ctx context.Context, sessionID string) ([]*models.SessionSummary, error) {
+	var summaries []*models.SessionSummary
+
+	err := r.db.WithContext(ctx).
+		Where("session_id = ?", sessionID).
+		Order("version ASC").
+		Find(&summaries).Error
+
+	if err != nil {
+		r.log.Error("Failed to get session summaries",
+			zap.Error(err),
+			zap.String("session_id", sessionID))
+		return nil, fmt.Errorf("failed to get session summaries: %w", err)
+	}
+
+	return summaries, nil
+}
+
+// InvalidateFrom marks every valid summary covering the message at
+// orderIndex as invalid and returns them, newest first.
+func (r *summaryRepository) InvalidateFrom(ctx context.Context, sessionID string, orderIndex int) ([]*models.SessionSummary, error) {
+	var invalidated []*models.SessionSummary
+
+	err := r.db.WithContext(ctx).
+		Model(&invalidated).
+		Clauses(clause.Returning{}).
+		Where("session_id = ? AND through_order_index >= ? AND invalidated_at IS NULL", sessionID, orderIndex).
+		Update("invalidated_at", time.Now().UTC()).Error
+
+	if err != nil {
+		r.log.Error("Failed to invalidate session summaries",
+			zap.Error(err),
+			zap.String("session_id", sessionID))
+		return nil, fmt.Errorf("failed to invalidate session summaries: %w", err)
+	}
+
+	sort.Slice(invalidated, func(i, j int) bool {
+		return invalidated[i].Version > invalidated[j].Version
+	})
+
+	return invalidated, nil
+}
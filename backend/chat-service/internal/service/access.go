@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Sourav01112/chat-service/internal/models"
+	"github.com/Sourav01112/chat-service/internal/policy"
+)
+
+// authorize loads a session and checks that userID may perform action on it.
+// Every service method that takes a user goes through here, so users who are
+// not members get the same error whether or not the session exists.
+func (s *chatService) authorize(ctx context.Context, sessionID string, userID string, action policy.Action) (*models.Session, models.MemberRole, error) {
+	session, err := s.loadSession(ctx, sessionID)
+	if err != nil {
+		return nil, "", fmt.Errorf("session not found or access denied")
+	}
+
+	role, err := s.memberRole(ctx, session, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if role == "" {
+		return nil, "", fmt.Errorf("session not found or access denied")
+	}
+	if !policy.Allows(role, action) {
+		return nil, role, fmt.Errorf("access denied: %s cannot %s this session", role, action)
+	}
+
+	return session, role, nil
+}
+
+// sessionMessage loads a message and authorizes action on the session it
+// belongs to.
+func (s *chatService) sessionMessage(ctx context.Context, messageID string, userID string, action policy.Action) (*models.Message, models.MemberRole, error) {
+	message, err := s.messageRepo.GetByID(ctx, messageID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get message: %w", err)
+	}
+
+	_, role, err := s.authorize(ctx, message.SessionID, userID, action)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return message, role, nil
+}
+
+func (s *chatService) loadSession(ctx context.Context, sessionID string) (*models.Session, error) {
+	if session, err := s.cacheRepo.GetSession(ctx, sessionID); err == nil {
+		recordCacheLookup("session", true)
+		return session, nil
+	}
+	recordCacheLookup("session", false)
+
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = s.cacheRepo.SetSession(ctx, session, s.config.CacheTTLSessions)
+
+	return session, nil
+}
+
+// memberRole returns userID's role in session, or "" when they have none.
+// The owner is known from the session itself and needs no lookup.
+func (s *chatService) memberRole(ctx context.Context, session *models.Session, userID string) (models.MemberRole, error) {
+	if userID == "" {
+		return "", nil
+	}
+	if session.UserID == userID {
+		return models.MemberRoleOwner, nil
+	}
+
+	role, err := s.memberRepo.GetRole(ctx, session.ID, userID)
+	if err != nil {
+		return "", fmt.Errorf("failed to check session access: %w", err)
+	}
+	return role, nil
+}
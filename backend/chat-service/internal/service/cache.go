@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"expvar"
+	"time"
+
+	"github.com/Sourav01112/chat-service/internal/models"
+	"github.com/Sourav01112/chat-service/internal/repository"
+)
+
+// cacheMetrics is published at /debug/vars under "cache". It counts
+// "session.hits" and "session.misses" for session lookups, and
+// "recent_messages.hits" and "recent_messages.misses" for history pages that
+// could have been served from the cached end of the active branch.
+var cacheMetrics = expvar.NewMap("cache")
+
+func recordCacheLookup(name string, hit bool) {
+	if hit {
+		cacheMetrics.Add(name+".hits", 1)
+		return
+	}
+	cacheMetrics.Add(name+".misses", 1)
+}
+
+// cacheMessage adds a new message to the cached end of its session's active
+// branch, which drops the cached branch if the message starts another one.
+func (s *chatService) cacheMessage(ctx context.Context, message *models.Message) {
+	if err := s.attachSiblings(ctx, message.SessionID, []*models.Message{message}); err != nil {
+		_ = s.cacheRepo.DeleteRecentMessages(ctx, message.SessionID)
+		return
+	}
+
+	_ = s.cacheRepo.AppendRecentMessage(ctx, message, s.config.CacheTTLMessages)
+}
+
+// touchSession records activity in the session, in the database and in the
+// cached session.
+func (s *chatService) touchSession(ctx context.Context, sessionID string) {
+	_ = s.sessionRepo.UpdateLastActivity(ctx, sessionID)
+
+	now := time.Now().UTC()
+	_ = s.cacheRepo.PatchSession(ctx, sessionID, func(session *models.Session) {
+		session.LastActivity = now
+	})
+}
+
+// recentMessages returns the cached end of the session's active branch, or
+// nil if it is not cached or was cached for another branch.
+func (s *chatService) recentMessages(ctx context.Context, session *models.Session) *repository.RecentMessages {
+	recent, err := s.cacheRepo.GetRecentMessages(ctx, session.ID)
+	if err != nil {
+		return nil
+	}
+
+	if !sameMessageID(recent.LeafID, session.ActiveLeafID) {
+		_ = s.cacheRepo.DeleteRecentMessages(ctx, session.ID)
+		return nil
+	}
+
+	return recent
+}
+
+// recentPage returns the messages at offset on the branch, if they are all
+// cached.
+func recentPage(recent *repository.RecentMessages, offset int, limit int) ([]*models.Message, bool) {
+	first := recent.Total - int64(len(recent.Messages))
+	if int64(offset) < first {
+		return nil, false
+	}
+
+	from := int(int64(offset) - first)
+	if from > len(recent.Messages) {
+		from = len(recent.Messages)
+	}
+	to := from + limit
+	if to > len(recent.Messages) {
+		to = len(recent.Messages)
+	}
+
+	return recent.Messages[from:to], true
+}
+
+// latestPage returns the last limit messages on the branch, if they are all
+// cached.
+func latestPage(recent *repository.RecentMessages, limit int) ([]*models.Message, bool) {
+	if len(recent.Messages) < limit && int64(len(recent.Messages)) < recent.Total {
+		return nil, false
+	}
+
+	from := len(recent.Messages) - limit
+	if from < 0 {
+		from = 0
+	}
+
+	return recent.Messages[from:], true
+}
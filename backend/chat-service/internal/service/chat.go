@@ -3,42 +3,109 @@ package service
 import (
 	"context"
 	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"go.uber.org/zap"
 
+	"github.com/Sourav01112/chat-service/internal/ai"
 	"github.com/Sourav01112/chat-service/internal/config"
+	"github.com/Sourav01112/chat-service/internal/contextwindow"
 	"github.com/Sourav01112/chat-service/internal/models"
+	"github.com/Sourav01112/chat-service/internal/policy"
 	"github.com/Sourav01112/chat-service/internal/repository"
+	"github.com/Sourav01112/chat-service/internal/users"
 )
 
 type chatService struct {
-	sessionRepo repository.SessionRepository
-	messageRepo repository.MessageRepository
-	cacheRepo   repository.CacheRepository
-	config      *config.Config
-	validator   *validator.Validate
-	log         *zap.Logger
+	sessionRepo     repository.SessionRepository
+	messageRepo     repository.MessageRepository
+	summaryRepo     repository.SummaryRepository
+	shareLinkRepo   repository.ShareLinkRepository
+	memberRepo      repository.MemberRepository
+	folderRepo      repository.FolderRepository
+	tagRepo         repository.TagRepository
+	idempotencyRepo repository.IdempotencyRepository
+	cacheRepo       repository.CacheRepository
+	eventRepo       repository.EventRepository
+	aiClient        ai.Client
+	userClient      users.Client
+	contextBuilder  *contextwindow.Builder
+	config          *config.Config
+	validator       *validator.Validate
+	log             *zap.Logger
+
+	// summarizing and titling hold the IDs of sessions with a summary or a
+	// title being generated.
+	summarizing sync.Map
+	titling     sync.Map
 }
 
 func NewChatService(
 	sessionRepo repository.SessionRepository,
 	messageRepo repository.MessageRepository,
+	summaryRepo repository.SummaryRepository,
+	shareLinkRepo repository.ShareLinkRepository,
+	memberRepo repository.MemberRepository,
+	folderRepo repository.FolderRepository,
+	tagRepo repository.TagRepository,
+	idempotencyRepo repository.IdempotencyRepository,
 	cacheRepo repository.CacheRepository,
+	eventRepo repository.EventRepository,
+	aiClient ai.Client,
+	userClient users.Client,
+	contextBuilder *contextwindow.Builder,
 	config *config.Config,
 	log *zap.Logger,
 ) ChatService {
 	return &chatService{
-		sessionRepo: sessionRepo,
-		messageRepo: messageRepo,
-		cacheRepo:   cacheRepo,
-		config:      config,
-		validator:   validator.New(),
-		log:         log,
+		sessionRepo:     sessionRepo,
+		messageRepo:     messageRepo,
+		summaryRepo:     summaryRepo,
+		shareLinkRepo:   shareLinkRepo,
+		memberRepo:      memberRepo,
+		folderRepo:      folderRepo,
+		tagRepo:         tagRepo,
+		idempotencyRepo: idempotencyRepo,
+		cacheRepo:       cacheRepo,
+		eventRepo:       eventRepo,
+		aiClient:        aiClient,
+		userClient:      userClient,
+		contextBuilder:  contextBuilder,
+		config:          config,
+		validator:       validator.New(),
+		log:             log,
 	}
 }
 
+// CreateSession starts a session for the user. With an idempotency key, a
+// retry returns the session the first request created, as long as the user
+// can still read it.
 func (s *chatService) CreateSession(ctx context.Context, req *CreateSessionRequest) (*models.Session, error) {
+	var session *models.Session
+	sessionID, err := s.once(ctx, models.IdempotencyScopeCreateSession, req.UserID, req.IdempotencyKey, req, func() (string, error) {
+		var err error
+		session, err = s.createSession(ctx, req)
+		if err != nil {
+			return "", err
+		}
+		return session.ID, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		session, _, err = s.authorize(ctx, sessionID, req.UserID, policy.ActionRead)
+		return session, err
+	}
+
+	return session, nil
+}
+
+func (s *chatService) createSession(ctx context.Context, req *CreateSessionRequest) (*models.Session, error) {
 	if err := s.validator.Struct(req); err != nil {
 		return nil, fmt.Errorf("validation error: %w", err)
 	}
@@ -56,10 +123,15 @@ func (s *chatService) CreateSession(ctx context.Context, req *CreateSessionReque
 	}
 
 	session := &models.Session{
-		UserID:   req.UserID,
-		Title:    req.Title,
-		Status:   models.SessionStatusActive,
-		Settings: settings,
+		UserID:      req.UserID,
+		Title:       strings.TrimSpace(req.Title),
+		TitleSource: models.TitleSourceUser,
+		Status:      models.SessionStatusActive,
+		Settings:    settings,
+	}
+	if session.Title == "" {
+		session.Title = models.DefaultSessionTitle
+		session.TitleSource = models.TitleSourceDefault
 	}
 
 	if err := s.sessionRepo.Create(ctx, session); err != nil {
@@ -75,59 +147,131 @@ func (s *chatService) CreateSession(ctx context.Context, req *CreateSessionReque
 	return session, nil
 }
 
+// GetSession returns a session any member may read.
 func (s *chatService) GetSession(ctx context.Context, sessionID string, userID string) (*models.Session, error) {
-	if session, err := s.cacheRepo.GetSession(ctx, sessionID); err == nil {
-		if session.UserID == userID {
-			return session, nil
-		}
-		return nil, fmt.Errorf("session not found or access denied")
-	}
+	session, _, err := s.authorize(ctx, sessionID, userID, policy.ActionRead)
+	return session, err
+}
 
-	session, err := s.sessionRepo.GetByID(ctx, sessionID, userID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get session: %w", err)
+func (s *chatService) GetUserSessions(ctx context.Context, req *GetUserSessionsRequest) (*GetUserSessionsResponse, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
 	}
 
-	_ = s.cacheRepo.SetSession(ctx, session, s.config.CacheTTLSessions)
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+	if req.Limit > 100 {
+		req.Limit = 100
+	}
 
-	return session, nil
-}
+	if req.SortBy == "" {
+		req.SortBy = models.SessionSortLastActivity
+	}
+	if !req.SortBy.IsValid() {
+		return nil, fmt.Errorf("invalid sort: %s", req.SortBy)
+	}
+	for _, status := range req.Statuses {
+		if !status.IsValid() {
+			return nil, fmt.Errorf("invalid session status: %s", status)
+		}
+	}
 
-func (s *chatService) GetUserSessions(ctx context.Context, userID string, limit, offset int) (*GetUserSessionsResponse, error) {
-	if limit <= 0 {
-		limit = 20
+	filter := repository.SessionFilter{
+		Statuses:      req.Statuses,
+		FolderID:      req.FolderID,
+		TagID:         req.TagID,
+		FavoritesOnly: req.FavoritesOnly,
+		TitlePrefix:   req.TitlePrefix,
+		Sort:          req.SortBy,
 	}
-	if limit > 100 {
-		limit = 100
+
+	if req.Cursor != "" || req.Direction != "" {
+		return s.getUserSessionsPage(ctx, req, filter)
 	}
 
-	sessions, total, err := s.sessionRepo.GetByUserID(ctx, userID, limit, offset)
+	sessions, total, err := s.sessionRepo.GetByUserID(ctx, req.UserID, filter, req.Limit, req.Offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user sessions: %w", err)
 	}
 
+	if err := s.attachTags(ctx, req.UserID, sessions); err != nil {
+		return nil, err
+	}
+
 	return &GetUserSessionsResponse{
 		Sessions:   sessions,
 		TotalCount: total,
-		HasMore:    int64(offset+len(sessions)) < total,
+		HasMore:    int64(req.Offset+len(sessions)) < total,
 	}, nil
 }
 
+func (s *chatService) getUserSessionsPage(ctx context.Context, req *GetUserSessionsRequest, filter repository.SessionFilter) (*GetUserSessionsResponse, error) {
+	direction, err := pageDirection(req.Direction)
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor *models.SessionCursor
+	if req.Cursor != "" {
+		if cursor, err = models.DecodeSessionCursor(req.Cursor); err != nil {
+			return nil, err
+		}
+		if cursor.SortOrDefault() != req.SortBy {
+			return nil, fmt.Errorf("cursor was issued for a different sort")
+		}
+	}
+
+	sessions, hasMore, err := s.sessionRepo.GetPageByUserID(ctx, req.UserID, filter, cursor, direction, req.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user sessions: %w", err)
+	}
+
+	if err := s.attachTags(ctx, req.UserID, sessions); err != nil {
+		return nil, err
+	}
+
+	response := &GetUserSessionsResponse{
+		Sessions: sessions,
+		HasMore:  hasMore,
+	}
+
+	// Sessions are listed most recent first, so the newer cursor sits on the
+	// first row and the older one on the last.
+	if len(sessions) > 0 {
+		response.NewerCursor = models.NewSessionCursor(sessions[0], req.SortBy).Encode()
+		response.OlderCursor = models.NewSessionCursor(sessions[len(sessions)-1], req.SortBy).Encode()
+	}
+
+	if req.IncludeTotal {
+		if response.TotalCount, err = s.sessionRepo.CountByUserID(ctx, req.UserID, filter); err != nil {
+			return nil, fmt.Errorf("failed to count user sessions: %w", err)
+		}
+	}
+
+	return response, nil
+}
+
 func (s *chatService) UpdateSession(ctx context.Context, req *UpdateSessionRequest) (*models.Session, error) {
 	if err := s.validator.Struct(req); err != nil {
 		return nil, fmt.Errorf("validation error: %w", err)
 	}
 
-	session, err := s.GetSession(ctx, req.SessionID, req.UserID)
+	session, _, err := s.authorize(ctx, req.SessionID, req.UserID, policy.ActionManage)
 	if err != nil {
 		return nil, err
 	}
 
+	statusChanged := req.Status != nil && *req.Status != session.Status
+	settingsChanged := req.Settings != nil
+	titleChanged := req.Title != nil && *req.Title != session.Title
+
 	if req.Title != nil {
 		session.Title = *req.Title
+		session.TitleSource = models.TitleSourceUser
 	}
 	if req.Status != nil {
-		session.Status = *req.Status
+		session.SetStatus(*req.Status)
 	}
 	if req.Settings != nil {
 		session.Settings = *req.Settings
@@ -137,7 +281,19 @@ func (s *chatService) UpdateSession(ctx context.Context, req *UpdateSessionReque
 		return nil, fmt.Errorf("failed to update session: %w", err)
 	}
 
-	_ = s.cacheRepo.SetSession(ctx, session, s.config.CacheTTLSessions)
+	// The session may have come from the cache, so writing it back could
+	// undo a send that moved the active leaf in the meantime.
+	_ = s.cacheRepo.DeleteSession(ctx, session.ID)
+
+	if statusChanged {
+		s.publishEvent(ctx, models.NewSessionEvent(models.EventTypeSessionStatusChanged, session))
+	}
+	if settingsChanged {
+		s.publishEvent(ctx, models.NewSessionEvent(models.EventTypeSessionSettingsChanged, session))
+	}
+	if titleChanged {
+		s.publishEvent(ctx, models.NewSessionEvent(models.EventTypeSessionUpdated, session))
+	}
 
 	s.log.Info("Session updated successfully", zap.String("session_id", session.ID))
 
@@ -145,12 +301,25 @@ func (s *chatService) UpdateSession(ctx context.Context, req *UpdateSessionReque
 }
 
 func (s *chatService) DeleteSession(ctx context.Context, sessionID string, userID string) error {
-	if err := s.sessionRepo.Delete(ctx, sessionID, userID); err != nil {
+	session, _, err := s.authorize(ctx, sessionID, userID, policy.ActionManage)
+	if err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+
+	if err := s.sessionRepo.Delete(ctx, sessionID); err != nil {
 		return fmt.Errorf("failed to delete session: %w", err)
 	}
 
 	_ = s.cacheRepo.InvalidateSessionCache(ctx, sessionID)
 
+	s.publishEvent(ctx, &models.SessionEvent{
+		SessionID: sessionID,
+		Type:      models.EventTypeSessionStatusChanged,
+		UserID:    userID,
+		Session:   &models.Session{ID: sessionID, UserID: session.UserID, Status: models.SessionStatusArchived},
+		CreatedAt: time.Now().UTC(),
+	})
+
 	s.log.Info("Session deleted successfully",
 		zap.String("session_id", sessionID),
 		zap.String("user_id", userID))
@@ -158,7 +327,33 @@ func (s *chatService) DeleteSession(ctx context.Context, sessionID string, userI
 	return nil
 }
 
+// SendMessage stores a message in the session. With an idempotency key, a
+// retry returns the message the first request stored, as long as the user
+// can still write in the session.
 func (s *chatService) SendMessage(ctx context.Context, req *SendMessageRequest) (*models.Message, error) {
+	var message *models.Message
+	messageID, err := s.once(ctx, models.IdempotencyScopeSendMessage, req.UserID, req.IdempotencyKey, req, func() (string, error) {
+		var err error
+		message, err = s.sendMessage(ctx, req)
+		if err != nil {
+			return "", err
+		}
+		return message.ID, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if message == nil {
+		if _, _, err := s.authorize(ctx, req.SessionID, req.UserID, policy.ActionWrite); err != nil {
+			return nil, err
+		}
+		return s.messageRepo.GetByID(ctx, messageID)
+	}
+
+	return message, nil
+}
+
+func (s *chatService) sendMessage(ctx context.Context, req *SendMessageRequest) (*models.Message, error) {
 	if err := s.validator.Struct(req); err != nil {
 		return nil, fmt.Errorf("validation error: %w", err)
 	}
@@ -171,7 +366,7 @@ func (s *chatService) SendMessage(ctx context.Context, req *SendMessageRequest)
 		return nil, fmt.Errorf("message too long: max %d characters", s.config.MaxMessageLength)
 	}
 
-	session, err := s.GetSession(ctx, req.SessionID, req.UserID)
+	session, _, err := s.authorize(ctx, req.SessionID, req.UserID, policy.ActionWrite)
 	if err != nil {
 		return nil, err
 	}
@@ -187,22 +382,37 @@ func (s *chatService) SendMessage(ctx context.Context, req *SendMessageRequest)
 		return nil, fmt.Errorf("maximum messages per session limit exceeded")
 	}
 
+	// New messages continue the active branch unless the caller picks a
+	// different parent, which starts a new branch there.
+	parentID := session.ActiveLeafID
+	if req.ParentMessageID != nil {
+		parent, err := s.messageRepo.GetByID(ctx, *req.ParentMessageID)
+		if err != nil || parent.SessionID != req.SessionID {
+			return nil, fmt.Errorf("parent message not found in session")
+		}
+		parentID = req.ParentMessageID
+	}
+
 	message := &models.Message{
 		SessionID:       req.SessionID,
 		UserID:          req.UserID,
 		Content:         req.Content,
 		Type:            req.Type,
 		Metadata:        req.Metadata,
-		ParentMessageID: req.ParentMessageID,
+		ParentMessageID: parentID,
 	}
 
 	if err := s.messageRepo.Create(ctx, message); err != nil {
 		return nil, fmt.Errorf("failed to create message: %w", err)
 	}
 
-	_ = s.sessionRepo.UpdateLastActivity(ctx, req.SessionID)
+	s.setActiveLeaf(ctx, req.SessionID, &message.ID)
 
-	_ = s.cacheRepo.InvalidateSessionCache(ctx, req.SessionID)
+	s.touchSession(ctx, req.SessionID)
+
+	s.cacheMessage(ctx, message)
+
+	s.publishEvent(ctx, models.NewMessageEvent(models.EventTypeMessageCreated, message))
 
 	s.log.Info("Message sent successfully",
 		zap.String("message_id", message.ID),
@@ -212,115 +422,1193 @@ func (s *chatService) SendMessage(ctx context.Context, req *SendMessageRequest)
 	return message, nil
 }
 
-func (s *chatService) GetChatHistory(ctx context.Context, req *GetChatHistoryRequest) (*GetChatHistoryResponse, error) {
-	if err := s.validator.Struct(req); err != nil {
-		return nil, fmt.Errorf("validation error: %w", err)
+// SendMessageAndReply stores the user message, asks the AI service for a reply
+// and stores it as an assistant message linked to the user message. If the AI
+// call fails the user message is kept and returned alongside the error.
+//
+// With an idempotency key, a retry returns the messages the first request
+// stored. Only if that request stored no reply is one generated now.
+func (s *chatService) SendMessageAndReply(ctx context.Context, req *SendMessageRequest) (*SendMessageAndReplyResponse, error) {
+	if req.Type == "" {
+		req.Type = models.MessageTypeUser
+	}
+	if req.Type != models.MessageTypeUser {
+		return nil, fmt.Errorf("only user messages can request a reply")
 	}
 
-	_, err := s.GetSession(ctx, req.SessionID, req.UserID)
+	// The key is held until the reply is stored, so a retry cannot start a
+	// second one while the first is being generated. It is completed once
+	// the user message exists, even if the reply then fails.
+	var response *SendMessageAndReplyResponse
+	var replyErr error
+	userMessageID, err := s.once(ctx, models.IdempotencyScopeSendMessageAndReply, req.UserID, req.IdempotencyKey, req, func() (string, error) {
+		userMessage, err := s.sendMessage(ctx, req)
+		if err != nil {
+			return "", err
+		}
+		response, replyErr = s.reply(ctx, req, userMessage)
+		return userMessage.ID, nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	if response != nil {
+		return response, replyErr
+	}
 
-	if req.Limit <= 0 {
-		req.Limit = 50
+	userMessage, assistantMessage, err := s.storedReply(ctx, req, userMessageID)
+	if err != nil {
+		return nil, err
 	}
-	if req.Limit > 100 {
-		req.Limit = 100
+	if assistantMessage != nil {
+		return &SendMessageAndReplyResponse{UserMessage: userMessage, AssistantMessage: assistantMessage}, nil
 	}
 
-	if req.Offset == 0 && req.FromDate == nil && req.ToDate == nil {
-		if cachedMessages, err := s.cacheRepo.GetRecentMessages(ctx, req.SessionID); err == nil {
-			if len(cachedMessages) >= req.Limit {
-				return &GetChatHistoryResponse{
-					Messages:   cachedMessages[:req.Limit],
-					TotalCount: int64(len(cachedMessages)),
-					HasMore:    len(cachedMessages) > req.Limit,
-				}, nil
-			}
-		}
-	}
+	return s.reply(ctx, req, userMessage)
+}
+
+// reply asks the AI service for a reply to userMessage and stores it.
+func (s *chatService) reply(ctx context.Context, req *SendMessageRequest, userMessage *models.Message) (*SendMessageAndReplyResponse, error) {
+	response := &SendMessageAndReplyResponse{UserMessage: userMessage}
 
-	messages, total, err := s.messageRepo.GetBySessionID(ctx, req.SessionID, req.Limit, req.Offset)
+	generateReq, err := s.buildGenerateRequest(ctx, req, userMessage)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get chat history: %w", err)
+		return response, err
 	}
 
-	if req.Offset == 0 && len(messages) > 0 {
-		_ = s.cacheRepo.SetRecentMessages(ctx, req.SessionID, messages, s.config.CacheTTLMessages)
+	response.AssistantMessage, err = s.generateReply(ctx, generateReq, userMessage)
+	if err != nil {
+		return response, err
 	}
 
-	return &GetChatHistoryResponse{
-		Messages:   messages,
-		TotalCount: total,
-		HasMore:    int64(req.Offset+len(messages)) < total,
-	}, nil
+	return response, nil
 }
 
-func (s *chatService) DeleteMessage(ctx context.Context, messageID string, userID string) error {
-	if err := s.messageRepo.Delete(ctx, messageID, userID); err != nil {
-		return fmt.Errorf("failed to delete message: %w", err)
+// generateReply asks the AI service to answer parent and stores the answer as
+// a child of it at the end of the active branch.
+func (s *chatService) generateReply(ctx context.Context, generateReq *ai.GenerateRequest, parent *models.Message) (*models.Message, error) {
+	start := time.Now()
+	reply, err := s.aiClient.GenerateResponse(ctx, generateReq)
+	if err != nil {
+		s.log.Error("Failed to generate AI reply",
+			zap.Error(err),
+			zap.String("session_id", parent.SessionID),
+			zap.String("message_id", parent.ID))
+		return nil, fmt.Errorf("failed to generate reply: %w", err)
 	}
 
-	s.log.Info("Message deleted successfully",
-		zap.String("message_id", messageID),
-		zap.String("user_id", userID))
+	metadata := reply.Metadata
+	if metadata.ResponseTimeMs == 0 {
+		metadata.ResponseTimeMs = float64(time.Since(start).Microseconds()) / 1000
+	}
+	if metadata.ModelUsed == "" {
+		metadata.ModelUsed = generateReq.Model
+	}
 
-	return nil
+	assistantMessage := &models.Message{
+		SessionID:       parent.SessionID,
+		UserID:          generateReq.UserID,
+		Content:         reply.Content,
+		Type:            models.MessageTypeAssistant,
+		Metadata:        metadata,
+		ParentMessageID: &parent.ID,
+	}
+
+	if err := s.messageRepo.Create(ctx, assistantMessage); err != nil {
+		return nil, fmt.Errorf("failed to create reply: %w", err)
+	}
+
+	s.setActiveLeaf(ctx, parent.SessionID, &assistantMessage.ID)
+
+	s.touchSession(ctx, parent.SessionID)
+
+	s.cacheMessage(ctx, assistantMessage)
+
+	s.publishEvent(ctx, models.NewMessageEvent(models.EventTypeMessageCreated, assistantMessage))
+
+	s.log.Info("Assistant reply stored",
+		zap.String("message_id", assistantMessage.ID),
+		zap.String("parent_message_id", parent.ID),
+		zap.String("model", metadata.ModelUsed),
+		zap.Int("token_count", metadata.TokenCount))
+
+	s.generateTitleInBackground(ctx, parent, assistantMessage)
+
+	return assistantMessage, nil
 }
 
-func (s *chatService) SearchMessages(ctx context.Context, req *SearchMessagesRequest) (*SearchMessagesResponse, error) {
+// RegenerateMessage answers an assistant message's prompt again and stores
+// the new answer as a sibling, leaving the original and its metadata in place
+// for comparison. The new answer becomes the active branch.
+func (s *chatService) RegenerateMessage(ctx context.Context, req *RegenerateMessageRequest) (*models.Message, error) {
 	if err := s.validator.Struct(req); err != nil {
 		return nil, fmt.Errorf("validation error: %w", err)
 	}
 
-	_, err := s.GetSession(ctx, req.SessionID, req.UserID)
+	original, _, err := s.sessionMessage(ctx, req.MessageID, req.UserID, policy.ActionWrite)
 	if err != nil {
 		return nil, err
 	}
 
-	if req.Limit <= 0 {
-		req.Limit = 20
+	if original.Type != models.MessageTypeAssistant {
+		return nil, fmt.Errorf("only assistant messages can be regenerated")
 	}
-	if req.Limit > 100 {
-		req.Limit = 100
+	if original.ParentMessageID == nil {
+		return nil, fmt.Errorf("message has no prompt to regenerate from")
 	}
 
-	messages, total, err := s.messageRepo.SearchInSession(ctx, req.SessionID, req.Query, req.Limit, req.Offset)
+	prompt, err := s.messageRepo.GetByID(ctx, *original.ParentMessageID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to search messages: %w", err)
+		return nil, fmt.Errorf("failed to get prompt message: %w", err)
+	}
+	if prompt.Type != models.MessageTypeUser {
+		return nil, fmt.Errorf("message has no prompt to regenerate from")
 	}
 
-	return &SearchMessagesResponse{
-		Messages:   messages,
-		TotalCount: total,
-		HasMore:    int64(req.Offset+len(messages)) < total,
-	}, nil
+	session, err := s.GetSession(ctx, original.SessionID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !session.IsActive() {
+		return nil, fmt.Errorf("cannot regenerate messages in inactive session")
+	}
+
+	settings := session.Settings
+	if req.Temperature != nil {
+		settings.Temperature = *req.Temperature
+	}
+
+	history, err := s.promptHistory(ctx, session, prompt, req.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	regenerated, err := s.generateReply(ctx, &ai.GenerateRequest{
+		SessionID:   session.ID,
+		UserID:      req.UserID,
+		UserMessage: prompt.Content,
+		History:     history,
+		Settings:    settings,
+		Model:       req.Model,
+	}, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.attachSiblings(ctx, session.ID, []*models.Message{regenerated}); err != nil {
+		s.log.Warn("Failed to load regenerated message siblings", zap.Error(err))
+	}
+
+	return regenerated, nil
 }
 
-func (s *chatService) UpdateTypingStatus(ctx context.Context, req *UpdateTypingStatusRequest) error {
-	if err := s.validator.Struct(req); err != nil {
-		return fmt.Errorf("validation error: %w", err)
+// StreamReply works like SendMessageAndReply but relays the AI service's
+// response stream through send. The assistant message is stored up front with
+// status streaming and its partial content is saved every StreamSaveInterval;
+// if the stream breaks or the client goes away it is kept as incomplete.
+func (s *chatService) StreamReply(ctx context.Context, req *SendMessageRequest, send func(*StreamReplyEvent) error) error {
+	if req.Type == "" {
+		req.Type = models.MessageTypeUser
+	}
+	if req.Type != models.MessageTypeUser {
+		return fmt.Errorf("only user messages can request a reply")
 	}
 
-	_, err := s.GetSession(ctx, req.SessionID, req.UserID)
+	// As in SendMessageAndReply, the key covers the whole reply.
+	streamed := false
+	var streamErr error
+	userMessageID, err := s.once(ctx, models.IdempotencyScopeSendMessageAndReply, req.UserID, req.IdempotencyKey, req, func() (string, error) {
+		userMessage, err := s.sendMessage(ctx, req)
+		if err != nil {
+			return "", err
+		}
+		streamed = true
+		streamErr = s.streamReply(ctx, req, userMessage, send)
+		return userMessage.ID, nil
+	})
 	if err != nil {
 		return err
 	}
+	if streamed {
+		return streamErr
+	}
 
-	err = s.cacheRepo.SetTypingStatus(ctx, req.SessionID, req.UserID, req.IsTyping, s.config.CacheTTLTyping)
+	userMessage, assistantMessage, err := s.storedReply(ctx, req, userMessageID)
 	if err != nil {
-		return fmt.Errorf("failed to update typing status: %w", err)
+		return err
+	}
+	if assistantMessage == nil {
+		return s.streamReply(ctx, req, userMessage, send)
 	}
 
-	return nil
+	// A replayed reply is sent whole, as one delta, whatever state the
+	// first request left it in.
+	if err := send(&StreamReplyEvent{
+		UserMessage:        userMessage,
+		AssistantMessageID: assistantMessage.ID,
+	}); err != nil {
+		return err
+	}
+	return send(&StreamReplyEvent{
+		AssistantMessageID: assistantMessage.ID,
+		Delta:              assistantMessage.Content,
+		IsFinal:            true,
+		AssistantMessage:   assistantMessage,
+	})
 }
 
-func (s *chatService) GetTypingUsers(ctx context.Context, sessionID string) ([]string, error) {
-	users, err := s.cacheRepo.GetTypingUsers(ctx, sessionID)
+// streamReply streams the AI service's reply to userMessage, storing it as
+// it arrives.
+func (s *chatService) streamReply(ctx context.Context, req *SendMessageRequest, userMessage *models.Message, send func(*StreamReplyEvent) error) error {
+	generateReq, err := s.buildGenerateRequest(ctx, req, userMessage)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get typing users: %w", err)
+		return err
 	}
 
-	return users, nil
+	assistantMessage := &models.Message{
+		SessionID:       req.SessionID,
+		UserID:          req.UserID,
+		Type:            models.MessageTypeAssistant,
+		Status:          models.MessageStatusStreaming,
+		ParentMessageID: &userMessage.ID,
+	}
+
+	if err := s.messageRepo.Create(ctx, assistantMessage); err != nil {
+		return fmt.Errorf("failed to create reply: %w", err)
+	}
+
+	s.setActiveLeaf(ctx, req.SessionID, &assistantMessage.ID)
+
+	s.cacheMessage(ctx, assistantMessage)
+
+	s.publishEvent(ctx, models.NewMessageEvent(models.EventTypeMessageCreated, assistantMessage))
+
+	if err := send(&StreamReplyEvent{
+		UserMessage:        userMessage,
+		AssistantMessageID: assistantMessage.ID,
+	}); err != nil {
+		s.abortStreamedReply(ctx, assistantMessage, err)
+		return err
+	}
+
+	start := time.Now()
+	stream, err := s.aiClient.GenerateStreamResponse(ctx, generateReq)
+	if err != nil {
+		s.abortStreamedReply(ctx, assistantMessage, err)
+		return fmt.Errorf("failed to generate reply: %w", err)
+	}
+
+	var content strings.Builder
+	var final *ai.GenerateResponse
+	lastSave := time.Now()
+
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			err = fmt.Errorf("AI service closed the stream before the final chunk")
+		}
+		if err != nil {
+			assistantMessage.Content = content.String()
+			s.abortStreamedReply(ctx, assistantMessage, err)
+			return fmt.Errorf("failed to generate reply: %w", err)
+		}
+
+		if chunk.IsFinal {
+			content.WriteString(chunk.Content)
+			final = chunk
+			break
+		}
+
+		content.WriteString(chunk.Content)
+
+		if err := send(&StreamReplyEvent{
+			AssistantMessageID: assistantMessage.ID,
+			Delta:              chunk.Content,
+		}); err != nil {
+			assistantMessage.Content = content.String()
+			s.abortStreamedReply(ctx, assistantMessage, err)
+			return err
+		}
+
+		if time.Since(lastSave) >= s.config.StreamSaveInterval {
+			assistantMessage.Content = content.String()
+			if err := s.messageRepo.UpdateContent(ctx, assistantMessage); err != nil {
+				s.log.Warn("Failed to save partial reply",
+					zap.Error(err),
+					zap.String("message_id", assistantMessage.ID))
+			}
+			lastSave = time.Now()
+		}
+	}
+
+	metadata := final.Metadata
+	if metadata.ResponseTimeMs == 0 {
+		metadata.ResponseTimeMs = float64(time.Since(start).Microseconds()) / 1000
+	}
+
+	assistantMessage.Content = content.String()
+	assistantMessage.Metadata = metadata
+	assistantMessage.Status = models.MessageStatusComplete
+
+	// The reply is whole by now, so a client hanging up must not stop it
+	// being saved; if saving fails anyway it is kept as incomplete.
+	ctx = context.WithoutCancel(ctx)
+
+	if err := s.messageRepo.UpdateContent(ctx, assistantMessage); err != nil {
+		s.abortStreamedReply(ctx, assistantMessage, err)
+		return fmt.Errorf("failed to save reply: %w", err)
+	}
+
+	s.touchSession(ctx, req.SessionID)
+
+	_ = s.cacheRepo.UpdateRecentMessage(ctx, assistantMessage)
+
+	s.publishEvent(ctx, models.NewMessageEvent(models.EventTypeMessageUpdated, assistantMessage))
+
+	s.log.Info("Streamed assistant reply stored",
+		zap.String("message_id", assistantMessage.ID),
+		zap.String("parent_message_id", userMessage.ID),
+		zap.String("model", metadata.ModelUsed),
+		zap.Int("token_count", metadata.TokenCount))
+
+	s.generateTitleInBackground(ctx, userMessage, assistantMessage)
+
+	return send(&StreamReplyEvent{
+		AssistantMessageID: assistantMessage.ID,
+		Delta:              final.Content,
+		IsFinal:            true,
+		AssistantMessage:   assistantMessage,
+	})
+}
+
+// abortStreamedReply keeps whatever was received so far and marks the reply
+// incomplete. It uses a context detached from ctx because the usual reason to
+// get here is that the client cancelled.
+func (s *chatService) abortStreamedReply(ctx context.Context, message *models.Message, cause error) {
+	ctx = context.WithoutCancel(ctx)
+
+	message.Status = models.MessageStatusIncomplete
+	if err := s.messageRepo.UpdateContent(ctx, message); err != nil {
+		s.log.Error("Failed to mark reply incomplete",
+			zap.Error(err),
+			zap.String("message_id", message.ID))
+	}
+
+	_ = s.cacheRepo.UpdateRecentMessage(ctx, message)
+
+	s.publishEvent(ctx, models.NewMessageEvent(models.EventTypeMessageUpdated, message))
+
+	s.log.Warn("Streamed reply aborted",
+		zap.Error(cause),
+		zap.String("message_id", message.ID),
+		zap.Int("content_length", len(message.Content)))
+}
+
+// buildGenerateRequest assembles the AI request for userMessage from the
+// session settings and as much of the branch before it as fits the model's
+// context window.
+func (s *chatService) buildGenerateRequest(ctx context.Context, req *SendMessageRequest, userMessage *models.Message) (*ai.GenerateRequest, error) {
+	session, err := s.GetSession(ctx, req.SessionID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := s.promptHistory(ctx, session, userMessage, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ai.GenerateRequest{
+		SessionID:   req.SessionID,
+		UserID:      req.UserID,
+		UserMessage: userMessage.Content,
+		History:     history,
+		Settings:    session.Settings,
+	}, nil
+}
+
+// promptHistory picks the messages to send along with prompt. It looks back
+// at most AIContextMessages along the branch ending at prompt, adds the
+// branch's pinned messages and latest summary, and lets the context builder
+// trim the result to the model's token budget. The builder's trace is logged
+// so an odd answer can be matched to what the model actually saw. Once the
+// turns after the summary that are not sent, whether trimmed by the builder
+// or older than the lookback, pass SummaryThresholdTokens they are
+// summarized in the background for the next prompt.
+func (s *chatService) promptHistory(ctx context.Context, session *models.Session, prompt *models.Message, model string) ([]*models.Message, error) {
+	// A missing summary only costs context, so the prompt goes ahead without.
+	summary, err := s.summaryRepo.GetLatestForLeaf(ctx, session.ID, prompt.ID)
+	if err != nil {
+		s.log.Warn("Building prompt context without summary",
+			zap.Error(err),
+			zap.String("session_id", session.ID))
+	}
+
+	summarized := -1
+	if summary != nil {
+		summarized = summary.ThroughOrderIndex
+	}
+
+	unsummarized, err := s.messageRepo.GetPathAfter(ctx, prompt.ID, summarized, s.config.MaxMessagesPerSession)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load conversation context: %w", err)
+	}
+
+	history, older := unsummarized, []*models.Message(nil)
+	if lookback := s.config.AIContextMessages + 1; len(history) > lookback {
+		older, history = history[:len(history)-lookback], history[len(history)-lookback:]
+	}
+
+	pinned, _, err := s.messageRepo.GetBySessionID(ctx, session.ID, repository.MessageFilter{
+		Tags:   []string{models.MessageTagPinned},
+		LeafID: prompt.ID,
+	}, s.config.AIContextMessages, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pinned messages: %w", err)
+	}
+
+	window := s.contextBuilder.Build(contextwindow.Input{
+		Model:          model,
+		SystemPrompt:   session.Settings.SystemPrompt,
+		Prompt:         prompt,
+		History:        history,
+		Pinned:         pinned,
+		Summary:        summary,
+		ReservedTokens: session.Settings.MaxTokens,
+	})
+
+	dropped, droppedTokens := window.Dropped()
+	newestDropped := window.NewestDropped()
+
+	isPinned := make(map[string]bool, len(pinned))
+	for _, message := range pinned {
+		isPinned[message.ID] = true
+	}
+	for i := len(older) - 1; i >= 0; i-- {
+		if isPinned[older[i].ID] {
+			continue
+		}
+		if newestDropped == "" {
+			newestDropped = older[i].ID
+		}
+		dropped++
+		droppedTokens += s.contextBuilder.MessageTokens(older[i])
+	}
+
+	s.log.Info("Built prompt context",
+		zap.String("session_id", session.ID),
+		zap.String("message_id", prompt.ID),
+		zap.String("model", model),
+		zap.Int("budget", window.Budget),
+		zap.Int("used_tokens", window.UsedTokens),
+		zap.Int("messages", len(window.Messages)),
+		zap.Int("dropped", dropped),
+		zap.Bool("summarized", summary != nil))
+	s.log.Debug("Prompt context trace",
+		zap.String("message_id", prompt.ID),
+		zap.Any("trace", window.Trace))
+
+	if droppedTokens >= s.config.SummaryThresholdTokens {
+		s.summarizeInBackground(ctx, session, newestDropped)
+	}
+
+	return window.Messages, nil
+}
+
+func (s *chatService) GetChatHistory(ctx context.Context, req *GetChatHistoryRequest) (*GetChatHistoryResponse, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	session, err := s.GetSession(ctx, req.SessionID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = 50
+	}
+	if req.Limit > 100 {
+		req.Limit = 100
+	}
+
+	filter, err := historyFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// The cache holds the end of the active branch, unfiltered, so only
+	// unfiltered pages that fall within it can be served from there. A page
+	// read from the database that reaches the end of the branch fills it.
+	cacheable := filter.IsEmpty()
+	var recent *repository.RecentMessages
+	if cacheable {
+		recent = s.recentMessages(ctx, session)
+	}
+
+	if session.ActiveLeafID != nil {
+		filter.LeafID = *session.ActiveLeafID
+	}
+
+	if req.Cursor != "" || req.Direction != "" {
+		return s.getChatHistoryPage(ctx, req, session, filter, cacheable, recent)
+	}
+
+	if recent != nil {
+		if messages, ok := recentPage(recent, req.Offset, req.Limit); ok {
+			recordCacheLookup("recent_messages", true)
+			return &GetChatHistoryResponse{
+				Messages:   messages,
+				TotalCount: recent.Total,
+				HasMore:    int64(req.Offset+len(messages)) < recent.Total,
+			}, nil
+		}
+	}
+	if cacheable {
+		recordCacheLookup("recent_messages", false)
+	}
+
+	messages, total, err := s.messageRepo.GetBySessionID(ctx, req.SessionID, filter, req.Limit, req.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat history: %w", err)
+	}
+
+	if err := s.attachSiblings(ctx, req.SessionID, messages); err != nil {
+		return nil, err
+	}
+
+	if cacheable && recent == nil && int64(req.Offset+len(messages)) == total {
+		s.cacheRecentMessages(ctx, session, messages, total)
+	}
+
+	return &GetChatHistoryResponse{
+		Messages:   messages,
+		TotalCount: total,
+		HasMore:    int64(req.Offset+len(messages)) < total,
+	}, nil
+}
+
+func historyFilter(req *GetChatHistoryRequest) (repository.MessageFilter, error) {
+	if req.FromDate != nil && req.ToDate != nil && req.FromDate.After(*req.ToDate) {
+		return repository.MessageFilter{}, fmt.Errorf("from_date must not be after to_date")
+	}
+
+	for _, messageType := range req.MessageTypes {
+		if !messageType.IsValid() {
+			return repository.MessageFilter{}, fmt.Errorf("invalid message type: %s", messageType)
+		}
+	}
+
+	return repository.MessageFilter{
+		FromDate: req.FromDate,
+		ToDate:   req.ToDate,
+		Types:    req.MessageTypes,
+		Tags:     req.Tags,
+	}, nil
+}
+
+// getChatHistoryPage serves keyset pages. Only the latest page, older from
+// no cursor, can come from the cached end of the branch.
+func (s *chatService) getChatHistoryPage(ctx context.Context, req *GetChatHistoryRequest, session *models.Session, filter repository.MessageFilter, cacheable bool, recent *repository.RecentMessages) (*GetChatHistoryResponse, error) {
+	direction, err := pageDirection(req.Direction)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := sessionMessageCursor(req.Cursor, req.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := cacheable && cursor == nil && direction == models.PageDirectionOlder
+	fill := latest && recent == nil
+
+	var messages []*models.Message
+	var hasMore bool
+	var total int64 = -1
+
+	cached := false
+	if latest && recent != nil {
+		messages, cached = latestPage(recent, req.Limit)
+		if cached {
+			hasMore = recent.Total > int64(len(messages))
+			total = recent.Total
+		}
+	}
+	if latest {
+		recordCacheLookup("recent_messages", cached)
+	}
+
+	if !cached {
+		messages, hasMore, err = s.messageRepo.GetPageBySessionID(ctx, req.SessionID, filter, cursor, direction, req.Limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get chat history: %w", err)
+		}
+
+		if err := s.attachSiblings(ctx, req.SessionID, messages); err != nil {
+			return nil, err
+		}
+	}
+
+	response := &GetChatHistoryResponse{
+		Messages: messages,
+		HasMore:  hasMore,
+	}
+
+	if len(messages) > 0 {
+		response.OlderCursor = models.NewMessageCursor(messages[0]).Encode()
+		response.NewerCursor = models.NewMessageCursor(messages[len(messages)-1]).Encode()
+	}
+
+	if req.IncludeTotal || fill {
+		if total < 0 {
+			if total, err = s.messageRepo.CountBySessionID(ctx, req.SessionID, filter); err != nil {
+				return nil, fmt.Errorf("failed to count messages: %w", err)
+			}
+		}
+		if req.IncludeTotal {
+			response.TotalCount = total
+		}
+	}
+
+	if fill {
+		s.cacheRecentMessages(ctx, session, messages, total)
+	}
+
+	return response, nil
+}
+
+// cacheRecentMessages caches messages, which must be the last ones on the
+// session's active branch out of total.
+func (s *chatService) cacheRecentMessages(ctx context.Context, session *models.Session, messages []*models.Message, total int64) {
+	_ = s.cacheRepo.SetRecentMessages(ctx, session.ID, &repository.RecentMessages{
+		LeafID:   session.ActiveLeafID,
+		Total:    total,
+		Messages: messages,
+	}, s.config.CacheTTLMessages)
+}
+
+func (s *chatService) DeleteMessage(ctx context.Context, messageID string, userID string) error {
+	message, err := s.messageRepo.GetByID(ctx, messageID)
+	if err != nil {
+		return fmt.Errorf("failed to delete message: %w", err)
+	}
+
+	session, role, err := s.authorize(ctx, message.SessionID, userID, policy.ActionWrite)
+	if err != nil {
+		return fmt.Errorf("failed to delete message: %w", err)
+	}
+	if !policy.CanModifyMessage(role, userID, message) {
+		return fmt.Errorf("failed to delete message: access denied: %s cannot delete another member's message", role)
+	}
+
+	if err := s.messageRepo.Delete(ctx, messageID); err != nil {
+		return fmt.Errorf("failed to delete message: %w", err)
+	}
+
+	// Deleting the active leaf moves the active branch up to its parent.
+	if session.ActiveLeafID != nil && *session.ActiveLeafID == messageID {
+		s.setActiveLeaf(ctx, message.SessionID, message.ParentMessageID)
+	}
+
+	s.invalidateSummaries(ctx, message, []string{messageID}, message.ParentMessageID)
+
+	_ = s.cacheRepo.RemoveRecentMessage(ctx, message)
+
+	s.publishEvent(ctx, &models.SessionEvent{
+		SessionID: message.SessionID,
+		Type:      models.EventTypeMessageDeleted,
+		UserID:    userID,
+		MessageID: messageID,
+		CreatedAt: time.Now().UTC(),
+	})
+
+	s.log.Info("Message deleted successfully",
+		zap.String("message_id", messageID),
+		zap.String("user_id", userID))
+
+	return nil
+}
+
+func (s *chatService) EditMessage(ctx context.Context, req *EditMessageRequest) (*EditMessageResponse, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	if len(req.Content) > s.config.MaxMessageLength {
+		return nil, fmt.Errorf("message too long: max %d characters", s.config.MaxMessageLength)
+	}
+
+	message, role, err := s.sessionMessage(ctx, req.MessageID, req.UserID, policy.ActionWrite)
+	if err != nil {
+		return nil, err
+	}
+	if !policy.CanModifyMessage(role, req.UserID, message) {
+		return nil, fmt.Errorf("access denied: %s cannot edit another member's message", role)
+	}
+
+	if !message.IsComplete() {
+		return nil, fmt.Errorf("cannot edit a message that is still being generated")
+	}
+
+	if req.TruncateReplies && message.Type != models.MessageTypeUser {
+		return nil, fmt.Errorf("only user messages can truncate replies")
+	}
+
+	edited, revision, err := s.messageRepo.Edit(ctx, req.MessageID, req.Content, req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to edit message: %w", err)
+	}
+
+	response := &EditMessageResponse{
+		Message:  edited,
+		Revision: revision,
+	}
+
+	if req.TruncateReplies {
+		response.RemovedMessageIDs, err = s.messageRepo.DeleteDescendants(ctx, edited.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to truncate replies: %w", err)
+		}
+		s.setActiveLeaf(ctx, edited.SessionID, &edited.ID)
+	}
+
+	s.invalidateSummaries(ctx, edited, response.RemovedMessageIDs, &edited.ID)
+
+	if req.TruncateReplies {
+		_ = s.cacheRepo.DeleteRecentMessages(ctx, edited.SessionID)
+	} else {
+		_ = s.cacheRepo.UpdateRecentMessage(ctx, edited)
+	}
+
+	s.publishEvent(ctx, models.NewMessageEvent(models.EventTypeMessageEdited, edited))
+	for _, removedID := range response.RemovedMessageIDs {
+		s.publishEvent(ctx, &models.SessionEvent{
+			SessionID: edited.SessionID,
+			Type:      models.EventTypeMessageDeleted,
+			UserID:    req.UserID,
+			MessageID: removedID,
+			CreatedAt: time.Now().UTC(),
+		})
+	}
+
+	s.log.Info("Message edited successfully",
+		zap.String("message_id", edited.ID),
+		zap.Int("revision", revision.Revision),
+		zap.Int("removed_replies", len(response.RemovedMessageIDs)))
+
+	return response, nil
+}
+
+func (s *chatService) GetMessageRevisions(ctx context.Context, messageID string, userID string) ([]*models.MessageRevision, error) {
+	if _, _, err := s.sessionMessage(ctx, messageID, userID, policy.ActionRead); err != nil {
+		return nil, err
+	}
+
+	revisions, err := s.messageRepo.GetRevisions(ctx, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message revisions: %w", err)
+	}
+
+	return revisions, nil
+}
+
+func (s *chatService) GetMessageTree(ctx context.Context, sessionID string, userID string) (*MessageTreeResponse, error) {
+	session, err := s.GetSession(ctx, sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := s.messageRepo.GetAllBySessionID(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message tree: %w", err)
+	}
+
+	response := &MessageTreeResponse{}
+	if session.ActiveLeafID != nil {
+		response.ActiveLeafID = *session.ActiveLeafID
+	}
+	response.Roots = models.BuildMessageTree(messages, response.ActiveLeafID)
+
+	return response, nil
+}
+
+func (s *chatService) SwitchBranch(ctx context.Context, req *SwitchBranchRequest) (*models.Session, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	message, _, err := s.sessionMessage(ctx, req.MessageID, req.UserID, policy.ActionWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	if message.SessionID != req.SessionID {
+		return nil, fmt.Errorf("message not found in session")
+	}
+
+	leafID, err := s.messageRepo.GetLatestLeafID(ctx, message.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to switch branch: %w", err)
+	}
+
+	if err := s.sessionRepo.SetActiveLeaf(ctx, req.SessionID, &leafID); err != nil {
+		return nil, fmt.Errorf("failed to switch branch: %w", err)
+	}
+
+	_ = s.cacheRepo.InvalidateSessionCache(ctx, req.SessionID)
+
+	session, err := s.GetSession(ctx, req.SessionID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishEvent(ctx, models.NewSessionEvent(models.EventTypeActiveBranchChanged, session))
+
+	s.log.Info("Active branch switched",
+		zap.String("session_id", req.SessionID),
+		zap.String("message_id", req.MessageID),
+		zap.String("active_leaf_id", leafID))
+
+	return session, nil
+}
+
+// setActiveLeaf moves the end of the session's active branch. It is logged
+// rather than returned because the message it follows has already been
+// stored.
+func (s *chatService) setActiveLeaf(ctx context.Context, sessionID string, messageID *string) {
+	if err := s.sessionRepo.SetActiveLeaf(ctx, sessionID, messageID); err != nil {
+		s.log.Error("Failed to set active leaf",
+			zap.Error(err),
+			zap.String("session_id", sessionID))
+		_ = s.cacheRepo.InvalidateSessionCache(ctx, sessionID)
+		return
+	}
+
+	_ = s.cacheRepo.PatchSession(ctx, sessionID, func(session *models.Session) {
+		session.ActiveLeafID = messageID
+	})
+}
+
+// storedReply loads the user message a replayed request stored, after
+// checking the user can still write in the session, and the first
+// assistant reply to it, or nil if none was stored.
+func (s *chatService) storedReply(ctx context.Context, req *SendMessageRequest, userMessageID string) (*models.Message, *models.Message, error) {
+	if _, _, err := s.authorize(ctx, req.SessionID, req.UserID, policy.ActionWrite); err != nil {
+		return nil, nil, err
+	}
+
+	userMessage, err := s.messageRepo.GetByID(ctx, userMessageID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get message: %w", err)
+	}
+
+	children, err := s.messageRepo.GetChildIDs(ctx, userMessage.SessionID, []string{userMessage.ID})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get reply: %w", err)
+	}
+
+	for _, childID := range children[userMessage.ID] {
+		child, err := s.messageRepo.GetByID(ctx, childID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get reply: %w", err)
+		}
+		if child.Type == models.MessageTypeAssistant {
+			return userMessage, child, nil
+		}
+	}
+
+	return userMessage, nil, nil
+}
+
+// attachSiblings fills in SiblingIDs for messages that have alternatives, so
+// clients can show "2/3" and switch between them.
+func (s *chatService) attachSiblings(ctx context.Context, sessionID string, messages []*models.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	parentIDs := make([]string, len(messages))
+	for i, message := range messages {
+		if message.ParentMessageID != nil {
+			parentIDs[i] = *message.ParentMessageID
+		}
+	}
+
+	children, err := s.messageRepo.GetChildIDs(ctx, sessionID, parentIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get message siblings: %w", err)
+	}
+
+	for i, message := range messages {
+		if siblings := children[parentIDs[i]]; len(siblings) > 1 {
+			message.SiblingIDs = siblings
+		}
+	}
+
+	return nil
+}
+
+func (s *chatService) SearchMessages(ctx context.Context, req *SearchMessagesRequest) (*SearchMessagesResponse, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	_, err := s.GetSession(ctx, req.SessionID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+	if req.Limit > 100 {
+		req.Limit = 100
+	}
+
+	searchConfig := s.searchConfig(ctx, req.UserID)
+
+	if req.Cursor != "" || req.Direction != "" {
+		return s.searchMessagesPage(ctx, req, searchConfig)
+	}
+
+	results, total, err := s.messageRepo.SearchInSession(ctx, req.SessionID, req.Query, searchConfig, req.Limit, req.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search messages: %w", err)
+	}
+
+	return &SearchMessagesResponse{
+		Results:    results,
+		TotalCount: total,
+		HasMore:    int64(req.Offset+len(results)) < total,
+	}, nil
+}
+
+// defaultSearchContextSize is how many neighbouring messages a cross-session
+// search hit comes with when the caller does not ask for a number.
+const defaultSearchContextSize = 1
+
+func (s *chatService) SearchAllMessages(ctx context.Context, req *SearchAllMessagesRequest) (*SearchAllMessagesResponse, error) {
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+	if req.Limit > 100 {
+		req.Limit = 100
+	}
+	if req.ContextSize == 0 {
+		req.ContextSize = defaultSearchContextSize
+	}
+
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	if req.FromDate != nil && req.ToDate != nil && req.FromDate.After(*req.ToDate) {
+		return nil, fmt.Errorf("from_date must not be after to_date")
+	}
+	for _, status := range req.SessionStatuses {
+		if !status.IsValid() {
+			return nil, fmt.Errorf("invalid session status: %s", status)
+		}
+	}
+	for _, messageType := range req.MessageTypes {
+		if !messageType.IsValid() {
+			return nil, fmt.Errorf("invalid message type: %s", messageType)
+		}
+	}
+
+	filter := repository.UserSearchFilter{
+		MessageFilter: repository.MessageFilter{
+			FromDate: req.FromDate,
+			ToDate:   req.ToDate,
+			Types:    req.MessageTypes,
+		},
+		SessionStatuses: req.SessionStatuses,
+	}
+
+	results, total, err := s.messageRepo.SearchByUserID(ctx, req.UserID, req.Query, s.searchConfig(ctx, req.UserID), filter, req.Limit, req.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search messages: %w", err)
+	}
+
+	if err := s.attachSearchContext(ctx, results, req.ContextSize); err != nil {
+		return nil, err
+	}
+
+	return &SearchAllMessagesResponse{
+		Sessions:   models.GroupSearchResults(results),
+		TotalCount: total,
+		HasMore:    int64(req.Offset+len(results)) < total,
+	}, nil
+}
+
+// attachSearchContext loads up to size messages either side of each hit,
+// along the hit's branch, so the caller can show it in context without
+// opening the session.
+func (s *chatService) attachSearchContext(ctx context.Context, results []*models.SearchResult, size int) error {
+	ids := make([]string, len(results))
+	for i, result := range results {
+		ids[i] = result.Message.ID
+	}
+
+	contexts, err := s.messageRepo.GetContext(ctx, ids, size)
+	if err != nil {
+		return fmt.Errorf("failed to get search context: %w", err)
+	}
+
+	for _, result := range results {
+		if around, ok := contexts[result.Message.ID]; ok {
+			result.Before = around.Before
+			result.After = around.After
+		}
+	}
+
+	return nil
+}
+
+// searchConfig picks the text search configuration from the user's language
+// preference. Search still works if user-service is unavailable; it just
+// falls back to English stemming.
+func (s *chatService) searchConfig(ctx context.Context, userID string) string {
+	preferences, err := s.userClient.GetPreferences(ctx, userID)
+	if err != nil {
+		s.log.Warn("Falling back to default search language",
+			zap.Error(err),
+			zap.String("user_id", userID))
+		return models.DefaultTextSearchConfig
+	}
+
+	return models.TextSearchConfig(preferences.Language)
+}
+
+func (s *chatService) searchMessagesPage(ctx context.Context, req *SearchMessagesRequest, searchConfig string) (*SearchMessagesResponse, error) {
+	direction, err := pageDirection(req.Direction)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := sessionMessageCursor(req.Cursor, req.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	results, hasMore, err := s.messageRepo.SearchPageInSession(ctx, req.SessionID, req.Query, searchConfig, cursor, direction, req.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search messages: %w", err)
+	}
+
+	response := &SearchMessagesResponse{
+		Results: results,
+		HasMore: hasMore,
+	}
+
+	// Search results are listed best match first, so "newer" walks back up
+	// the ranking from the first row and "older" continues past the last.
+	if len(results) > 0 {
+		response.NewerCursor = models.NewSearchCursor(results[0]).Encode()
+		response.OlderCursor = models.NewSearchCursor(results[len(results)-1]).Encode()
+	}
+
+	if req.IncludeTotal {
+		if response.TotalCount, err = s.messageRepo.CountSearchResults(ctx, req.SessionID, req.Query, searchConfig); err != nil {
+			return nil, fmt.Errorf("failed to count search results: %w", err)
+		}
+	}
+
+	return response, nil
+}
+
+func (s *chatService) UpdateTypingStatus(ctx context.Context, req *UpdateTypingStatusRequest) error {
+	if err := s.validator.Struct(req); err != nil {
+		return fmt.Errorf("validation error: %w", err)
+	}
+
+	_, _, err := s.authorize(ctx, req.SessionID, req.UserID, policy.ActionWrite)
+	if err != nil {
+		return err
+	}
+
+	err = s.cacheRepo.SetTypingStatus(ctx, req.SessionID, req.UserID, req.IsTyping, s.config.CacheTTLTyping)
+	if err != nil {
+		return fmt.Errorf("failed to update typing status: %w", err)
+	}
+
+	eventType := models.EventTypeTypingStopped
+	if req.IsTyping {
+		eventType = models.EventTypeTypingStarted
+	}
+	s.publishEvent(ctx, &models.SessionEvent{
+		SessionID: req.SessionID,
+		Type:      eventType,
+		UserID:    req.UserID,
+		CreatedAt: time.Now().UTC(),
+	})
+
+	return nil
+}
+
+func (s *chatService) GetTypingUsers(ctx context.Context, sessionID string, userID string) ([]string, error) {
+	if _, err := s.GetSession(ctx, sessionID, userID); err != nil {
+		return nil, err
+	}
+
+	users, err := s.cacheRepo.GetTypingUsers(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get typing users: %w", err)
+	}
+
+	return users, nil
+}
+
+// WatchSession streams events for a session until ctx is done. A non-empty
+// cursor replays retained events published after it before going live.
+func (s *chatService) WatchSession(ctx context.Context, sessionID string, userID string, cursor string) (<-chan *models.SessionEvent, error) {
+	if _, err := s.GetSession(ctx, sessionID, userID); err != nil {
+		return nil, err
+	}
+
+	events, err := s.eventRepo.Subscribe(ctx, sessionID, cursor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch session: %w", err)
+	}
+
+	return events, nil
+}
+
+func (s *chatService) publishEvent(ctx context.Context, event *models.SessionEvent) {
+	if err := s.eventRepo.Publish(ctx, event); err != nil {
+		s.log.Warn("Failed to publish session event",
+			zap.Error(err),
+			zap.String("session_id", event.SessionID),
+			zap.String("type", event.Type.String()))
+	}
+}
+
+func pageDirection(direction models.PageDirection) (models.PageDirection, error) {
+	if direction == "" {
+		return models.PageDirectionOlder, nil
+	}
+	if !direction.IsValid() {
+		return "", fmt.Errorf("invalid page direction: %s", direction)
+	}
+	return direction, nil
+}
+
+// sessionMessageCursor decodes token and rejects cursors issued for another
+// session.
+func sessionMessageCursor(token string, sessionID string) (*models.MessageCursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	cursor, err := models.DecodeMessageCursor(token)
+	if err != nil {
+		return nil, err
+	}
+	if cursor.SessionID != sessionID {
+		return nil, fmt.Errorf("cursor does not belong to this session")
+	}
+
+	return cursor, nil
 }
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"go.uber.org/zap"
+
+	"github.com/Sourav01112/chat-service/internal/export"
+	"github.com/Sourav01112/chat-service/internal/models"
+	"github.com/Sourav01112/chat-service/internal/repository"
+)
+
+// exportPageSize is how many messages an export reads from the database at
+// a time.
+const exportPageSize = 200
+
+// ExportSession renders the session to w page by page. Nothing is written
+// before the session has been found and the request checked, so callers can
+// still report those errors in place of the download.
+func (s *chatService) ExportSession(ctx context.Context, req *ExportSessionRequest, w io.Writer) error {
+	if req.Scope == "" {
+		req.Scope = export.ScopeActiveBranch
+	}
+
+	if err := s.validator.Struct(req); err != nil {
+		return fmt.Errorf("validation error: %w", err)
+	}
+	if !req.Format.IsValid() {
+		return fmt.Errorf("invalid export format: %s", req.Format)
+	}
+	if !req.Scope.IsValid() {
+		return fmt.Errorf("invalid export scope: %s", req.Scope)
+	}
+
+	session, err := s.GetSession(ctx, req.SessionID, req.UserID)
+	if err != nil {
+		return err
+	}
+
+	renderer, err := export.NewRenderer(req.Format, req.Scope)
+	if err != nil {
+		return err
+	}
+
+	if err := renderer.Begin(w, session); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+
+	// A session without an active leaf has no messages on its active branch.
+	exported := 0
+	if req.Scope == export.ScopeFullTree || session.ActiveLeafID != nil {
+		var filter repository.MessageFilter
+		if req.Scope == export.ScopeActiveBranch {
+			filter.LeafID = *session.ActiveLeafID
+		}
+
+		var cursor *models.MessageCursor
+		for {
+			messages, hasMore, err := s.messageRepo.GetPageBySessionID(ctx, session.ID, filter, cursor, models.PageDirectionNewer, exportPageSize)
+			if err != nil {
+				return fmt.Errorf("failed to export session: %w", err)
+			}
+
+			for _, message := range messages {
+				if err := renderer.Message(w, message); err != nil {
+					return fmt.Errorf("failed to write export: %w", err)
+				}
+			}
+			exported += len(messages)
+
+			if !hasMore || len(messages) == 0 {
+				break
+			}
+			cursor = models.NewMessageCursor(messages[len(messages)-1])
+		}
+	}
+
+	if err := renderer.End(w); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+
+	s.log.Info("Session exported",
+		zap.String("session_id", session.ID),
+		zap.String("format", req.Format.String()),
+		zap.String("scope", req.Scope.String()),
+		zap.Int("messages", exported))
+
+	return nil
+}
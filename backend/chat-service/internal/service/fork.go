@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/Sourav01112/chat-service/internal/models"
+	"github.com/Sourav01112/chat-service/internal/policy"
+)
+
+// ForkSession starts a new session from any message the user can read. The
+// fork gets the source session's settings and a copy of every message from
+// the root down to that message; the source session's metadata records the
+// fork.
+func (s *chatService) ForkSession(ctx context.Context, req *ForkSessionRequest) (*models.Session, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	message, err := s.messageRepo.GetByID(ctx, req.MessageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+
+	source, _, err := s.authorize(ctx, message.SessionID, req.UserID, policy.ActionRead)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := s.messageRepo.GetPath(ctx, message.ID, s.config.MaxMessagesPerSession)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load conversation: %w", err)
+	}
+	if len(path) > 0 && path[0].ParentMessageID != nil {
+		return nil, fmt.Errorf("conversation is too long to fork")
+	}
+
+	fork, messages := forkedSession(source, path, req.UserID)
+	if title := strings.TrimSpace(req.Title); title != "" {
+		fork.Title = title
+		fork.TitleSource = models.TitleSourceUser
+	}
+
+	record := models.SessionFork{
+		SessionID: fork.ID,
+		MessageID: message.ID,
+		UserID:    req.UserID,
+		CreatedAt: fork.CreatedAt,
+	}
+	if err := s.sessionRepo.Fork(ctx, fork, messages, record); err != nil {
+		return nil, err
+	}
+
+	// The cached source session no longer has the right metadata.
+	_ = s.cacheRepo.DeleteSession(ctx, source.ID)
+	_ = s.cacheRepo.SetSession(ctx, fork, s.config.CacheTTLSessions)
+
+	s.log.Info("Session forked",
+		zap.String("session_id", fork.ID),
+		zap.String("source_session_id", source.ID),
+		zap.String("message_id", message.ID),
+		zap.String("user_id", req.UserID))
+
+	return fork, nil
+}
+
+// forkedSession builds the rows for a fork of source ending at the last
+// message of path. Messages get new IDs, order indexes following the path
+// and parent links to each other; their content, authors and timestamps are
+// kept.
+func forkedSession(source *models.Session, path []*models.Message, userID string) (*models.Session, []*models.Message) {
+	now := time.Now()
+	sourceID := source.ID
+
+	fork := &models.Session{
+		ID:                  uuid.New().String(),
+		UserID:              userID,
+		Title:               source.Title,
+		TitleSource:         source.TitleSource,
+		Status:              models.SessionStatusActive,
+		Settings:            source.Settings,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+		LastActivity:        now,
+		ForkedFromSessionID: &sourceID,
+	}
+
+	messages := make([]*models.Message, len(path))
+	var parentID *string
+	for i, original := range path {
+		message := &models.Message{
+			ID:              uuid.New().String(),
+			SessionID:       fork.ID,
+			UserID:          original.UserID,
+			Content:         original.Content,
+			Type:            original.Type,
+			Metadata:        original.Metadata,
+			CreatedAt:       original.CreatedAt,
+			ParentMessageID: parentID,
+			OrderIndex:      i + 1,
+			Status:          original.Status,
+			EditedAt:        original.EditedAt,
+		}
+		// A reply still being written in the source stops growing here.
+		if message.Status == models.MessageStatusStreaming {
+			message.Status = models.MessageStatusIncomplete
+		}
+
+		messages[i] = message
+		parentID = &message.ID
+	}
+
+	if len(path) > 0 {
+		forkedFrom := path[len(path)-1].ID
+		fork.ForkedFromMessageID = &forkedFrom
+		fork.ActiveLeafID = &messages[len(messages)-1].ID
+	}
+
+	return fork, messages
+}
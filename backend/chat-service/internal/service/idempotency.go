@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Sourav01112/chat-service/internal/models"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// once runs create at most once per idempotency key and returns the ID of
+// the resource it made. When the key was already used for the same request
+// within the idempotency window, create is skipped and the first result's
+// ID is returned instead, and the caller must check that the user may still
+// see that resource before handing it back. Without a key create simply
+// runs.
+//
+// Postgres decides who gets a key; Redis keeps completed keys so that
+// retries are usually answered without touching it.
+func (s *chatService) once(ctx context.Context, scope string, userID string, key string, req interface{}, create func() (string, error)) (string, error) {
+	if key == "" {
+		return create()
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		return "", fmt.Errorf("validation error: idempotency key longer than %d characters", maxIdempotencyKeyLength)
+	}
+
+	hash, err := requestHash(req)
+	if err != nil {
+		return "", err
+	}
+
+	if cached, err := s.cacheRepo.GetIdempotencyKey(ctx, userID, scope, key); err == nil && cached.IsComplete() {
+		if cached.RequestHash != hash {
+			return "", errIdempotencyKeyReused(key)
+		}
+		return *cached.ResourceID, nil
+	}
+
+	record := &models.IdempotencyKey{
+		UserID:      userID,
+		Scope:       scope,
+		Key:         key,
+		RequestHash: hash,
+		ExpiresAt:   time.Now().Add(s.config.IdempotencyWindow),
+	}
+
+	holder, err := s.idempotencyRepo.Reserve(ctx, record)
+	if err != nil {
+		return "", err
+	}
+	if holder != nil {
+		if holder.RequestHash != hash {
+			return "", errIdempotencyKeyReused(key)
+		}
+		if !holder.IsComplete() {
+			return "", fmt.Errorf("a request with idempotency key %q is still in progress", key)
+		}
+		_ = s.cacheRepo.SetIdempotencyKey(ctx, holder)
+		return *holder.ResourceID, nil
+	}
+
+	resourceID, err := create()
+	if err != nil {
+		if releaseErr := s.idempotencyRepo.Release(context.WithoutCancel(ctx), record); releaseErr != nil {
+			s.log.Warn("Failed to release idempotency key",
+				zap.Error(releaseErr),
+				zap.String("scope", scope),
+				zap.String("user_id", userID))
+		}
+		return "", err
+	}
+
+	// The resource exists now whatever happens to the key; if it cannot be
+	// completed, a retry is told the request is still in progress until the
+	// key expires, which is better than creating a second one.
+	record.ResourceID = &resourceID
+	if err := s.idempotencyRepo.Complete(context.WithoutCancel(ctx), record); err != nil {
+		s.log.Warn("Failed to complete idempotency key",
+			zap.Error(err),
+			zap.String("scope", scope),
+			zap.String("user_id", userID))
+		return resourceID, nil
+	}
+	_ = s.cacheRepo.SetIdempotencyKey(ctx, record)
+
+	return resourceID, nil
+}
+
+// requestHash fingerprints a request so that reusing its idempotency key for
+// something else can be told apart from a retry.
+func requestHash(req interface{}) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash request: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func errIdempotencyKeyReused(key string) error {
+	return fmt.Errorf("idempotency key %q was already used for a different request", key)
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/Sourav01112/chat-service/internal/importer"
+	"github.com/Sourav01112/chat-service/internal/models"
+)
+
+const maxSessionTitleRunes = 200
+
+// ImportSessions stores every conversation in an export file as a session of
+// the user. Each conversation is written in its own transaction, so one that
+// fails does not undo the others; conversations the user has imported
+// before are skipped.
+func (s *chatService) ImportSessions(ctx context.Context, req *ImportSessionsRequest) (*ImportSessionsResponse, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	conversations, err := importer.Parse(req.Data, req.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &ImportSessionsResponse{Reports: make([]*ImportReport, 0, len(conversations))}
+	for _, conversation := range conversations {
+		report := s.importConversation(ctx, req.UserID, conversation)
+		response.Reports = append(response.Reports, report)
+
+		switch report.Status {
+		case ImportStatusImported:
+			response.Imported++
+		case ImportStatusSkipped:
+			response.Skipped++
+		case ImportStatusFailed:
+			response.Failed++
+		}
+	}
+
+	s.log.Info("Sessions imported",
+		zap.String("user_id", req.UserID),
+		zap.Int("conversations", len(conversations)),
+		zap.Int("imported", response.Imported),
+		zap.Int("skipped", response.Skipped),
+		zap.Int("failed", response.Failed))
+
+	return response, nil
+}
+
+func (s *chatService) importConversation(ctx context.Context, userID string, conversation *importer.Conversation) *ImportReport {
+	report := &ImportReport{
+		ExternalID:   conversation.ExternalID,
+		Title:        conversation.Title,
+		MessageCount: len(conversation.Messages),
+	}
+
+	session, messages := importedSession(userID, conversation)
+
+	imported, err := s.sessionRepo.Import(ctx, session, messages)
+	switch {
+	case err != nil:
+		s.log.Warn("Failed to import conversation",
+			zap.Error(err),
+			zap.String("user_id", userID),
+			zap.String("external_id", conversation.ExternalID))
+		report.Status = ImportStatusFailed
+		report.Error = err.Error()
+	case !imported:
+		report.Status = ImportStatusSkipped
+	default:
+		report.Status = ImportStatusImported
+		report.SessionID = session.ID
+		report.Title = session.Title
+	}
+
+	return report
+}
+
+// importedSession builds the rows for a conversation. Messages get new IDs
+// and order indexes in the order the importer returned them, which puts
+// parents first; their timestamps are kept.
+func importedSession(userID string, conversation *importer.Conversation) (*models.Session, []*models.Message) {
+	source := conversation.Source.String()
+	externalID := conversation.ExternalID
+
+	session := &models.Session{
+		ID:               uuid.New().String(),
+		UserID:           userID,
+		Title:            conversation.Title,
+		TitleSource:      models.TitleSourceUser,
+		Status:           conversation.Status,
+		Settings:         models.GetDefaultSettings(),
+		CreatedAt:        conversation.CreatedAt,
+		UpdatedAt:        conversation.UpdatedAt,
+		ImportSource:     &source,
+		ImportExternalID: &externalID,
+	}
+	if conversation.Settings != nil {
+		session.Settings = *conversation.Settings
+	}
+	if session.Title == "" {
+		session.Title = models.DefaultSessionTitle
+		session.TitleSource = models.TitleSourceDefault
+	} else if runes := []rune(session.Title); len(runes) > maxSessionTitleRunes {
+		session.Title = string(runes[:maxSessionTitleRunes])
+	}
+	if !session.Status.IsValid() || session.Status == models.SessionStatusArchived {
+		session.Status = models.SessionStatusActive
+	}
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = time.Now()
+	}
+	if session.UpdatedAt.IsZero() {
+		session.UpdatedAt = session.CreatedAt
+	}
+	session.LastActivity = session.UpdatedAt
+
+	ids := make(map[string]string, len(conversation.Messages))
+	messages := make([]*models.Message, 0, len(conversation.Messages))
+	for i, imported := range conversation.Messages {
+		message := &models.Message{
+			ID:         uuid.New().String(),
+			SessionID:  session.ID,
+			UserID:     userID,
+			Content:    imported.Content,
+			Type:       imported.Type,
+			Metadata:   imported.Metadata,
+			CreatedAt:  imported.CreatedAt,
+			OrderIndex: i + 1,
+			Status:     models.MessageStatusComplete,
+		}
+		if !message.Type.IsValid() {
+			message.Type = models.MessageTypeSystem
+		}
+		if message.CreatedAt.IsZero() {
+			message.CreatedAt = session.CreatedAt
+		}
+		if parentID, ok := ids[imported.ParentExternalID]; ok {
+			message.ParentMessageID = &parentID
+		}
+		if message.CreatedAt.After(session.LastActivity) {
+			session.LastActivity = message.CreatedAt
+		}
+
+		ids[imported.ExternalID] = message.ID
+		messages = append(messages, message)
+	}
+
+	if leafID, ok := ids[conversation.ActiveLeafID]; ok {
+		session.ActiveLeafID = &leafID
+	} else if len(messages) > 0 {
+		session.ActiveLeafID = &messages[len(messages)-1].ID
+	}
+
+	return session, messages
+}
@@ -2,37 +2,107 @@ package service
 
 import (
 	"context"
+	"io"
 	"time"
 
+	"github.com/Sourav01112/chat-service/internal/export"
+	"github.com/Sourav01112/chat-service/internal/importer"
 	"github.com/Sourav01112/chat-service/internal/models"
 )
 
 type ChatService interface {
 	CreateSession(ctx context.Context, req *CreateSessionRequest) (*models.Session, error)
 	GetSession(ctx context.Context, sessionID string, userID string) (*models.Session, error)
-	GetUserSessions(ctx context.Context, userID string, limit, offset int) (*GetUserSessionsResponse, error)
+	GetUserSessions(ctx context.Context, req *GetUserSessionsRequest) (*GetUserSessionsResponse, error)
 	UpdateSession(ctx context.Context, req *UpdateSessionRequest) (*models.Session, error)
 	DeleteSession(ctx context.Context, sessionID string, userID string) error
 
 	SendMessage(ctx context.Context, req *SendMessageRequest) (*models.Message, error)
+	SendMessageAndReply(ctx context.Context, req *SendMessageRequest) (*SendMessageAndReplyResponse, error)
+	StreamReply(ctx context.Context, req *SendMessageRequest, send func(*StreamReplyEvent) error) error
+	RegenerateMessage(ctx context.Context, req *RegenerateMessageRequest) (*models.Message, error)
 	GetChatHistory(ctx context.Context, req *GetChatHistoryRequest) (*GetChatHistoryResponse, error)
 	DeleteMessage(ctx context.Context, messageID string, userID string) error
+	EditMessage(ctx context.Context, req *EditMessageRequest) (*EditMessageResponse, error)
+	GetMessageRevisions(ctx context.Context, messageID string, userID string) ([]*models.MessageRevision, error)
+	GetMessageTree(ctx context.Context, sessionID string, userID string) (*MessageTreeResponse, error)
+	SwitchBranch(ctx context.Context, req *SwitchBranchRequest) (*models.Session, error)
 	SearchMessages(ctx context.Context, req *SearchMessagesRequest) (*SearchMessagesResponse, error)
+	SearchAllMessages(ctx context.Context, req *SearchAllMessagesRequest) (*SearchAllMessagesResponse, error)
 
 	UpdateTypingStatus(ctx context.Context, req *UpdateTypingStatusRequest) error
-	GetTypingUsers(ctx context.Context, sessionID string) ([]string, error)
+	GetTypingUsers(ctx context.Context, sessionID string, userID string) ([]string, error)
+
+	WatchSession(ctx context.Context, sessionID string, userID string, cursor string) (<-chan *models.SessionEvent, error)
+
+	ExportSession(ctx context.Context, req *ExportSessionRequest, w io.Writer) error
+	ImportSessions(ctx context.Context, req *ImportSessionsRequest) (*ImportSessionsResponse, error)
+
+	CreateShareLink(ctx context.Context, req *CreateShareLinkRequest) (*models.ShareLink, error)
+	GetSharedSession(ctx context.Context, token string) (*models.SharedSession, error)
+	RevokeShareLink(ctx context.Context, token string, userID string) error
+
+	InviteMember(ctx context.Context, req *InviteMemberRequest) (*models.SessionMember, error)
+	RemoveMember(ctx context.Context, req *RemoveMemberRequest) error
+	GetSessionMembers(ctx context.Context, sessionID string, userID string) ([]*models.SessionMember, error)
+
+	CreateFolder(ctx context.Context, req *FolderRequest) (*models.Folder, error)
+	UpdateFolder(ctx context.Context, req *FolderRequest) (*models.Folder, error)
+	DeleteFolder(ctx context.Context, folderID string, userID string) error
+	ListFolders(ctx context.Context, userID string) ([]*models.Folder, error)
+	CreateTag(ctx context.Context, req *TagRequest) (*models.Tag, error)
+	UpdateTag(ctx context.Context, req *TagRequest) (*models.Tag, error)
+	DeleteTag(ctx context.Context, tagID string, userID string) error
+	ListTags(ctx context.Context, userID string) ([]*models.Tag, error)
+	OrganizeSession(ctx context.Context, req *OrganizeSessionRequest) (*models.Session, error)
+
+	ForkSession(ctx context.Context, req *ForkSessionRequest) (*models.Session, error)
+
+	ListTrash(ctx context.Context, req *ListTrashRequest) (*ListTrashResponse, error)
+	RestoreSession(ctx context.Context, sessionID string, userID string) (*models.Session, error)
+	RestoreMessage(ctx context.Context, messageID string, userID string) (*models.Message, error)
 }
 
+// CreateSessionRequest may leave Title empty, in which case the session is
+// titled from its first exchange.
 type CreateSessionRequest struct {
 	UserID   string                 `json:"user_id" validate:"required"`
-	Title    string                 `json:"title" validate:"required,max=200"`
+	Title    string                 `json:"title" validate:"max=200"`
 	Settings models.SessionSettings `json:"settings"`
+
+	// IdempotencyKey, if set, makes a retry return the session the first
+	// request created. It is not part of the request it identifies.
+	IdempotencyKey string `json:"-"`
+}
+
+// Paging fields shared by the list RPCs. Setting Cursor or Direction switches
+// from LIMIT/OFFSET to keyset paging; TotalCount is then only computed when
+// IncludeTotal is set.
+//
+// The remaining fields filter and order the list as described on
+// repository.SessionFilter; pinned sessions always come first.
+type GetUserSessionsRequest struct {
+	UserID       string               `json:"user_id" validate:"required"`
+	Limit        int                  `json:"limit"`
+	Offset       int                  `json:"offset" validate:"min=0"`
+	Cursor       string               `json:"cursor,omitempty"`
+	Direction    models.PageDirection `json:"direction,omitempty"`
+	IncludeTotal bool                 `json:"include_total"`
+
+	Statuses      []models.SessionStatus `json:"statuses,omitempty"`
+	FolderID      string                 `json:"folder_id,omitempty"`
+	TagID         string                 `json:"tag_id,omitempty"`
+	FavoritesOnly bool                   `json:"favorites_only,omitempty"`
+	TitlePrefix   string                 `json:"title_prefix,omitempty" validate:"max=200"`
+	SortBy        models.SessionSort     `json:"sort_by,omitempty"`
 }
 
 type GetUserSessionsResponse struct {
-	Sessions   []*models.Session `json:"sessions"`
-	TotalCount int64             `json:"total_count"`
-	HasMore    bool              `json:"has_more"`
+	Sessions    []*models.Session `json:"sessions"`
+	TotalCount  int64             `json:"total_count"`
+	HasMore     bool              `json:"has_more"`
+	OlderCursor string            `json:"older_cursor,omitempty"`
+	NewerCursor string            `json:"newer_cursor,omitempty"`
 }
 
 type UpdateSessionRequest struct {
@@ -50,6 +120,27 @@ type SendMessageRequest struct {
 	Type            models.MessageType     `json:"type" validate:"required"`
 	Metadata        models.MessageMetadata `json:"metadata"`
 	ParentMessageID *string                `json:"parent_message_id,omitempty"`
+
+	// IdempotencyKey, if set, makes a retry return the message the first
+	// request stored. It is not part of the request it identifies.
+	IdempotencyKey string `json:"-"`
+}
+
+type SendMessageAndReplyResponse struct {
+	UserMessage      *models.Message `json:"user_message"`
+	AssistantMessage *models.Message `json:"assistant_message"`
+}
+
+// StreamReplyEvent is one step of a streamed reply. The first event carries
+// the stored user message and the ID of the assistant message being written,
+// later events carry content deltas, and the final one the last delta, if
+// any, along with the finished message.
+type StreamReplyEvent struct {
+	UserMessage        *models.Message `json:"user_message,omitempty"`
+	AssistantMessageID string          `json:"assistant_message_id"`
+	Delta              string          `json:"delta,omitempty"`
+	IsFinal            bool            `json:"is_final"`
+	AssistantMessage   *models.Message `json:"assistant_message,omitempty"`
 }
 
 type GetChatHistoryRequest struct {
@@ -59,12 +150,60 @@ type GetChatHistoryRequest struct {
 	Offset    int        `json:"offset" validate:"min=0"`
 	FromDate  *time.Time `json:"from_date,omitempty"`
 	ToDate    *time.Time `json:"to_date,omitempty"`
+
+	MessageTypes []models.MessageType `json:"message_types,omitempty"`
+	Tags         []string             `json:"tags,omitempty"`
+
+	Cursor       string               `json:"cursor,omitempty"`
+	Direction    models.PageDirection `json:"direction,omitempty"`
+	IncludeTotal bool                 `json:"include_total"`
 }
 
 type GetChatHistoryResponse struct {
-	Messages   []*models.Message `json:"messages"`
-	TotalCount int64             `json:"total_count"`
-	HasMore    bool              `json:"has_more"`
+	Messages    []*models.Message `json:"messages"`
+	TotalCount  int64             `json:"total_count"`
+	HasMore     bool              `json:"has_more"`
+	OlderCursor string            `json:"older_cursor,omitempty"`
+	NewerCursor string            `json:"newer_cursor,omitempty"`
+}
+
+// RegenerateMessageRequest asks for a new answer to the prompt behind an
+// assistant message. Temperature and Model override the session settings for
+// this answer only.
+type RegenerateMessageRequest struct {
+	MessageID   string   `json:"message_id" validate:"required"`
+	UserID      string   `json:"user_id" validate:"required"`
+	Temperature *float64 `json:"temperature,omitempty" validate:"omitempty,min=0,max=2"`
+	Model       string   `json:"model,omitempty" validate:"max=100"`
+}
+
+// EditMessageRequest replaces a message's content. TruncateReplies, which is
+// only allowed on user messages, also deletes every reply beneath the edited
+// message so the conversation can be regenerated from it.
+type EditMessageRequest struct {
+	MessageID       string `json:"message_id" validate:"required"`
+	UserID          string `json:"user_id" validate:"required"`
+	Content         string `json:"content" validate:"required"`
+	TruncateReplies bool   `json:"truncate_replies"`
+}
+
+type EditMessageResponse struct {
+	Message           *models.Message         `json:"message"`
+	Revision          *models.MessageRevision `json:"revision"`
+	RemovedMessageIDs []string                `json:"removed_message_ids,omitempty"`
+}
+
+type MessageTreeResponse struct {
+	Roots        []*models.MessageNode `json:"roots"`
+	ActiveLeafID string                `json:"active_leaf_id,omitempty"`
+}
+
+// SwitchBranchRequest makes the branch through MessageID active. When the
+// message has replies the newest leaf beneath it becomes the active leaf.
+type SwitchBranchRequest struct {
+	SessionID string `json:"session_id" validate:"required"`
+	UserID    string `json:"user_id" validate:"required"`
+	MessageID string `json:"message_id" validate:"required"`
 }
 
 type SearchMessagesRequest struct {
@@ -73,12 +212,40 @@ type SearchMessagesRequest struct {
 	Query     string `json:"query" validate:"required,min=1"`
 	Limit     int    `json:"limit" validate:"min=1,max=100"`
 	Offset    int    `json:"offset" validate:"min=0"`
+
+	Cursor       string               `json:"cursor,omitempty"`
+	Direction    models.PageDirection `json:"direction,omitempty"`
+	IncludeTotal bool                 `json:"include_total"`
 }
 
 type SearchMessagesResponse struct {
-	Messages   []*models.Message `json:"messages"`
-	TotalCount int64             `json:"total_count"`
-	HasMore    bool              `json:"has_more"`
+	Results     []*models.SearchResult `json:"results"`
+	TotalCount  int64                  `json:"total_count"`
+	HasMore     bool                   `json:"has_more"`
+	OlderCursor string                 `json:"older_cursor,omitempty"`
+	NewerCursor string                 `json:"newer_cursor,omitempty"`
+}
+
+// SearchAllMessagesRequest searches every session the user owns. With no
+// SessionStatuses archived sessions are skipped. ContextSize is how many
+// messages either side of each hit are returned with it.
+type SearchAllMessagesRequest struct {
+	UserID string `json:"user_id" validate:"required"`
+	Query  string `json:"query" validate:"required,min=1"`
+	Limit  int    `json:"limit" validate:"min=1,max=100"`
+	Offset int    `json:"offset" validate:"min=0"`
+
+	SessionStatuses []models.SessionStatus `json:"session_statuses,omitempty"`
+	FromDate        *time.Time             `json:"from_date,omitempty"`
+	ToDate          *time.Time             `json:"to_date,omitempty"`
+	MessageTypes    []models.MessageType   `json:"message_types,omitempty"`
+	ContextSize     int                    `json:"context_size" validate:"min=0,max=5"`
+}
+
+type SearchAllMessagesResponse struct {
+	Sessions   []*models.SessionSearchResults `json:"sessions"`
+	TotalCount int64                          `json:"total_count"`
+	HasMore    bool                           `json:"has_more"`
 }
 
 type UpdateTypingStatusRequest struct {
@@ -86,3 +253,126 @@ type UpdateTypingStatusRequest struct {
 	UserID    string `json:"user_id" validate:"required"`
 	IsTyping  bool   `json:"is_typing"`
 }
+
+// ExportSessionRequest picks the format of an export and whether it covers
+// only the active branch, the default, or every branch.
+type ExportSessionRequest struct {
+	SessionID string        `json:"session_id" validate:"required"`
+	UserID    string        `json:"user_id" validate:"required"`
+	Format    export.Format `json:"format" validate:"required"`
+	Scope     export.Scope  `json:"scope,omitempty"`
+}
+
+// ImportSessionsRequest carries a whole export file. An empty Source detects
+// the format from the content.
+type ImportSessionsRequest struct {
+	UserID string          `json:"user_id" validate:"required"`
+	Source importer.Source `json:"source,omitempty"`
+	Data   []byte          `json:"-" validate:"required"`
+}
+
+type ImportStatus string
+
+const (
+	ImportStatusImported ImportStatus = "imported"
+	ImportStatusSkipped  ImportStatus = "skipped"
+	ImportStatusFailed   ImportStatus = "failed"
+)
+
+// ImportReport describes what happened to one conversation of an import.
+// SessionID is only set when it was imported.
+type ImportReport struct {
+	ExternalID   string       `json:"external_id"`
+	Title        string       `json:"title"`
+	SessionID    string       `json:"session_id,omitempty"`
+	Status       ImportStatus `json:"status"`
+	MessageCount int          `json:"message_count"`
+	Error        string       `json:"error,omitempty"`
+}
+
+type ImportSessionsResponse struct {
+	Reports  []*ImportReport `json:"reports"`
+	Imported int             `json:"imported"`
+	Skipped  int             `json:"skipped"`
+	Failed   int             `json:"failed"`
+}
+
+// CreateShareLinkRequest defaults to a snapshot link that hides citations.
+// ExpiresIn of zero uses the configured default.
+type CreateShareLinkRequest struct {
+	SessionID        string           `json:"session_id" validate:"required"`
+	UserID           string           `json:"user_id" validate:"required"`
+	Mode             models.ShareMode `json:"mode,omitempty"`
+	IncludeCitations bool             `json:"include_citations"`
+	ExpiresIn        time.Duration    `json:"expires_in,omitempty" validate:"min=0"`
+}
+
+// InviteMemberRequest is made by the owner, UserID, for MemberUserID.
+type InviteMemberRequest struct {
+	SessionID    string            `json:"session_id" validate:"required"`
+	UserID       string            `json:"user_id" validate:"required"`
+	MemberUserID string            `json:"member_user_id" validate:"required,uuid"`
+	Role         models.MemberRole `json:"role" validate:"required"`
+}
+
+type RemoveMemberRequest struct {
+	SessionID    string `json:"session_id" validate:"required"`
+	UserID       string `json:"user_id" validate:"required"`
+	MemberUserID string `json:"member_user_id" validate:"required"`
+}
+
+// FolderRequest creates a folder, or renames the one named by FolderID.
+type FolderRequest struct {
+	FolderID string `json:"folder_id,omitempty"`
+	UserID   string `json:"user_id" validate:"required"`
+	Name     string `json:"name" validate:"required,max=100"`
+}
+
+// TagRequest creates a tag, or updates the one named by TagID.
+type TagRequest struct {
+	TagID  string `json:"tag_id,omitempty"`
+	UserID string `json:"user_id" validate:"required"`
+	Name   string `json:"name" validate:"required,max=50"`
+	Color  string `json:"color,omitempty" validate:"max=20"`
+}
+
+// OrganizeSessionRequest changes how UserID has organized a session. Nil
+// fields are left alone. An empty FolderID takes the session out of its
+// folder, and TagIDs replaces the session's tags when SetTags is true.
+type OrganizeSessionRequest struct {
+	SessionID string   `json:"session_id" validate:"required"`
+	UserID    string   `json:"user_id" validate:"required"`
+	FolderID  *string  `json:"folder_id,omitempty"`
+	Pinned    *bool    `json:"pinned,omitempty"`
+	Favorite  *bool    `json:"favorite,omitempty"`
+	SetTags   bool     `json:"set_tags,omitempty"`
+	TagIDs    []string `json:"tag_ids,omitempty"`
+}
+
+// ForkSessionRequest copies the conversation leading to MessageID into a new
+// session owned by UserID. An empty Title reuses the source session's.
+type ForkSessionRequest struct {
+	MessageID string `json:"message_id" validate:"required"`
+	UserID    string `json:"user_id" validate:"required"`
+	Title     string `json:"title,omitempty" validate:"max=200"`
+}
+
+// ListTrashRequest lists the sessions UserID owns that are in the trash, or
+// with SessionID set, the deleted messages of that session.
+type ListTrashRequest struct {
+	UserID    string `json:"user_id" validate:"required"`
+	SessionID string `json:"session_id,omitempty"`
+	Limit     int    `json:"limit"`
+	Offset    int    `json:"offset" validate:"min=0"`
+}
+
+// ListTrashResponse fills Sessions or Messages depending on the request.
+// PurgeAfter is how long items stay in the trash before they are deleted
+// for good.
+type ListTrashResponse struct {
+	Sessions   []*models.Session `json:"sessions,omitempty"`
+	Messages   []*models.Message `json:"messages,omitempty"`
+	TotalCount int64             `json:"total_count"`
+	HasMore    bool              `json:"has_more"`
+	PurgeAfter time.Duration     `json:"purge_after"`
+}
@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Sourav01112/chat-service/internal/models"
+	"github.com/Sourav01112/chat-service/internal/repository"
+)
+
+// The in-memory repositories below implement what the reply flow needs.
+// Anything else falls through to the embedded nil interface and panics, so
+// a test that strays outside them fails loudly.
+
+type memorySessions struct {
+	repository.SessionRepository
+
+	mu       sync.Mutex
+	sessions map[string]*models.Session
+}
+
+func newMemorySessions(sessions ...*models.Session) *memorySessions {
+	r := &memorySessions{sessions: make(map[string]*models.Session)}
+	for _, session := range sessions {
+		r.sessions[session.ID] = session
+	}
+	return r
+}
+
+func (r *memorySessions) GetByID(ctx context.Context, sessionID string) (*models.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[sessionID]
+	if !ok {
+		return nil, fmt.Errorf("session not found")
+	}
+	copied := *session
+	return &copied, nil
+}
+
+func (r *memorySessions) SetActiveLeaf(ctx context.Context, sessionID string, messageID *string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if session, ok := r.sessions[sessionID]; ok {
+		session.ActiveLeafID = messageID
+	}
+	return nil
+}
+
+func (r *memorySessions) UpdateLastActivity(ctx context.Context, sessionID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if session, ok := r.sessions[sessionID]; ok {
+		session.LastActivity = time.Now()
+	}
+	return nil
+}
+
+// memoryMessages keeps its own copies of messages, so what a test reads
+// back is what was written, not the service's object. Every UpdateContent
+// is also recorded in updates.
+type memoryMessages struct {
+	repository.MessageRepository
+
+	mu        sync.Mutex
+	messages  []*models.Message
+	updates   []models.Message
+	updateErr error
+}
+
+func (r *memoryMessages) Create(ctx context.Context, message *models.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if message.ID == "" {
+		message.ID = uuid.New().String()
+	}
+	message.CreatedAt = time.Now()
+	message.OrderIndex = len(r.messages) + 1
+	if message.Status == "" {
+		message.Status = models.MessageStatusComplete
+	}
+	stored := *message
+	r.messages = append(r.messages, &stored)
+	return nil
+}
+
+func (r *memoryMessages) GetByID(ctx context.Context, messageID string) (*models.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if message := r.find(messageID); message != nil {
+		copied := *message
+		return &copied, nil
+	}
+	return nil, fmt.Errorf("message not found")
+}
+
+// UpdateContent fails with updateErr, when set, for messages being
+// completed, so tests can make the final save of a streamed reply fail.
+func (r *memoryMessages) UpdateContent(ctx context.Context, message *models.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if r.updateErr != nil && message.Status == models.MessageStatusComplete {
+		return r.updateErr
+	}
+
+	stored := r.find(message.ID)
+	if stored == nil {
+		return fmt.Errorf("message not found")
+	}
+	stored.Content = message.Content
+	stored.Status = message.Status
+	stored.Metadata = message.Metadata
+	r.updates = append(r.updates, *stored)
+	return nil
+}
+
+func (r *memoryMessages) GetMessageCount(ctx context.Context, sessionID string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var count int64
+	for _, message := range r.messages {
+		if message.SessionID == sessionID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryMessages) GetChildIDs(ctx context.Context, sessionID string, parentIDs []string) (map[string][]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	children := make(map[string][]string)
+	for _, parentID := range parentIDs {
+		for _, message := range r.messages {
+			if message.SessionID != sessionID {
+				continue
+			}
+			if (parentID == "" && message.ParentMessageID == nil) ||
+				(message.ParentMessageID != nil && *message.ParentMessageID == parentID) {
+				children[parentID] = append(children[parentID], message.ID)
+			}
+		}
+	}
+	return children, nil
+}
+
+func (r *memoryMessages) GetPathAfter(ctx context.Context, leafID string, afterOrderIndex int, limit int) ([]*models.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.path(leafID, afterOrderIndex, limit), nil
+}
+
+// GetBySessionID only supports the tag and branch filters the prompt uses
+// to find pinned messages.
+func (r *memoryMessages) GetBySessionID(ctx context.Context, sessionID string, filter repository.MessageFilter, limit, offset int) ([]*models.Message, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	candidates := r.messages
+	if filter.LeafID != "" {
+		candidates = r.path(filter.LeafID, -1, len(r.messages))
+	}
+
+	var matched []*models.Message
+	for _, message := range candidates {
+		if message.SessionID == sessionID && hasTags(message, filter.Tags) {
+			copied := *message
+			matched = append(matched, &copied)
+		}
+	}
+
+	total := int64(len(matched))
+	if offset > len(matched) {
+		offset = len(matched)
+	}
+	matched = matched[offset:]
+	if len(matched) > limit {
+		matched = matched[:limit]
+	}
+	return matched, total, nil
+}
+
+func (r *memoryMessages) find(messageID string) *models.Message {
+	for _, message := range r.messages {
+		if message.ID == messageID {
+			return message
+		}
+	}
+	return nil
+}
+
+func (r *memoryMessages) path(leafID string, afterOrderIndex int, limit int) []*models.Message {
+	var path []*models.Message
+	for message := r.find(leafID); message != nil && message.OrderIndex > afterOrderIndex && len(path) < limit; {
+		copied := *message
+		path = append([]*models.Message{&copied}, path...)
+		if message.ParentMessageID == nil {
+			break
+		}
+		message = r.find(*message.ParentMessageID)
+	}
+	return path
+}
+
+func hasTags(message *models.Message, tags []string) bool {
+	for _, tag := range tags {
+		found := false
+		for _, messageTag := range message.Metadata.Tags {
+			if messageTag == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// noSummaries is a summary repository for sessions never summarized.
+type noSummaries struct {
+	repository.SummaryRepository
+}
+
+func (noSummaries) GetLatestForLeaf(ctx context.Context, sessionID string, leafID string) (*models.SessionSummary, error) {
+	return nil, nil
+}
+
+// memoryIdempotency holds idempotency keys by user, scope and key.
+type memoryIdempotency struct {
+	repository.IdempotencyRepository
+
+	mu   sync.Mutex
+	keys map[string]models.IdempotencyKey
+}
+
+func idempotencyKeyID(key *models.IdempotencyKey) string {
+	return key.UserID + "/" + key.Scope + "/" + key.Key
+}
+
+func (r *memoryIdempotency) Reserve(ctx context.Context, key *models.IdempotencyKey) (*models.IdempotencyKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if holder, ok := r.keys[idempotencyKeyID(key)]; ok {
+		return &holder, nil
+	}
+	if r.keys == nil {
+		r.keys = make(map[string]models.IdempotencyKey)
+	}
+	r.keys[idempotencyKeyID(key)] = *key
+	return nil, nil
+}
+
+func (r *memoryIdempotency) Complete(ctx context.Context, key *models.IdempotencyKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.keys[idempotencyKeyID(key)] = *key
+	return nil
+}
+
+func (r *memoryIdempotency) Release(ctx context.Context, key *models.IdempotencyKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.keys, idempotencyKeyID(key))
+	return nil
+}
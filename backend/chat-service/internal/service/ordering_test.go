@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/Sourav01112/chat-service/internal/config"
+	"github.com/Sourav01112/chat-service/internal/models"
+	pgrepo "github.com/Sourav01112/chat-service/internal/repository/postgres"
+)
+
+// testDatabaseURL names the variable holding the DSN of a Postgres database
+// with every migration applied. Tests that need one are skipped without it.
+const testDatabaseURL = "CHAT_SERVICE_TEST_DATABASE_URL"
+
+func openTestDatabase(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv(testDatabaseURL)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDatabaseURL)
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	return db
+}
+
+func TestSendMessageOrderingUnderConcurrency(t *testing.T) {
+	const senders = 50
+
+	db := openTestDatabase(t)
+	log := zap.NewNop()
+	ctx := context.Background()
+
+	sessionRepo := pgrepo.NewSessionRepository(db, log)
+	messageRepo := pgrepo.NewMessageRepository(db, log)
+
+	svc := NewChatService(
+		sessionRepo,
+		messageRepo,
+		pgrepo.NewSummaryRepository(db, log),
+		pgrepo.NewShareLinkRepository(db, log),
+		pgrepo.NewMemberRepository(db, log),
+		pgrepo.NewFolderRepository(db, log),
+		pgrepo.NewTagRepository(db, log),
+		pgrepo.NewIdempotencyRepository(db, log),
+		missCache{},
+		discardEvents{},
+		nil,
+		nil,
+		nil,
+		&config.Config{
+			MaxMessageLength:      1000,
+			MaxMessagesPerSession: 10000,
+		},
+		log,
+	)
+
+	session := &models.Session{
+		UserID:      uuid.New().String(),
+		Title:       "Concurrent sends",
+		TitleSource: models.TitleSourceUser,
+		Status:      models.SessionStatusActive,
+	}
+	if err := sessionRepo.Create(ctx, session); err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM outbox_events WHERE session_id = ?`, session.ID)
+		db.Exec(`DELETE FROM sessions WHERE id = ?`, session.ID)
+	})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, senders)
+	for i := 0; i < senders; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := svc.SendMessage(ctx, &SendMessageRequest{
+				SessionID: session.ID,
+				UserID:    session.UserID,
+				Content:   fmt.Sprintf("message %d", i),
+				Type:      models.MessageTypeUser,
+			})
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("SendMessage failed: %v", err)
+		}
+	}
+
+	var indexes []int
+	if err := db.Raw(`SELECT order_index FROM messages WHERE session_id = ? ORDER BY order_index`, session.ID).
+		Scan(&indexes).Error; err != nil {
+		t.Fatalf("failed to read order indexes: %v", err)
+	}
+	if len(indexes) != senders {
+		t.Fatalf("got %d messages, want %d", len(indexes), senders)
+	}
+	for i, index := range indexes {
+		if index != i+1 {
+			t.Fatalf("order_index %d at position %d, want %d: %v", index, i, i+1, indexes)
+		}
+	}
+
+	var last int
+	if err := db.Raw(`SELECT last_order_index FROM sessions WHERE id = ?`, session.ID).
+		Scan(&last).Error; err != nil {
+		t.Fatalf("failed to read last_order_index: %v", err)
+	}
+	if last != senders {
+		t.Errorf("last_order_index = %d, want %d", last, senders)
+	}
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/Sourav01112/chat-service/internal/models"
+	"github.com/Sourav01112/chat-service/internal/policy"
+	"github.com/Sourav01112/chat-service/internal/repository"
+)
+
+func (s *chatService) CreateFolder(ctx context.Context, req *FolderRequest) (*models.Folder, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	folder := &models.Folder{
+		UserID: req.UserID,
+		Name:   req.Name,
+	}
+	if err := s.folderRepo.Create(ctx, folder); err != nil {
+		return nil, err
+	}
+
+	return folder, nil
+}
+
+func (s *chatService) UpdateFolder(ctx context.Context, req *FolderRequest) (*models.Folder, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	if req.FolderID == "" {
+		return nil, fmt.Errorf("validation error: folder_id is required")
+	}
+
+	folder := &models.Folder{
+		ID:     req.FolderID,
+		UserID: req.UserID,
+		Name:   req.Name,
+	}
+	if err := s.folderRepo.Update(ctx, folder); err != nil {
+		return nil, err
+	}
+
+	return s.folderRepo.GetByID(ctx, req.FolderID, req.UserID)
+}
+
+// DeleteFolder removes one of the user's folders. The sessions in it are not
+// touched; they just stop being filed anywhere.
+func (s *chatService) DeleteFolder(ctx context.Context, folderID string, userID string) error {
+	if folderID == "" || userID == "" {
+		return fmt.Errorf("validation error: folder_id and user_id are required")
+	}
+
+	return s.folderRepo.Delete(ctx, folderID, userID)
+}
+
+func (s *chatService) ListFolders(ctx context.Context, userID string) ([]*models.Folder, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("validation error: user_id is required")
+	}
+
+	return s.folderRepo.GetByUserID(ctx, userID)
+}
+
+func (s *chatService) CreateTag(ctx context.Context, req *TagRequest) (*models.Tag, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	tag := &models.Tag{
+		UserID: req.UserID,
+		Name:   req.Name,
+		Color:  req.Color,
+	}
+	if err := s.tagRepo.Create(ctx, tag); err != nil {
+		return nil, err
+	}
+
+	return tag, nil
+}
+
+func (s *chatService) UpdateTag(ctx context.Context, req *TagRequest) (*models.Tag, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	if req.TagID == "" {
+		return nil, fmt.Errorf("validation error: tag_id is required")
+	}
+
+	tag := &models.Tag{
+		ID:     req.TagID,
+		UserID: req.UserID,
+		Name:   req.Name,
+		Color:  req.Color,
+	}
+	if err := s.tagRepo.Update(ctx, tag); err != nil {
+		return nil, err
+	}
+
+	return s.tagRepo.GetByID(ctx, req.TagID, req.UserID)
+}
+
+// DeleteTag removes one of the user's tags from every session it was on.
+func (s *chatService) DeleteTag(ctx context.Context, tagID string, userID string) error {
+	if tagID == "" || userID == "" {
+		return fmt.Errorf("validation error: tag_id and user_id are required")
+	}
+
+	return s.tagRepo.Delete(ctx, tagID, userID)
+}
+
+func (s *chatService) ListTags(ctx context.Context, userID string) ([]*models.Tag, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("validation error: user_id is required")
+	}
+
+	return s.tagRepo.GetByUserID(ctx, userID)
+}
+
+// OrganizeSession files, pins, favorites or tags a session for the calling
+// user. Any member may organize a session they can read; other members do
+// not see it.
+func (s *chatService) OrganizeSession(ctx context.Context, req *OrganizeSessionRequest) (*models.Session, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	session, _, err := s.authorize(ctx, req.SessionID, req.UserID, policy.ActionRead)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.FolderID != nil && *req.FolderID != "" {
+		if _, err := s.folderRepo.GetByID(ctx, *req.FolderID, req.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	organization := repository.SessionOrganization{
+		FolderID: req.FolderID,
+		Pinned:   req.Pinned,
+		Favorite: req.Favorite,
+	}
+	if organization.FolderID != nil || organization.Pinned != nil || organization.Favorite != nil {
+		if err := s.memberRepo.Organize(ctx, session.ID, req.UserID, organization); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.SetTags {
+		if err := s.tagRepo.SetSessionTags(ctx, session.ID, req.UserID, req.TagIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	member, err := s.memberRepo.Get(ctx, session.ID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	session.FolderID = member.FolderID
+	session.Pinned = member.Pinned
+	session.Favorite = member.Favorite
+
+	if err := s.attachTags(ctx, req.UserID, []*models.Session{session}); err != nil {
+		return nil, err
+	}
+
+	s.log.Info("Session organized",
+		zap.String("session_id", session.ID),
+		zap.String("user_id", req.UserID))
+
+	return session, nil
+}
+
+// attachTags fills in the tags userID has put on each session.
+func (s *chatService) attachTags(ctx context.Context, userID string, sessions []*models.Session) error {
+	if len(sessions) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(sessions))
+	for i, session := range sessions {
+		ids[i] = session.ID
+	}
+
+	tags, err := s.tagRepo.GetBySessionIDs(ctx, userID, ids)
+	if err != nil {
+		return fmt.Errorf("failed to load session tags: %w", err)
+	}
+
+	for _, session := range sessions {
+		session.Tags = tags[session.ID]
+	}
+	return nil
+}
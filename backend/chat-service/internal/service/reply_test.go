@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/Sourav01112/chat-service/internal/ai"
+	"github.com/Sourav01112/chat-service/internal/ai/fake"
+	"github.com/Sourav01112/chat-service/internal/config"
+	"github.com/Sourav01112/chat-service/internal/contextwindow"
+	"github.com/Sourav01112/chat-service/internal/models"
+)
+
+// newOfflineChatService builds a chat service on the fake AI client and the
+// in-memory repositories, with one active session owned by its user.
+func newOfflineChatService() (*chatService, *models.Session, *memorySessions, *memoryMessages, *fake.Client) {
+	session := &models.Session{
+		ID:          uuid.New().String(),
+		UserID:      uuid.New().String(),
+		Title:       "Offline chat",
+		TitleSource: models.TitleSourceUser,
+		Status:      models.SessionStatusActive,
+		Settings:    models.SessionSettings{MaxTokens: 256},
+	}
+	sessions := newMemorySessions(session)
+	messages := &memoryMessages{}
+	aiClient := fake.NewClient()
+
+	svc := NewChatService(
+		sessions,
+		messages,
+		noSummaries{},
+		nil,
+		nil,
+		nil,
+		nil,
+		&memoryIdempotency{},
+		missCache{},
+		discardEvents{},
+		aiClient,
+		nil,
+		contextwindow.NewBuilder(contextwindow.EstimateTokenizer{}, contextwindow.Budgets{Default: 4096}),
+		&config.Config{
+			MaxMessageLength:       1000,
+			MaxMessagesPerSession:  1000,
+			AIContextMessages:      20,
+			SummaryThresholdTokens: 1 << 20,
+			StreamSaveInterval:     time.Hour,
+			IdempotencyWindow:      time.Hour,
+		},
+		zap.NewNop(),
+	)
+
+	return svc.(*chatService), session, sessions, messages, aiClient
+}
+
+func TestSendMessageAndReplyStoresFakeReply(t *testing.T) {
+	ctx := context.Background()
+
+	svc, session, sessions, messages, aiClient := newOfflineChatService()
+
+	want := models.MessageMetadata{
+		ModelUsed:       "fake-model",
+		TokenCount:      42,
+		ResponseTimeMs:  12.5,
+		SourceCitations: map[string]string{"doc-1": "https://example.com/doc-1"},
+	}
+	aiClient.Reply = func(req *ai.GenerateRequest) (*ai.GenerateResponse, error) {
+		return &ai.GenerateResponse{Content: "Hello from the fake", Metadata: want, IsFinal: true}, nil
+	}
+
+	response, err := svc.SendMessageAndReply(ctx, &SendMessageRequest{
+		SessionID: session.ID,
+		UserID:    session.UserID,
+		Content:   "Hello?",
+	})
+	if err != nil {
+		t.Fatalf("SendMessageAndReply failed: %v", err)
+	}
+
+	if got := aiClient.LastRequest(); got == nil || got.UserMessage != "Hello?" {
+		t.Fatalf("AI service got %+v, want the user message", got)
+	}
+
+	userMessage := response.UserMessage
+	stored, err := messages.GetByID(ctx, response.AssistantMessage.ID)
+	if err != nil {
+		t.Fatalf("assistant message was not stored: %v", err)
+	}
+
+	if stored.Type != models.MessageTypeAssistant {
+		t.Errorf("Type = %s, want %s", stored.Type, models.MessageTypeAssistant)
+	}
+	if stored.Content != "Hello from the fake" {
+		t.Errorf("Content = %q, want the fake reply", stored.Content)
+	}
+	if stored.ParentMessageID == nil || *stored.ParentMessageID != userMessage.ID {
+		t.Errorf("ParentMessageID = %v, want %s", stored.ParentMessageID, userMessage.ID)
+	}
+	if stored.Metadata.ModelUsed != want.ModelUsed {
+		t.Errorf("ModelUsed = %q, want %q", stored.Metadata.ModelUsed, want.ModelUsed)
+	}
+	if stored.Metadata.TokenCount != want.TokenCount {
+		t.Errorf("TokenCount = %d, want %d", stored.Metadata.TokenCount, want.TokenCount)
+	}
+	if stored.Metadata.ResponseTimeMs != want.ResponseTimeMs {
+		t.Errorf("ResponseTimeMs = %v, want %v", stored.Metadata.ResponseTimeMs, want.ResponseTimeMs)
+	}
+	if !reflect.DeepEqual(stored.Metadata.SourceCitations, want.SourceCitations) {
+		t.Errorf("SourceCitations = %v, want %v", stored.Metadata.SourceCitations, want.SourceCitations)
+	}
+
+	if leaf, _ := sessions.GetByID(ctx, session.ID); leaf.ActiveLeafID == nil || *leaf.ActiveLeafID != stored.ID {
+		t.Errorf("active leaf = %v, want the assistant message", leaf.ActiveLeafID)
+	}
+}
+
+func TestSendMessageAndReplyReplaysKey(t *testing.T) {
+	ctx := context.Background()
+
+	svc, session, _, messages, aiClient := newOfflineChatService()
+	failing := true
+	aiClient.Reply = func(req *ai.GenerateRequest) (*ai.GenerateResponse, error) {
+		if failing {
+			return nil, errors.New("upstream unavailable")
+		}
+		return replyWith("Hello from the fake")(req)
+	}
+
+	req := func() *SendMessageRequest {
+		return &SendMessageRequest{
+			SessionID:      session.ID,
+			UserID:         session.UserID,
+			Content:        "Hello?",
+			IdempotencyKey: "reply-1",
+		}
+	}
+
+	// The first attempt stores the user message but no reply.
+	first, err := svc.SendMessageAndReply(ctx, req())
+	if err == nil {
+		t.Fatal("SendMessageAndReply succeeded, want the AI error")
+	}
+
+	// A retry generates the missing reply to the same user message.
+	failing = false
+	second, err := svc.SendMessageAndReply(ctx, req())
+	if err != nil {
+		t.Fatalf("retry failed: %v", err)
+	}
+	if second.UserMessage.ID != first.UserMessage.ID {
+		t.Errorf("retry stored user message %s, want %s", second.UserMessage.ID, first.UserMessage.ID)
+	}
+	if second.AssistantMessage == nil {
+		t.Fatal("retry returned no reply")
+	}
+
+	// Once a reply is stored, a retry returns it without asking again.
+	third, err := svc.SendMessageAndReply(ctx, req())
+	if err != nil {
+		t.Fatalf("second retry failed: %v", err)
+	}
+	if third.UserMessage.ID != first.UserMessage.ID || third.AssistantMessage.ID != second.AssistantMessage.ID {
+		t.Errorf("second retry returned %s/%s, want %s/%s",
+			third.UserMessage.ID, third.AssistantMessage.ID, first.UserMessage.ID, second.AssistantMessage.ID)
+	}
+
+	if len(aiClient.Requests) != 2 {
+		t.Errorf("AI service got %d requests, want 2", len(aiClient.Requests))
+	}
+	if len(messages.messages) != 2 {
+		t.Errorf("stored %d messages, want 2", len(messages.messages))
+	}
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Sourav01112/chat-service/internal/models"
+	"github.com/Sourav01112/chat-service/internal/policy"
+)
+
+// shareTokenBytes is how much randomness goes into a share token.
+const shareTokenBytes = 24
+
+// CreateShareLink lets the owner of a session share it read-only. A snapshot
+// link copies the active branch now; a live link shows it as it is whenever
+// the link is opened.
+func (s *chatService) CreateShareLink(ctx context.Context, req *CreateShareLinkRequest) (*models.ShareLink, error) {
+	if req.Mode == "" {
+		req.Mode = models.ShareModeSnapshot
+	}
+
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	if !req.Mode.IsValid() {
+		return nil, fmt.Errorf("invalid share mode: %s", req.Mode)
+	}
+
+	session, _, err := s.authorize(ctx, req.SessionID, req.UserID, policy.ActionManage)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := newShareToken()
+	if err != nil {
+		return nil, err
+	}
+
+	link := &models.ShareLink{
+		SessionID:        session.ID,
+		UserID:           req.UserID,
+		Token:            token,
+		Mode:             req.Mode,
+		IncludeCitations: req.IncludeCitations,
+	}
+
+	expiresIn := req.ExpiresIn
+	if expiresIn == 0 {
+		expiresIn = s.config.ShareLinkTTL
+	}
+	if expiresIn > 0 {
+		expiresAt := time.Now().Add(expiresIn)
+		link.ExpiresAt = &expiresAt
+	}
+
+	if link.Mode == models.ShareModeSnapshot {
+		messages, err := s.sharedMessages(ctx, session, link.IncludeCitations)
+		if err != nil {
+			return nil, err
+		}
+		link.Snapshot = &models.ShareSnapshot{
+			Title:     session.Title,
+			CreatedAt: session.CreatedAt,
+			Messages:  messages,
+		}
+	}
+
+	if err := s.shareLinkRepo.Create(ctx, link); err != nil {
+		return nil, err
+	}
+
+	return link, nil
+}
+
+// GetSharedSession opens a share link. It needs no user, so it only returns
+// what models.SharedMessage carries, and counts the view.
+func (s *chatService) GetSharedSession(ctx context.Context, token string) (*models.SharedSession, error) {
+	if token == "" {
+		return nil, fmt.Errorf("validation error: token is required")
+	}
+
+	link, err := s.shareLinkRepo.GetByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if link.RevokedAt != nil {
+		return nil, fmt.Errorf("share link has been revoked")
+	}
+	if !link.IsUsable(time.Now()) {
+		return nil, fmt.Errorf("share link has expired")
+	}
+
+	shared := &models.SharedSession{
+		Mode:     link.Mode,
+		SharedAt: link.CreatedAt,
+	}
+
+	if link.Mode == models.ShareModeSnapshot && link.Snapshot != nil {
+		shared.Title = link.Snapshot.Title
+		shared.CreatedAt = link.Snapshot.CreatedAt
+		shared.Messages = link.Snapshot.Messages
+	} else {
+		session, err := s.sessionRepo.GetByID(ctx, link.SessionID)
+		if err != nil || session.Status == models.SessionStatusArchived {
+			return nil, fmt.Errorf("share link not found")
+		}
+
+		shared.Title = session.Title
+		shared.CreatedAt = session.CreatedAt
+		shared.Messages, err = s.sharedMessages(ctx, session, link.IncludeCitations)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	shared.ViewCount, err = s.shareLinkRepo.RecordView(ctx, link.ID)
+	if err != nil {
+		s.log.Warn("Failed to record share link view",
+			zap.Error(err),
+			zap.String("share_link_id", link.ID))
+		shared.ViewCount = link.ViewCount
+	}
+
+	return shared, nil
+}
+
+func (s *chatService) RevokeShareLink(ctx context.Context, token string, userID string) error {
+	if token == "" || userID == "" {
+		return fmt.Errorf("validation error: token and user_id are required")
+	}
+
+	return s.shareLinkRepo.Revoke(ctx, token, userID)
+}
+
+// sharedMessages returns the session's active branch as a share link shows
+// it, without system messages.
+func (s *chatService) sharedMessages(ctx context.Context, session *models.Session, includeCitations bool) ([]*models.SharedMessage, error) {
+	shared := []*models.SharedMessage{}
+	if session.ActiveLeafID == nil {
+		return shared, nil
+	}
+
+	path, err := s.messageRepo.GetPath(ctx, *session.ActiveLeafID, s.config.MaxMessagesPerSession)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load conversation: %w", err)
+	}
+
+	for _, message := range path {
+		if message.IsSystemMessage() {
+			continue
+		}
+		shared = append(shared, models.NewSharedMessage(message, includeCitations))
+	}
+
+	return shared, nil
+}
+
+func newShareToken() (string, error) {
+	buf := make([]byte, shareTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate share token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
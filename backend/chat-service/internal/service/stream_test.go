@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Sourav01112/chat-service/internal/ai"
+	"github.com/Sourav01112/chat-service/internal/ai/fake"
+	"github.com/Sourav01112/chat-service/internal/models"
+)
+
+const streamedReply = "one two three four"
+
+func replyWith(content string) func(req *ai.GenerateRequest) (*ai.GenerateResponse, error) {
+	return func(req *ai.GenerateRequest) (*ai.GenerateResponse, error) {
+		return &ai.GenerateResponse{
+			Content:  content,
+			Metadata: models.MessageMetadata{ModelUsed: "fake-model", TokenCount: 4},
+			IsFinal:  true,
+		}, nil
+	}
+}
+
+// truncatingClient streams its chunks and then ends the stream without ever
+// sending the final one.
+type truncatingClient struct {
+	*fake.Client
+	chunks []string
+}
+
+func (c *truncatingClient) GenerateStreamResponse(ctx context.Context, req *ai.GenerateRequest) (ai.ResponseStream, error) {
+	return &truncatedStream{chunks: c.chunks}, nil
+}
+
+type truncatedStream struct {
+	chunks []string
+}
+
+func (s *truncatedStream) Recv() (*ai.GenerateResponse, error) {
+	if len(s.chunks) == 0 {
+		return nil, io.EOF
+	}
+	chunk := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return &ai.GenerateResponse{Content: chunk}, nil
+}
+
+// streamReply runs StreamReply with send, and returns the deltas it sent,
+// the assistant message as stored and StreamReply's error.
+func streamReply(ctx context.Context, t *testing.T, svc *chatService, session *models.Session, messages *memoryMessages, send func(*StreamReplyEvent) error) (string, *models.Message, error) {
+	t.Helper()
+
+	var deltas strings.Builder
+	var assistantID string
+	err := svc.StreamReply(ctx, &SendMessageRequest{
+		SessionID: session.ID,
+		UserID:    session.UserID,
+		Content:   "Count to four",
+	}, func(event *StreamReplyEvent) error {
+		assistantID = event.AssistantMessageID
+		deltas.WriteString(event.Delta)
+		if send != nil {
+			return send(event)
+		}
+		return nil
+	})
+
+	if assistantID == "" {
+		t.Fatalf("StreamReply sent no events: %v", err)
+	}
+	stored, getErr := messages.GetByID(context.Background(), assistantID)
+	if getErr != nil {
+		t.Fatalf("assistant message was not stored: %v", getErr)
+	}
+
+	return deltas.String(), stored, err
+}
+
+func TestStreamReplyCompletes(t *testing.T) {
+	svc, session, _, messages, aiClient := newOfflineChatService()
+	aiClient.Reply = replyWith(streamedReply)
+	svc.config.StreamSaveInterval = 0
+
+	deltas, stored, err := streamReply(context.Background(), t, svc, session, messages, nil)
+	if err != nil {
+		t.Fatalf("StreamReply failed: %v", err)
+	}
+
+	if deltas != streamedReply {
+		t.Errorf("deltas = %q, want %q", deltas, streamedReply)
+	}
+	if stored.Status != models.MessageStatusComplete || stored.Content != streamedReply {
+		t.Errorf("stored %s %q, want complete %q", stored.Status, stored.Content, streamedReply)
+	}
+	if stored.Metadata.ModelUsed != "fake-model" {
+		t.Errorf("ModelUsed = %q, want fake-model", stored.Metadata.ModelUsed)
+	}
+
+	// With no save interval every delta is saved as it arrives.
+	var partial []string
+	for _, update := range messages.updates {
+		if update.Status == models.MessageStatusStreaming {
+			partial = append(partial, update.Content)
+		}
+	}
+	want := []string{"one", "one two", "one two three", "one two three four"}
+	if strings.Join(partial, "|") != strings.Join(want, "|") {
+		t.Errorf("partial saves = %q, want %q", partial, want)
+	}
+}
+
+func TestStreamReplyFinalChunkContentIsSent(t *testing.T) {
+	svc, session, _, messages, aiClient := newOfflineChatService()
+	svc.aiClient = &finalContentClient{Client: aiClient}
+
+	deltas, stored, err := streamReply(context.Background(), t, svc, session, messages, nil)
+	if err != nil {
+		t.Fatalf("StreamReply failed: %v", err)
+	}
+
+	if deltas != "one two" || stored.Content != "one two" {
+		t.Errorf("deltas %q, stored %q, want both %q", deltas, stored.Content, "one two")
+	}
+}
+
+// finalContentClient puts text on the final chunk as well as before it.
+type finalContentClient struct {
+	*fake.Client
+}
+
+func (c *finalContentClient) GenerateStreamResponse(ctx context.Context, req *ai.GenerateRequest) (ai.ResponseStream, error) {
+	return &finalContentStream{}, nil
+}
+
+type finalContentStream struct {
+	sent int
+}
+
+func (s *finalContentStream) Recv() (*ai.GenerateResponse, error) {
+	s.sent++
+	switch s.sent {
+	case 1:
+		return &ai.GenerateResponse{Content: "one"}, nil
+	case 2:
+		return &ai.GenerateResponse{Content: " two", IsFinal: true}, nil
+	}
+	return nil, io.EOF
+}
+
+func TestStreamReplyKeepsIncompleteReply(t *testing.T) {
+	errSaveFailed := errors.New("save failed")
+
+	tests := []struct {
+		name    string
+		setup   func(svc *chatService, messages *memoryMessages, aiClient *fake.Client)
+		cancel  int
+		content string
+	}{
+		{
+			name:    "client cancels",
+			cancel:  2,
+			content: "one two",
+		},
+		{
+			name: "upstream error",
+			setup: func(svc *chatService, messages *memoryMessages, aiClient *fake.Client) {
+				aiClient.StreamErr = errors.New("upstream failed")
+			},
+			content: streamedReply,
+		},
+		{
+			name: "stream ends before final chunk",
+			setup: func(svc *chatService, messages *memoryMessages, aiClient *fake.Client) {
+				svc.aiClient = &truncatingClient{Client: aiClient, chunks: []string{"one", " two", " three"}}
+			},
+			content: "one two three",
+		},
+		{
+			name: "final save fails",
+			setup: func(svc *chatService, messages *memoryMessages, aiClient *fake.Client) {
+				messages.updateErr = errSaveFailed
+			},
+			content: streamedReply,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, session, _, messages, aiClient := newOfflineChatService()
+			aiClient.Reply = replyWith(streamedReply)
+			if tt.setup != nil {
+				tt.setup(svc, messages, aiClient)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			deltas := 0
+			send := func(event *StreamReplyEvent) error {
+				if event.Delta == "" {
+					return nil
+				}
+				deltas++
+				if tt.cancel > 0 && deltas == tt.cancel {
+					cancel()
+					return ctx.Err()
+				}
+				return nil
+			}
+
+			_, stored, err := streamReply(ctx, t, svc, session, messages, send)
+			if err == nil {
+				t.Fatal("StreamReply succeeded, want an error")
+			}
+			if stored.Status != models.MessageStatusIncomplete {
+				t.Errorf("Status = %s, want %s", stored.Status, models.MessageStatusIncomplete)
+			}
+			if stored.Content != tt.content {
+				t.Errorf("Content = %q, want %q", stored.Content, tt.content)
+			}
+		})
+	}
+}
+
+func TestStreamReplyReplaysKey(t *testing.T) {
+	ctx := context.Background()
+
+	svc, session, _, messages, aiClient := newOfflineChatService()
+	aiClient.Reply = replyWith(streamedReply)
+
+	var events []*StreamReplyEvent
+	stream := func() error {
+		events = nil
+		return svc.StreamReply(ctx, &SendMessageRequest{
+			SessionID:      session.ID,
+			UserID:         session.UserID,
+			Content:        "Count to four",
+			IdempotencyKey: "stream-1",
+		}, func(event *StreamReplyEvent) error {
+			events = append(events, event)
+			return nil
+		})
+	}
+
+	if err := stream(); err != nil {
+		t.Fatalf("StreamReply failed: %v", err)
+	}
+	firstUserID := events[0].UserMessage.ID
+	firstAssistantID := events[0].AssistantMessageID
+
+	if err := stream(); err != nil {
+		t.Fatalf("retry failed: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("retry sent %d events, want the start and the whole reply", len(events))
+	}
+	if events[0].UserMessage == nil || events[0].UserMessage.ID != firstUserID || events[0].AssistantMessageID != firstAssistantID {
+		t.Errorf("retry started %+v, want user message %s and reply %s", events[0], firstUserID, firstAssistantID)
+	}
+	last := events[1]
+	if !last.IsFinal || last.Delta != streamedReply || last.AssistantMessage == nil || last.AssistantMessage.ID != firstAssistantID {
+		t.Errorf("retry finished with %+v, want the stored reply %q", last, streamedReply)
+	}
+
+	if len(aiClient.Requests) != 1 {
+		t.Errorf("AI service got %d requests, want 1", len(aiClient.Requests))
+	}
+	if len(messages.messages) != 2 {
+		t.Errorf("stored %d messages, want 2", len(messages.messages))
+	}
+}
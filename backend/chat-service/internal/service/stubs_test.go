@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Sourav01112/chat-service/internal/models"
+	"github.com/Sourav01112/chat-service/internal/repository"
+)
+
+var errCacheMiss = errors.New("cache miss")
+
+// missCache is a cache that never holds anything, so every read goes to the
+// repositories under test.
+type missCache struct{}
+
+func (missCache) SetSession(ctx context.Context, session *models.Session, ttl time.Duration) error {
+	return nil
+}
+
+func (missCache) GetSession(ctx context.Context, sessionID string) (*models.Session, error) {
+	return nil, errCacheMiss
+}
+
+func (missCache) DeleteSession(ctx context.Context, sessionID string) error {
+	return nil
+}
+
+func (missCache) PatchSession(ctx context.Context, sessionID string, patch func(session *models.Session)) error {
+	return nil
+}
+
+func (missCache) SetRecentMessages(ctx context.Context, sessionID string, recent *repository.RecentMessages, ttl time.Duration) error {
+	return nil
+}
+
+func (missCache) GetRecentMessages(ctx context.Context, sessionID string) (*repository.RecentMessages, error) {
+	return nil, errCacheMiss
+}
+
+func (missCache) AppendRecentMessage(ctx context.Context, message *models.Message, ttl time.Duration) error {
+	return nil
+}
+
+func (missCache) UpdateRecentMessage(ctx context.Context, message *models.Message) error {
+	return nil
+}
+
+func (missCache) RemoveRecentMessage(ctx context.Context, message *models.Message) error {
+	return nil
+}
+
+func (missCache) DeleteRecentMessages(ctx context.Context, sessionID string) error {
+	return nil
+}
+
+func (missCache) SetTypingStatus(ctx context.Context, sessionID, userID string, isTyping bool, ttl time.Duration) error {
+	return nil
+}
+
+func (missCache) GetTypingUsers(ctx context.Context, sessionID string) ([]string, error) {
+	return nil, nil
+}
+
+func (missCache) InvalidateSessionCache(ctx context.Context, sessionID string) error {
+	return nil
+}
+
+func (missCache) SetIdempotencyKey(ctx context.Context, key *models.IdempotencyKey) error {
+	return nil
+}
+
+func (missCache) GetIdempotencyKey(ctx context.Context, userID, scope, key string) (*models.IdempotencyKey, error) {
+	return nil, errCacheMiss
+}
+
+// discardEvents drops every session event.
+type discardEvents struct{}
+
+func (discardEvents) Publish(ctx context.Context, event *models.SessionEvent) error {
+	return nil
+}
+
+func (discardEvents) Subscribe(ctx context.Context, sessionID string, cursor string) (<-chan *models.SessionEvent, error) {
+	events := make(chan *models.SessionEvent)
+	close(events)
+	return events, nil
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/Sourav01112/chat-service/internal/ai"
+	"github.com/Sourav01112/chat-service/internal/models"
+)
+
+const summaryInstructions = "You maintain a running summary of a conversation between a user and an assistant. " +
+	"Combine the previous summary, if any, with the new turns into one summary of at most a few paragraphs. " +
+	"Keep facts, decisions, names, numbers and open questions; drop greetings and small talk. " +
+	"Reply with the summary only."
+
+// summarizeInBackground rolls the summary forward to throughID without
+// holding up the reply that noticed it was needed. Only one summary per
+// session is built at a time; requests that arrive meanwhile are dropped
+// since the next prompt will ask again if it still needs one.
+func (s *chatService) summarizeInBackground(ctx context.Context, session *models.Session, throughID string) {
+	if _, busy := s.summarizing.LoadOrStore(session.ID, struct{}{}); busy {
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+
+	go func() {
+		defer s.summarizing.Delete(session.ID)
+
+		if _, err := s.summarize(ctx, session, throughID); err != nil {
+			s.log.Warn("Failed to summarize session",
+				zap.Error(err),
+				zap.String("session_id", session.ID),
+				zap.String("through_message_id", throughID))
+		}
+	}()
+}
+
+// summarize asks the AI service to fold the turns after the latest valid
+// summary, up to and including throughID, into a new summary version. A
+// long run of turns is folded a chunk at a time, oldest first, each chunk
+// sized to fit the summary model's budget together with the summary carried
+// forward from the one before. Every step is stored as a version, so a
+// failure part way resumes from the last chunk folded.
+func (s *chatService) summarize(ctx context.Context, session *models.Session, throughID string) (*models.SessionSummary, error) {
+	through, err := s.messageRepo.GetByID(ctx, throughID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+
+	previous, err := s.summaryRepo.GetLatestForLeaf(ctx, session.ID, throughID)
+	if err != nil {
+		return nil, err
+	}
+	if previous != nil && previous.ThroughOrderIndex >= through.OrderIndex {
+		return previous, nil
+	}
+
+	summarized := -1
+	if previous != nil {
+		summarized = previous.ThroughOrderIndex
+	}
+
+	turns, err := s.messageRepo.GetPathAfter(ctx, throughID, summarized, s.config.MaxMessagesPerSession)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load conversation: %w", err)
+	}
+
+	// Each request holds the instructions, the summary so far, the chunk and
+	// room for the reply; the summary so far is at most one reply long.
+	budget := s.contextBuilder.Budget(s.config.SummaryModel) -
+		s.contextBuilder.CountTokens(summaryInstructions) -
+		2*s.config.SummaryMaxTokens
+	if budget <= 0 {
+		return nil, fmt.Errorf("failed to summarize session: summary model budget leaves no room for turns")
+	}
+
+	summary := previous
+	for _, chunk := range s.contextBuilder.Chunks(turns, budget) {
+		if summary, err = s.summarizeChunk(ctx, session, summary, chunk); err != nil {
+			return nil, err
+		}
+	}
+
+	return summary, nil
+}
+
+// summarizeChunk folds turns into previous, which may be nil, and stores the
+// result as a summary through the last of them.
+func (s *chatService) summarizeChunk(ctx context.Context, session *models.Session, previous *models.SessionSummary, turns []*models.Message) (*models.SessionSummary, error) {
+	var transcript strings.Builder
+	messageCount := 0
+	if previous != nil {
+		fmt.Fprintf(&transcript, "Previous summary:\n%s\n\nNew turns:\n", previous.Content)
+		messageCount = previous.MessageCount
+	}
+	for _, message := range turns {
+		fmt.Fprintf(&transcript, "%s: %s\n", message.Type, message.Content)
+		messageCount++
+	}
+
+	reply, err := s.aiClient.GenerateResponse(ctx, &ai.GenerateRequest{
+		SessionID:   session.ID,
+		UserID:      session.UserID,
+		UserMessage: transcript.String(),
+		Settings: models.SessionSettings{
+			AIPersona:       session.Settings.AIPersona,
+			Temperature:     0.2,
+			MaxTokens:       s.config.SummaryMaxTokens,
+			DocumentSources: []string{},
+			SystemPrompt:    summaryInstructions,
+		},
+		Model: s.config.SummaryModel,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate summary: %w", err)
+	}
+
+	through := turns[len(turns)-1]
+	summary := &models.SessionSummary{
+		SessionID:         session.ID,
+		ThroughMessageID:  through.ID,
+		ThroughOrderIndex: through.OrderIndex,
+		Content:           strings.TrimSpace(reply.Content),
+		MessageCount:      messageCount,
+		TokenCount:        reply.Metadata.TokenCount,
+		ModelUsed:         reply.Metadata.ModelUsed,
+	}
+
+	if err := s.summaryRepo.Create(ctx, summary); err != nil {
+		return nil, err
+	}
+
+	s.log.Info("Session summarized",
+		zap.String("session_id", session.ID),
+		zap.Int("version", summary.Version),
+		zap.String("through_message_id", through.ID),
+		zap.Int("message_count", summary.MessageCount))
+
+	return summary, nil
+}
+
+// invalidateSummaries discards the summaries covering a message that was
+// edited or deleted and rebuilds the newest of them. If the message that
+// summary ended at is among removedIDs, the rebuilt one ends at
+// replacementID instead, or is skipped when that is nil.
+func (s *chatService) invalidateSummaries(ctx context.Context, message *models.Message, removedIDs []string, replacementID *string) {
+	invalidated, err := s.summaryRepo.InvalidateFrom(ctx, message.SessionID, message.OrderIndex)
+	if err != nil {
+		s.log.Warn("Failed to invalidate session summaries",
+			zap.Error(err),
+			zap.String("session_id", message.SessionID))
+		return
+	}
+	if len(invalidated) == 0 {
+		return
+	}
+
+	s.log.Info("Session summaries invalidated",
+		zap.String("session_id", message.SessionID),
+		zap.String("message_id", message.ID),
+		zap.Int("count", len(invalidated)))
+
+	throughID := invalidated[0].ThroughMessageID
+	for _, removedID := range removedIDs {
+		if removedID != throughID {
+			continue
+		}
+		if replacementID == nil {
+			return
+		}
+		throughID = *replacementID
+		break
+	}
+
+	session, err := s.sessionRepo.GetByID(ctx, message.SessionID)
+	if err != nil {
+		s.log.Warn("Failed to load session for summary rebuild",
+			zap.Error(err),
+			zap.String("session_id", message.SessionID))
+		return
+	}
+
+	s.summarizeInBackground(ctx, session, throughID)
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"go.uber.org/zap"
+
+	"github.com/Sourav01112/chat-service/internal/ai"
+	"github.com/Sourav01112/chat-service/internal/models"
+)
+
+const (
+	titleInstructions = "Write a short title for the conversation below, at most six words. " +
+		"Do not use quotes or a trailing full stop. Reply with the title only."
+
+	maxTitleWords = 6
+	maxTitleRunes = 80
+)
+
+// generateTitleInBackground gives a session created without a title one
+// based on its first exchange. It returns straight away; the title arrives
+// with a session_updated event.
+func (s *chatService) generateTitleInBackground(ctx context.Context, prompt *models.Message, reply *models.Message) {
+	session, err := s.GetSession(ctx, prompt.SessionID, prompt.UserID)
+	if err != nil || !session.HasDefaultTitle() {
+		return
+	}
+
+	if _, busy := s.titling.LoadOrStore(session.ID, struct{}{}); busy {
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+
+	go func() {
+		defer s.titling.Delete(session.ID)
+
+		if err := s.generateTitle(ctx, session, prompt, reply); err != nil {
+			s.log.Warn("Failed to generate session title",
+				zap.Error(err),
+				zap.String("session_id", session.ID))
+		}
+	}()
+}
+
+func (s *chatService) generateTitle(ctx context.Context, session *models.Session, prompt *models.Message, reply *models.Message) error {
+	title := ""
+
+	response, err := s.aiClient.GenerateResponse(ctx, &ai.GenerateRequest{
+		SessionID:   session.ID,
+		UserID:      session.UserID,
+		UserMessage: fmt.Sprintf("user: %s\nassistant: %s", prompt.Content, reply.Content),
+		Settings: models.SessionSettings{
+			AIPersona:       session.Settings.AIPersona,
+			Temperature:     0.3,
+			MaxTokens:       32,
+			DocumentSources: []string{},
+			SystemPrompt:    titleInstructions,
+		},
+	})
+	if err != nil {
+		s.log.Warn("Falling back to local session title",
+			zap.Error(err),
+			zap.String("session_id", session.ID))
+	} else {
+		title = cleanTitle(response.Content)
+	}
+
+	if title == "" {
+		title = keyPhraseTitle(prompt.Content)
+	}
+	if title == "" {
+		return nil
+	}
+
+	// A title the user set while this one was being generated wins.
+	set, err := s.sessionRepo.SetGeneratedTitle(ctx, session.ID, title)
+	if err != nil {
+		return err
+	}
+	if !set {
+		return nil
+	}
+
+	session.Title = title
+	session.TitleSource = models.TitleSourceGenerated
+
+	_ = s.cacheRepo.PatchSession(ctx, session.ID, func(cached *models.Session) {
+		if cached.HasDefaultTitle() {
+			cached.Title = title
+			cached.TitleSource = models.TitleSourceGenerated
+		}
+	})
+
+	s.publishEvent(ctx, models.NewSessionEvent(models.EventTypeSessionUpdated, session))
+
+	s.log.Info("Session title generated",
+		zap.String("session_id", session.ID),
+		zap.String("title", title))
+
+	return nil
+}
+
+// cleanTitle trims what models tend to wrap a title in: a "Title:" label,
+// quotes, a trailing full stop and anything after the first line.
+func cleanTitle(text string) string {
+	title, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	title = strings.TrimSpace(title)
+	if label, rest, ok := strings.Cut(title, ":"); ok && strings.EqualFold(strings.TrimSpace(label), "title") {
+		title = rest
+	}
+	title = strings.Trim(title, " \t\"'`*.")
+
+	return truncateTitle(strings.Fields(title))
+}
+
+// keyPhraseTitle builds a title from the first content words of text when the
+// AI service cannot provide one.
+func keyPhraseTitle(text string) string {
+	var words []string
+	for _, word := range strings.Fields(text) {
+		word = strings.TrimFunc(word, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		})
+		if word == "" || titleStopWords[strings.ToLower(word)] {
+			continue
+		}
+
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		words = append(words, string(runes))
+	}
+
+	return truncateTitle(words)
+}
+
+func truncateTitle(words []string) string {
+	if len(words) > maxTitleWords {
+		words = words[:maxTitleWords]
+	}
+
+	title := []rune(strings.Join(words, " "))
+	if len(title) > maxTitleRunes {
+		title = title[:maxTitleRunes]
+	}
+	return strings.TrimSpace(string(title))
+}
+
+var titleStopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "and": true, "or": true, "but": true,
+	"i": true, "me": true, "my": true, "we": true, "you": true, "your": true,
+	"it": true, "is": true, "are": true, "was": true, "be": true, "do": true,
+	"does": true, "can": true, "could": true, "would": true, "should": true,
+	"please": true, "help": true, "what": true, "how": true, "why": true,
+	"when": true, "where": true, "which": true, "who": true, "to": true,
+	"of": true, "in": true, "on": true, "for": true, "with": true, "about": true,
+	"this": true, "that": true, "there": true, "hi": true, "hello": true,
+	"hey": true, "tell": true, "explain": true, "want": true, "need": true,
+}
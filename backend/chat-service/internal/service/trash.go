@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/Sourav01112/chat-service/internal/models"
+	"github.com/Sourav01112/chat-service/internal/policy"
+)
+
+// ListTrash shows what a user can still restore. Deleted sessions are only
+// listed to their owner; a session's deleted messages to anyone who may
+// write in it.
+func (s *chatService) ListTrash(ctx context.Context, req *ListTrashRequest) (*ListTrashResponse, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+	if req.Limit > 100 {
+		req.Limit = 100
+	}
+
+	response := &ListTrashResponse{PurgeAfter: s.config.TrashRetention}
+
+	if req.SessionID == "" {
+		sessions, total, err := s.sessionRepo.GetTrashedByUserID(ctx, req.UserID, req.Limit, req.Offset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list trash: %w", err)
+		}
+		response.Sessions = sessions
+		response.TotalCount = total
+		response.HasMore = int64(req.Offset+len(sessions)) < total
+		return response, nil
+	}
+
+	if _, _, err := s.authorize(ctx, req.SessionID, req.UserID, policy.ActionWrite); err != nil {
+		return nil, err
+	}
+
+	messages, total, err := s.messageRepo.GetDeletedBySessionID(ctx, req.SessionID, req.Limit, req.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trash: %w", err)
+	}
+	response.Messages = messages
+	response.TotalCount = total
+	response.HasMore = int64(req.Offset+len(messages)) < total
+
+	return response, nil
+}
+
+// RestoreSession takes a deleted session out of the trash. Only its owner
+// may do this.
+func (s *chatService) RestoreSession(ctx context.Context, sessionID string, userID string) (*models.Session, error) {
+	session, _, err := s.authorize(ctx, sessionID, userID, policy.ActionManage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore session: %w", err)
+	}
+
+	if err := s.sessionRepo.Restore(ctx, session.ID); err != nil {
+		return nil, fmt.Errorf("failed to restore session: %w", err)
+	}
+	session.SetStatus(models.SessionStatusActive)
+
+	_ = s.cacheRepo.InvalidateSessionCache(ctx, session.ID)
+
+	s.publishEvent(ctx, models.NewSessionEvent(models.EventTypeSessionStatusChanged, session))
+
+	s.log.Info("Session restored successfully",
+		zap.String("session_id", session.ID),
+		zap.String("user_id", userID))
+
+	return session, nil
+}
+
+// RestoreMessage puts a deleted message back where it was. The same members
+// who could delete it may restore it. If the active branch ended where the
+// message used to hang, it is extended back through it.
+func (s *chatService) RestoreMessage(ctx context.Context, messageID string, userID string) (*models.Message, error) {
+	deleted, err := s.messageRepo.GetDeletedByID(ctx, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore message: %w", err)
+	}
+
+	session, role, err := s.authorize(ctx, deleted.SessionID, userID, policy.ActionWrite)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore message: %w", err)
+	}
+	if !policy.CanModifyMessage(role, userID, deleted) {
+		return nil, fmt.Errorf("failed to restore message: access denied: %s cannot restore another member's message", role)
+	}
+
+	message, err := s.messageRepo.Restore(ctx, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore message: %w", err)
+	}
+
+	if sameMessageID(session.ActiveLeafID, message.ParentMessageID) {
+		if leafID, err := s.messageRepo.GetLatestLeafID(ctx, message.ID); err == nil {
+			s.setActiveLeaf(ctx, session.ID, &leafID)
+		}
+	}
+
+	s.invalidateSummaries(ctx, message, nil, nil)
+
+	_ = s.cacheRepo.DeleteRecentMessages(ctx, session.ID)
+
+	s.publishEvent(ctx, models.NewMessageEvent(models.EventTypeMessageRestored, message))
+
+	s.log.Info("Message restored successfully",
+		zap.String("message_id", message.ID),
+		zap.String("user_id", userID))
+
+	return message, nil
+}
+
+func sameMessageID(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,30 @@
+package users
+
+import "context"
+
+// Client is the slice of user-service that chat-service depends on.
+type Client interface {
+	GetPreferences(ctx context.Context, userID string) (*Preferences, error)
+	RecordActivity(ctx context.Context, activity *Activity) error
+	Close() error
+}
+
+type Preferences struct {
+	UserID   string
+	Language string
+	Timezone string
+
+	// MessageRetentionDays is how long the user wants their messages kept.
+	// Zero leaves it to chat-service's default.
+	MessageRetentionDays int
+}
+
+// Activity is something a user did in chat-service, reported to
+// user-service's analytics. EventID makes reporting it again harmless.
+type Activity struct {
+	EventID   string
+	UserID    string
+	Type      string
+	SessionID string
+	Metadata  map[string]string
+}
@@ -0,0 +1,90 @@
+package grpcclient
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/Sourav01112/chat-service/internal/config"
+	"github.com/Sourav01112/chat-service/internal/users"
+	userpb "github.com/Sourav01112/chat-service/proto/user"
+)
+
+type userClient struct {
+	conn    *grpc.ClientConn
+	client  userpb.UserServiceClient
+	timeout time.Duration
+	log     *zap.Logger
+}
+
+func NewUserClient(cfg *config.Config, log *zap.Logger) (users.Client, error) {
+	conn, err := grpc.NewClient(cfg.UserServiceURL,
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to user service: %w", err)
+	}
+
+	log.Info("User service client created", zap.String("url", cfg.UserServiceURL))
+
+	return &userClient{
+		conn:    conn,
+		client:  userpb.NewUserServiceClient(conn),
+		timeout: cfg.UserServiceTimeout,
+		log:     log,
+	}, nil
+}
+
+func (c *userClient) GetPreferences(ctx context.Context, userID string) (*users.Preferences, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := c.client.GetPreferences(ctx, &userpb.GetPreferencesRequest{UserId: userID})
+	if err != nil {
+		c.log.Error("User service GetPreferences failed",
+			zap.Error(err),
+			zap.String("user_id", userID))
+		return nil, fmt.Errorf("failed to get preferences: %w", err)
+	}
+
+	if !resp.Success || resp.Preferences == nil {
+		return nil, fmt.Errorf("user service error: %s", resp.Error)
+	}
+
+	return &users.Preferences{
+		UserID:   resp.Preferences.UserId,
+		Language: resp.Preferences.Language,
+		Timezone: resp.Preferences.Timezone,
+
+		MessageRetentionDays: int(resp.Preferences.MessageRetentionDays),
+	}, nil
+}
+
+func (c *userClient) RecordActivity(ctx context.Context, activity *users.Activity) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	_, err := c.client.RecordActivity(ctx, &userpb.RecordActivityRequest{
+		UserId:       activity.UserID,
+		ActivityType: activity.Type,
+		Metadata:     activity.Metadata,
+		SessionId:    activity.SessionID,
+		EventId:      activity.EventID,
+	})
+	if err != nil {
+		c.log.Warn("User service RecordActivity failed",
+			zap.Error(err),
+			zap.String("event_id", activity.EventID),
+			zap.String("user_id", activity.UserID))
+		return fmt.Errorf("failed to record activity: %w", err)
+	}
+
+	return nil
+}
+
+func (c *userClient) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"context"
+
+	"github.com/Sourav01112/chat-service/internal/config"
+	"github.com/Sourav01112/chat-service/internal/repository"
+)
+
+// IdempotencyJob deletes idempotency keys whose window has passed.
+type IdempotencyJob struct {
+	idempotencyRepo repository.IdempotencyRepository
+	config          *config.Config
+}
+
+func NewIdempotencyJob(idempotencyRepo repository.IdempotencyRepository, config *config.Config) *IdempotencyJob {
+	return &IdempotencyJob{
+		idempotencyRepo: idempotencyRepo,
+		config:          config,
+	}
+}
+
+func (j *IdempotencyJob) Name() string {
+	return "expire_idempotency_keys"
+}
+
+func (j *IdempotencyJob) Run(ctx context.Context, dryRun bool) (Result, error) {
+	result := Result{}
+
+	if dryRun {
+		keys, err := j.idempotencyRepo.CountExpired(ctx)
+		result["keys"] = keys
+		return result, err
+	}
+
+	keys, err := inBatches(ctx, j.config.RetentionBatchSize, func(limit int) (int64, error) {
+		return j.idempotencyRepo.PurgeExpired(ctx, limit)
+	})
+	result["keys"] = keys
+
+	return result, err
+}
@@ -0,0 +1,63 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Sourav01112/chat-service/internal/repository"
+)
+
+// withLock calls fn if this replica can take the named lock, and does
+// nothing otherwise. The lock is extended while fn runs; if it is lost, the
+// context given to fn is cancelled so two replicas never work at once.
+func withLock(ctx context.Context, lockRepo repository.LockRepository, log *zap.Logger, name string, ttl time.Duration, fn func(ctx context.Context)) {
+	token, acquired, err := lockRepo.Acquire(ctx, name, ttl)
+	if err != nil {
+		log.Warn("Failed to take worker lock", zap.Error(err), zap.String("lock", name))
+		return
+	}
+	if !acquired {
+		log.Debug("Another replica holds the worker lock", zap.String("lock", name))
+		return
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	defer func() {
+		if err := lockRepo.Release(context.WithoutCancel(ctx), name, token); err != nil {
+			log.Warn("Failed to release worker lock", zap.Error(err), zap.String("lock", name))
+		}
+	}()
+
+	go keepLock(runCtx, cancel, lockRepo, log, name, token, ttl)
+
+	fn(runCtx)
+}
+
+// keepLock extends the lock until ctx is done, calling cancel if the lock is
+// lost.
+func keepLock(ctx context.Context, cancel context.CancelFunc, lockRepo repository.LockRepository, log *zap.Logger, name string, token string, ttl time.Duration) {
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		extended, err := lockRepo.Extend(ctx, name, token, ttl)
+		if err != nil && ctx.Err() != nil {
+			return
+		}
+		if err != nil || !extended {
+			log.Warn("Lost worker lock; stopping", zap.Error(err), zap.String("lock", name))
+			cancel()
+			return
+		}
+	}
+}
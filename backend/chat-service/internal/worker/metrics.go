@@ -0,0 +1,43 @@
+package worker
+
+import (
+	"expvar"
+	"time"
+)
+
+// jobMetrics is published at /debug/vars under "jobs". For each job it keeps
+// "<job>.runs", "<job>.failures", "<job>.last_run_unix" and
+// "<job>.last_duration_ms", plus "<job>.<kind>" totals of what real runs
+// changed and "<job>.dry_run.<kind>" totals of what dry runs would have.
+var jobMetrics = expvar.NewMap("jobs")
+
+func recordRun(job string, result Result, err error, dryRun bool, took time.Duration) {
+	jobMetrics.Add(job+".runs", 1)
+	if err != nil {
+		jobMetrics.Add(job+".failures", 1)
+	}
+
+	lastRun := new(expvar.Int)
+	lastRun.Set(time.Now().Unix())
+	jobMetrics.Set(job+".last_run_unix", lastRun)
+
+	lastDuration := new(expvar.Int)
+	lastDuration.Set(took.Milliseconds())
+	jobMetrics.Set(job+".last_duration_ms", lastDuration)
+
+	prefix := job + "."
+	if dryRun {
+		prefix += "dry_run."
+	}
+	for kind, count := range result {
+		jobMetrics.Add(prefix+kind, count)
+	}
+}
+
+// outboxMetrics is published at /debug/vars under "outbox". It counts
+// "<type>.delivered", "<type>.retried" and "<type>.failed" outbox events.
+var outboxMetrics = expvar.NewMap("outbox")
+
+func recordDelivery(eventType string, outcome string) {
+	outboxMetrics.Add(eventType+"."+outcome, 1)
+}
@@ -0,0 +1,208 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Sourav01112/chat-service/internal/config"
+	"github.com/Sourav01112/chat-service/internal/models"
+	"github.com/Sourav01112/chat-service/internal/repository"
+	"github.com/Sourav01112/chat-service/internal/users"
+)
+
+// outboxLock elects the replica that relays the outbox. A single relay is
+// what keeps each session's events in order.
+const outboxLock = "worker:outbox"
+
+// Failed deliveries are retried after outboxMinBackoff, doubling up to
+// outboxMaxBackoff.
+const (
+	outboxMinBackoff = time.Second
+	outboxMaxBackoff = 5 * time.Minute
+)
+
+// OutboxRelay delivers outbox events to user-service's analytics. Delivery
+// is at least once: an event is marked delivered only after user-service
+// took it, and user-service ignores event IDs it has seen. Within a session
+// events go out in the order they were written; when one fails, the
+// session's later events wait for it.
+type OutboxRelay struct {
+	outboxRepo repository.OutboxRepository
+	lockRepo   repository.LockRepository
+	userClient users.Client
+	config     *config.Config
+	log        *zap.Logger
+}
+
+func NewOutboxRelay(outboxRepo repository.OutboxRepository, lockRepo repository.LockRepository, userClient users.Client, config *config.Config, log *zap.Logger) *OutboxRelay {
+	return &OutboxRelay{
+		outboxRepo: outboxRepo,
+		lockRepo:   lockRepo,
+		userClient: userClient,
+		config:     config,
+		log:        log,
+	}
+}
+
+// Start relays the outbox every OutboxPollInterval until ctx is done. An
+// OutboxPollInterval of zero turns the relay off.
+func (r *OutboxRelay) Start(ctx context.Context) {
+	if r.config.OutboxPollInterval <= 0 {
+		r.log.Info("Outbox relay disabled")
+		return
+	}
+
+	r.log.Info("Outbox relay starting",
+		zap.Duration("interval", r.config.OutboxPollInterval),
+		zap.Int("batch_size", r.config.OutboxBatchSize))
+
+	ticker := time.NewTicker(r.config.OutboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		withLock(ctx, r.lockRepo, r.log, outboxLock, r.config.OutboxLockTTL, r.RelayOnce)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayOnce delivers batches of due events until none are left.
+func (r *OutboxRelay) RelayOnce(ctx context.Context) {
+	batchSize := r.config.OutboxBatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+
+	for ctx.Err() == nil {
+		events, err := r.outboxRepo.GetPending(ctx, batchSize)
+		if err != nil {
+			r.log.Warn("Failed to read outbox", zap.Error(err))
+			return
+		}
+
+		delivered := r.deliver(ctx, events)
+		if len(events) < batchSize || delivered == 0 {
+			return
+		}
+	}
+}
+
+// deliver sends events in order and returns how many user-service took.
+// Once one of a session's events fails, its later ones are left for the
+// next round.
+func (r *OutboxRelay) deliver(ctx context.Context, events []*models.OutboxEvent) int {
+	blocked := make(map[string]bool)
+	delivered := 0
+
+	for _, event := range events {
+		if ctx.Err() != nil {
+			break
+		}
+		if blocked[event.SessionID] {
+			continue
+		}
+
+		err := r.userClient.RecordActivity(ctx, &users.Activity{
+			EventID:   event.EventID,
+			UserID:    event.UserID,
+			Type:      event.Type,
+			SessionID: event.SessionID,
+			Metadata:  event.Payload,
+		})
+		if err != nil {
+			blocked[event.SessionID] = true
+			r.retryLater(ctx, event, err)
+			continue
+		}
+
+		if err := r.outboxRepo.MarkDelivered(ctx, event.ID); err != nil {
+			// user-service has it; it will be sent again and ignored.
+			blocked[event.SessionID] = true
+			continue
+		}
+		recordDelivery(event.Type, "delivered")
+		delivered++
+	}
+
+	return delivered
+}
+
+// retryLater records a failed delivery and schedules the next attempt, or
+// gives the event up after OutboxMaxAttempts.
+func (r *OutboxRelay) retryLater(ctx context.Context, event *models.OutboxEvent, cause error) {
+	event.Attempts++
+	event.LastError = cause.Error()
+	event.NextAttemptAt = time.Now().Add(outboxBackoff(event.Attempts))
+
+	outcome := "retried"
+	if r.config.OutboxMaxAttempts > 0 && event.Attempts >= r.config.OutboxMaxAttempts {
+		now := time.Now()
+		event.FailedAt = &now
+		outcome = "failed"
+
+		r.log.Error("Giving up on outbox event",
+			zap.Error(cause),
+			zap.String("event_id", event.EventID),
+			zap.String("type", event.Type),
+			zap.String("session_id", event.SessionID),
+			zap.Int("attempts", event.Attempts))
+	}
+
+	if err := r.outboxRepo.MarkFailed(context.WithoutCancel(ctx), event); err != nil {
+		return
+	}
+	recordDelivery(event.Type, outcome)
+}
+
+func outboxBackoff(attempts int) time.Duration {
+	backoff := outboxMinBackoff
+	for i := 1; i < attempts && backoff < outboxMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > outboxMaxBackoff {
+		backoff = outboxMaxBackoff
+	}
+	return backoff
+}
+
+// OutboxJob deletes outbox events delivered longer than OutboxRetention ago.
+// Events that were given up on are kept for inspection.
+type OutboxJob struct {
+	outboxRepo repository.OutboxRepository
+	config     *config.Config
+}
+
+func NewOutboxJob(outboxRepo repository.OutboxRepository, config *config.Config) *OutboxJob {
+	return &OutboxJob{
+		outboxRepo: outboxRepo,
+		config:     config,
+	}
+}
+
+func (j *OutboxJob) Name() string {
+	return "purge_outbox"
+}
+
+func (j *OutboxJob) Run(ctx context.Context, dryRun bool) (Result, error) {
+	cutoff := time.Now().Add(-j.config.OutboxRetention)
+	result := Result{}
+
+	if dryRun {
+		events, err := j.outboxRepo.CountDelivered(ctx, cutoff)
+		result["events"] = events
+		return result, err
+	}
+
+	events, err := inBatches(ctx, j.config.RetentionBatchSize, func(limit int) (int64, error) {
+		return j.outboxRepo.PurgeDelivered(ctx, cutoff, limit)
+	})
+	result["events"] = events
+
+	return result, err
+}
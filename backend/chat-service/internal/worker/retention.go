@@ -0,0 +1,189 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Sourav01112/chat-service/internal/config"
+	"github.com/Sourav01112/chat-service/internal/models"
+	"github.com/Sourav01112/chat-service/internal/repository"
+	"github.com/Sourav01112/chat-service/internal/users"
+)
+
+// IdleSessionJob pauses sessions with no activity for RetentionPauseAfter
+// and archives those idle for RetentionArchiveAfter. Archived sessions are
+// kept until their owner deletes them; only the trash is purged. Either step
+// is skipped when its age is zero.
+type IdleSessionJob struct {
+	sessionRepo repository.SessionRepository
+	cacheRepo   repository.CacheRepository
+	config      *config.Config
+}
+
+func NewIdleSessionJob(sessionRepo repository.SessionRepository, cacheRepo repository.CacheRepository, config *config.Config) *IdleSessionJob {
+	return &IdleSessionJob{
+		sessionRepo: sessionRepo,
+		cacheRepo:   cacheRepo,
+		config:      config,
+	}
+}
+
+func (j *IdleSessionJob) Name() string {
+	return "idle_sessions"
+}
+
+func (j *IdleSessionJob) Run(ctx context.Context, dryRun bool) (Result, error) {
+	now := time.Now()
+	result := Result{}
+
+	// Archive first, so a session idle past both ages goes straight to
+	// archived instead of being paused on the way.
+	steps := []struct {
+		kind  string
+		after time.Duration
+		from  []models.SessionStatus
+		to    models.SessionStatus
+	}{
+		{"archived", j.config.RetentionArchiveAfter, []models.SessionStatus{models.SessionStatusActive, models.SessionStatusPaused}, models.SessionStatusArchived},
+		{"paused", j.config.RetentionPauseAfter, []models.SessionStatus{models.SessionStatusActive}, models.SessionStatusPaused},
+	}
+
+	for _, step := range steps {
+		if step.after <= 0 {
+			continue
+		}
+		idleSince := now.Add(-step.after)
+
+		if dryRun {
+			count, err := j.sessionRepo.CountIdle(ctx, step.from, idleSince)
+			if err != nil {
+				return result, err
+			}
+			result[step.kind] = count
+			continue
+		}
+
+		count, err := inBatches(ctx, j.config.RetentionBatchSize, func(limit int) (int64, error) {
+			ids, err := j.sessionRepo.SetIdleStatus(ctx, step.from, idleSince, step.to, limit)
+			for _, id := range ids {
+				_ = j.cacheRepo.InvalidateSessionCache(ctx, id)
+			}
+			return int64(len(ids)), err
+		})
+		result[step.kind] = count
+		if err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// MessageRetentionJob permanently deletes messages older than their session
+// owner's retention window. The window is the owner's message retention
+// preference in user-service when set, and RetentionMessageMaxAge otherwise;
+// owners with neither keep their messages.
+type MessageRetentionJob struct {
+	sessionRepo repository.SessionRepository
+	messageRepo repository.MessageRepository
+	cacheRepo   repository.CacheRepository
+	userClient  users.Client
+	config      *config.Config
+	log         *zap.Logger
+}
+
+func NewMessageRetentionJob(sessionRepo repository.SessionRepository, messageRepo repository.MessageRepository, cacheRepo repository.CacheRepository, userClient users.Client, config *config.Config, log *zap.Logger) *MessageRetentionJob {
+	return &MessageRetentionJob{
+		sessionRepo: sessionRepo,
+		messageRepo: messageRepo,
+		cacheRepo:   cacheRepo,
+		userClient:  userClient,
+		config:      config,
+		log:         log,
+	}
+}
+
+func (j *MessageRetentionJob) Name() string {
+	return "expire_messages"
+}
+
+func (j *MessageRetentionJob) Run(ctx context.Context, dryRun bool) (Result, error) {
+	now := time.Now()
+	result := Result{}
+
+	batchSize := j.config.RetentionBatchSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	after := ""
+	for {
+		owners, err := j.sessionRepo.GetOwnerIDs(ctx, after, batchSize)
+		if err != nil {
+			return result, err
+		}
+
+		for _, ownerID := range owners {
+			if err := ctx.Err(); err != nil {
+				return result, err
+			}
+
+			window, err := j.window(ctx, ownerID)
+			if err != nil {
+				// Without the owner's preference we cannot tell whether
+				// they asked to keep messages longer, so leave them be.
+				j.log.Warn("Skipping message retention for user",
+					zap.Error(err),
+					zap.String("user_id", ownerID))
+				result["skipped_users"]++
+				continue
+			}
+			if window <= 0 {
+				continue
+			}
+
+			count, err := j.expire(ctx, ownerID, now.Add(-window), dryRun)
+			result["messages"] += count
+			if err != nil {
+				return result, err
+			}
+			if count > 0 {
+				result["users"]++
+			}
+		}
+
+		if len(owners) < batchSize {
+			return result, nil
+		}
+		after = owners[len(owners)-1]
+	}
+}
+
+// window returns how long ownerID's messages are kept, or zero to keep them
+// forever.
+func (j *MessageRetentionJob) window(ctx context.Context, ownerID string) (time.Duration, error) {
+	preferences, err := j.userClient.GetPreferences(ctx, ownerID)
+	if err != nil {
+		return 0, err
+	}
+	if preferences.MessageRetentionDays > 0 {
+		return time.Duration(preferences.MessageRetentionDays) * 24 * time.Hour, nil
+	}
+	return j.config.RetentionMessageMaxAge, nil
+}
+
+func (j *MessageRetentionJob) expire(ctx context.Context, ownerID string, cutoff time.Time, dryRun bool) (int64, error) {
+	if dryRun {
+		return j.messageRepo.CountOlderForOwner(ctx, ownerID, cutoff)
+	}
+
+	return inBatches(ctx, j.config.RetentionBatchSize, func(limit int) (int64, error) {
+		deleted, sessionIDs, err := j.messageRepo.PurgeOlderForOwner(ctx, ownerID, cutoff, limit)
+		for _, id := range sessionIDs {
+			_ = j.cacheRepo.InvalidateSessionCache(ctx, id)
+		}
+		return deleted, err
+	})
+}
@@ -0,0 +1,135 @@
+// Package worker runs chat-service's background jobs.
+package worker
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Sourav01112/chat-service/internal/config"
+	"github.com/Sourav01112/chat-service/internal/repository"
+)
+
+// schedulerLock elects the replica that runs the jobs. Only one replica
+// holds it at a time, so jobs never run twice concurrently.
+const schedulerLock = "worker:scheduler"
+
+// Result counts what one job run changed, or would have changed in dry-run
+// mode, by kind of item.
+type Result map[string]int64
+
+// Job is one piece of periodic maintenance. With dryRun set it only counts
+// what it would change.
+type Job interface {
+	Name() string
+	Run(ctx context.Context, dryRun bool) (Result, error)
+}
+
+// Scheduler runs its jobs every RetentionInterval on whichever replica wins
+// the scheduler lock. The lock is kept alive while the jobs run; if it is
+// lost, the run stops so two replicas never work at once.
+type Scheduler struct {
+	lockRepo repository.LockRepository
+	jobs     []Job
+	config   *config.Config
+	log      *zap.Logger
+}
+
+func NewScheduler(lockRepo repository.LockRepository, config *config.Config, log *zap.Logger, jobs ...Job) *Scheduler {
+	return &Scheduler{
+		lockRepo: lockRepo,
+		jobs:     jobs,
+		config:   config,
+		log:      log,
+	}
+}
+
+// Start runs the jobs right away and then every RetentionInterval until ctx
+// is done. A RetentionInterval of zero turns the scheduler off.
+func (s *Scheduler) Start(ctx context.Context) {
+	if s.config.RetentionInterval <= 0 {
+		s.log.Info("Job scheduler disabled")
+		return
+	}
+
+	s.log.Info("Job scheduler starting",
+		zap.Duration("interval", s.config.RetentionInterval),
+		zap.Bool("dry_run", s.config.RetentionDryRun),
+		zap.Int("jobs", len(s.jobs)))
+
+	ticker := time.NewTicker(s.config.RetentionInterval)
+	defer ticker.Stop()
+
+	for {
+		s.RunOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce runs every job once if this replica can take the scheduler lock,
+// and does nothing otherwise.
+func (s *Scheduler) RunOnce(ctx context.Context) {
+	withLock(ctx, s.lockRepo, s.log, schedulerLock, s.config.RetentionLockTTL, func(ctx context.Context) {
+		for _, job := range s.jobs {
+			if ctx.Err() != nil {
+				break
+			}
+			s.run(ctx, job)
+		}
+	})
+}
+
+func (s *Scheduler) run(ctx context.Context, job Job) {
+	dryRun := s.config.RetentionDryRun
+	started := time.Now()
+
+	result, err := job.Run(ctx, dryRun)
+	took := time.Since(started)
+
+	recordRun(job.Name(), result, err, dryRun, took)
+
+	fields := []zap.Field{
+		zap.String("job", job.Name()),
+		zap.Bool("dry_run", dryRun),
+		zap.Duration("took", took),
+	}
+	for kind, count := range result {
+		fields = append(fields, zap.Int64(kind, count))
+	}
+
+	if err != nil {
+		s.log.Error("Scheduled job failed", append(fields, zap.Error(err))...)
+		return
+	}
+	s.log.Info("Scheduled job finished", fields...)
+}
+
+// inBatches calls apply until a batch comes back short, so a large backlog
+// never holds one long-running statement. It returns the total applied.
+func inBatches(ctx context.Context, batchSize int, apply func(limit int) (int64, error)) (int64, error) {
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	var total int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+
+		applied, err := apply(batchSize)
+		total += applied
+		if err != nil {
+			return total, err
+		}
+		if applied < int64(batchSize) {
+			return total, nil
+		}
+	}
+}
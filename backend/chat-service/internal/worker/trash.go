@@ -0,0 +1,65 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/Sourav01112/chat-service/internal/config"
+	"github.com/Sourav01112/chat-service/internal/repository"
+)
+
+// TrashJob permanently deletes sessions and messages that have been in the
+// trash longer than TrashRetention.
+type TrashJob struct {
+	sessionRepo repository.SessionRepository
+	messageRepo repository.MessageRepository
+	config      *config.Config
+}
+
+func NewTrashJob(sessionRepo repository.SessionRepository, messageRepo repository.MessageRepository, config *config.Config) *TrashJob {
+	return &TrashJob{
+		sessionRepo: sessionRepo,
+		messageRepo: messageRepo,
+		config:      config,
+	}
+}
+
+func (j *TrashJob) Name() string {
+	return "purge_trash"
+}
+
+func (j *TrashJob) Run(ctx context.Context, dryRun bool) (Result, error) {
+	cutoff := time.Now().Add(-j.config.TrashRetention)
+	result := Result{}
+
+	if dryRun {
+		sessions, err := j.sessionRepo.CountTrashed(ctx, cutoff)
+		if err != nil {
+			return result, err
+		}
+		result["sessions"] = sessions
+
+		messages, err := j.messageRepo.CountDeleted(ctx, cutoff)
+		if err != nil {
+			return result, err
+		}
+		result["messages"] = messages
+
+		return result, nil
+	}
+
+	sessions, err := inBatches(ctx, j.config.RetentionBatchSize, func(limit int) (int64, error) {
+		return j.sessionRepo.PurgeTrashed(ctx, cutoff, limit)
+	})
+	result["sessions"] = sessions
+	if err != nil {
+		return result, err
+	}
+
+	messages, err := inBatches(ctx, j.config.RetentionBatchSize, func(limit int) (int64, error) {
+		return j.messageRepo.PurgeDeleted(ctx, cutoff, limit)
+	})
+	result["messages"] = messages
+
+	return result, err
+}
@@ -0,0 +1,5 @@
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'complete'
+    CHECK (status IN ('complete', 'streaming', 'incomplete'));
+
+CREATE INDEX idx_messages_status ON messages(status) WHERE status != 'complete';
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_sessions_user_activity_id ON sessions(user_id, last_activity DESC, id DESC);
@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS idx_messages_session_type_order ON messages(session_id, type, order_index);
+CREATE INDEX IF NOT EXISTS idx_messages_metadata_tags ON messages USING gin ((metadata->'tags'));
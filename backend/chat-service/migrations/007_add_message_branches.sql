@@ -0,0 +1,25 @@
+ALTER TABLE sessions
+    ADD COLUMN IF NOT EXISTS active_leaf_id UUID REFERENCES messages(id) ON DELETE SET NULL;
+
+-- Messages written before branching only link assistant replies to their
+-- prompt. Chain every other message to the one before it so existing
+-- sessions read as a single branch.
+WITH ordered AS (
+    SELECT id, LAG(id) OVER (PARTITION BY session_id ORDER BY order_index, created_at) AS previous_id
+    FROM messages
+)
+UPDATE messages
+SET parent_message_id = ordered.previous_id
+FROM ordered
+WHERE messages.id = ordered.id
+  AND messages.parent_message_id IS NULL
+  AND ordered.previous_id IS NOT NULL;
+
+UPDATE sessions
+SET active_leaf_id = (
+    SELECT id FROM messages
+    WHERE messages.session_id = sessions.id
+    ORDER BY order_index DESC, created_at DESC
+    LIMIT 1
+)
+WHERE active_leaf_id IS NULL;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS session_summaries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    session_id UUID NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    through_message_id UUID NOT NULL,
+    through_order_index INTEGER NOT NULL,
+    content TEXT NOT NULL,
+    message_count INTEGER NOT NULL,
+    token_count INTEGER NOT NULL DEFAULT 0,
+    model_used VARCHAR(100),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    invalidated_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE (session_id, version)
+);
+
+CREATE INDEX idx_session_summaries_valid ON session_summaries(session_id, through_order_index DESC) WHERE invalidated_at IS NULL;
@@ -0,0 +1,7 @@
+ALTER TABLE sessions
+    ADD COLUMN IF NOT EXISTS title_source VARCHAR(20) NOT NULL DEFAULT 'user'
+    CHECK (title_source IN ('default', 'generated', 'user'));
+
+-- Sessions still carrying the placeholder the frontend used to send get a
+-- generated title after their next exchange.
+UPDATE sessions SET title_source = 'default' WHERE title = 'New chat';
@@ -0,0 +1,7 @@
+ALTER TABLE sessions
+    ADD COLUMN IF NOT EXISTS import_source VARCHAR(20),
+    ADD COLUMN IF NOT EXISTS import_external_id VARCHAR(255);
+
+-- Sessions created here leave both columns NULL, which never conflicts.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sessions_import
+    ON sessions(user_id, import_source, import_external_id);
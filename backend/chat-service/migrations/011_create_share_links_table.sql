@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS share_links (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    session_id UUID NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    token VARCHAR(64) NOT NULL UNIQUE,
+    mode VARCHAR(20) NOT NULL CHECK (mode IN ('snapshot', 'live')),
+    include_citations BOOLEAN NOT NULL DEFAULT FALSE,
+    snapshot JSONB,
+    view_count BIGINT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    last_viewed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_share_links_session_id ON share_links(session_id);
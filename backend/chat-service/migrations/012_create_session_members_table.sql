@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS session_members (
+    session_id UUID NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+    invited_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (session_id, user_id)
+);
+
+CREATE INDEX idx_session_members_user_id ON session_members(user_id);
+
+-- A session has exactly one owner.
+CREATE UNIQUE INDEX idx_session_members_owner ON session_members(session_id) WHERE role = 'owner';
+
+INSERT INTO session_members (session_id, user_id, role, created_at, updated_at)
+SELECT id, user_id, 'owner', created_at, created_at FROM sessions
+ON CONFLICT (session_id, user_id) DO NOTHING;
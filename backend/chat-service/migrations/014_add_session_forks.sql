@@ -0,0 +1,6 @@
+ALTER TABLE sessions
+    ADD COLUMN IF NOT EXISTS forked_from_session_id UUID REFERENCES sessions(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS forked_from_message_id UUID REFERENCES messages(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_sessions_forked_from ON sessions(forked_from_session_id);
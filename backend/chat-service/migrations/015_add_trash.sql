@@ -0,0 +1,15 @@
+ALTER TABLE sessions
+    ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP WITH TIME ZONE;
+
+-- Sessions archived before this migration start their retention period now.
+UPDATE sessions SET archived_at = NOW() WHERE status = 'archived' AND archived_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_sessions_archived_at ON sessions(archived_at) WHERE status = 'archived';
+
+-- Deleted messages stay in the trash until purged. reparented_ids lists the
+-- replies handed to the message's parent when it was deleted.
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS reparented_ids JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_messages_deleted_at ON messages(deleted_at) WHERE deleted_at IS NOT NULL;
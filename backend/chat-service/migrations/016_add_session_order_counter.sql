@@ -0,0 +1,41 @@
+-- last_order_index is the highest order_index handed out in the session.
+-- New messages take the next one by bumping it in their insert transaction.
+ALTER TABLE sessions
+    ADD COLUMN IF NOT EXISTS last_order_index INTEGER NOT NULL DEFAULT 0;
+
+-- Concurrent sends could give two messages of a session the same
+-- order_index. Renumber the sessions where that happened, keeping the
+-- existing order and breaking ties by creation time.
+CREATE TEMPORARY TABLE duplicate_order_sessions AS
+SELECT DISTINCT session_id
+FROM messages
+GROUP BY session_id, order_index
+HAVING COUNT(*) > 1;
+
+WITH renumbered AS (
+    SELECT messages.id,
+           ROW_NUMBER() OVER (PARTITION BY messages.session_id ORDER BY messages.order_index, messages.created_at, messages.id) AS order_index
+    FROM messages
+    JOIN duplicate_order_sessions ON duplicate_order_sessions.session_id = messages.session_id
+)
+UPDATE messages
+SET order_index = renumbered.order_index
+FROM renumbered
+WHERE messages.id = renumbered.id
+  AND messages.order_index <> renumbered.order_index;
+
+-- Summaries of a renumbered session may now end at the wrong message.
+UPDATE session_summaries
+SET invalidated_at = NOW()
+WHERE invalidated_at IS NULL
+  AND session_id IN (SELECT session_id FROM duplicate_order_sessions);
+
+DROP TABLE duplicate_order_sessions;
+
+UPDATE sessions
+SET last_order_index = COALESCE((
+    SELECT MAX(order_index) FROM messages WHERE messages.session_id = sessions.id
+), 0);
+
+DROP INDEX IF EXISTS idx_messages_order;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_messages_order ON messages(session_id, order_index);
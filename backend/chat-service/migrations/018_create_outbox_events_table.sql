@@ -0,0 +1,19 @@
+-- Events owed to user-service's analytics, written in the same transaction
+-- as the change they describe and delivered by the outbox relay.
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id BIGSERIAL PRIMARY KEY,
+    event_id UUID NOT NULL UNIQUE,
+    session_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    payload JSONB DEFAULT '{}',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    failed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON outbox_events(session_id, id) WHERE delivered_at IS NULL AND failed_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_events_delivered_at ON outbox_events(delivered_at) WHERE delivered_at IS NOT NULL;
@@ -0,0 +1,10 @@
+-- Archived no longer means deleted: idle session retention archives sessions
+-- too, and those must never be purged. Only sessions deleted from now on go
+-- to the trash. Sessions already archived cannot be told apart, so they are
+-- all kept; deleting one again puts it in the trash.
+ALTER TABLE sessions
+    ADD COLUMN IF NOT EXISTS trashed_at TIMESTAMP WITH TIME ZONE;
+
+DROP INDEX IF EXISTS idx_sessions_archived_at;
+
+CREATE INDEX IF NOT EXISTS idx_sessions_trashed_at ON sessions(trashed_at) WHERE trashed_at IS NOT NULL;
@@ -36,6 +36,34 @@ protoc --go_out=proto \
        --proto_path=proto \
        proto/chat_service.proto
 
+if [ -f "proto/ai/ai_service.proto" ]; then
+    echo "🔧 Generating AI service client stubs..."
+    protoc --go_out=proto \
+           --go_opt=paths=source_relative \
+           --go-grpc_out=proto \
+           --go-grpc_opt=paths=source_relative \
+           --proto_path=proto \
+           proto/ai/ai_service.proto
+else
+    echo "❌ AI service proto not found at: $(pwd)/proto/ai/ai_service.proto"
+    echo "   Copy ai_service.proto from the ai-service with go_package \"github.com/Sourav01112/chat-service/proto/ai;aipb\""
+    exit 1
+fi
+
+if [ -f "proto/user/user_service.proto" ]; then
+    echo "🔧 Generating user service client stubs..."
+    protoc --go_out=proto \
+           --go_opt=paths=source_relative \
+           --go-grpc_out=proto \
+           --go-grpc_opt=paths=source_relative \
+           --proto_path=proto \
+           proto/user/user_service.proto
+else
+    echo "❌ User service proto not found at: $(pwd)/proto/user/user_service.proto"
+    echo "   Copy user_service.proto from the user-service with go_package \"github.com/Sourav01112/chat-service/proto/user;userpb\""
+    exit 1
+fi
+
 if [ -f "proto/chat_service.pb.go" ] && [ -f "proto/chat_service_grpc.pb.go" ]; then
     echo "✅ Proto files generated successfully"
     echo "   📄 Generated: proto/chat_service.pb.go"
@@ -59,6 +59,7 @@ func preferencesToProto(prefs *models.UserPreferences) *pb.UserPreferences {
 		AiPreferences:        aiPrefs,
 		ProfileVisibility:    prefs.ProfileVisibility,
 		DataSharing:          prefs.DataSharing,
+		MessageRetentionDays: int32(prefs.MessageRetentionDays),
 		CreatedAt:            timestamppb.New(prefs.CreatedAt),
 		UpdatedAt:            timestamppb.New(prefs.UpdatedAt),
 	}
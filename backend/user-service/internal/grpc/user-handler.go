@@ -180,6 +180,7 @@ func (s *Server) UpdatePreferences(ctx context.Context, req *pb.UpdatePreference
 		PushNotifications:    req.Preferences.PushNotifications,
 		ProfileVisibility:    req.Preferences.ProfileVisibility,
 		DataSharing:          req.Preferences.DataSharing,
+		MessageRetentionDays: int(req.Preferences.MessageRetentionDays),
 	}
 
 	if req.Preferences.AiPreferences != nil {
@@ -240,6 +241,7 @@ func (s *Server) RecordActivity(ctx context.Context, req *pb.RecordActivityReque
 		IPAddress:    req.IpAddress,
 		UserAgent:    req.UserAgent,
 		SessionID:    req.SessionId,
+		EventID:      req.EventId,
 	}
 
 	err := s.userService.RecordActivity(ctx, serviceReq)
@@ -28,6 +28,10 @@ type UserAnalytics struct {
 	UserAgent string  `gorm:"size:500" json:"user_agent"`
 	SessionID string  `gorm:"size:100" json:"session_id"`
 
+	// EventID identifies activities reported by other services, which may
+	// report the same one more than once.
+	EventID *string `gorm:"type:uuid;uniqueIndex" json:"event_id,omitempty"`
+
 	CreatedAt time.Time `gorm:"index" json:"created_at"`
 
 	User User `gorm:"foreignKey:UserID;references:ID" json:"-"`
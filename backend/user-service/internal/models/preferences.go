@@ -24,6 +24,10 @@ type UserPreferences struct {
 	ProfileVisibility string `gorm:"size:20;default:'public'" json:"profile_visibility"` // public, private, friends
 	DataSharing       bool   `gorm:"default:false" json:"data_sharing"`
 
+	// MessageRetentionDays is how long chat-service keeps the user's
+	// messages; 0 leaves it to chat-service's default.
+	MessageRetentionDays int `gorm:"default:0" json:"message_retention_days"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 
@@ -7,6 +7,7 @@ import (
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/Sourav01112/user-service/internal/models"
 )
@@ -30,8 +31,18 @@ func NewAnalyticsRepository(db *gorm.DB, log *zap.Logger) AnalyticsRepository {
 	}
 }
 
+// RecordActivityRepo stores an activity. One whose EventID was already
+// recorded is skipped.
 func (r *analyticsRepository) RecordActivityRepo(ctx context.Context, activity *models.UserAnalytics) error {
-	if err := r.db.WithContext(ctx).Create(activity).Error; err != nil {
+	db := r.db.WithContext(ctx)
+	if activity.EventID != nil {
+		db = db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "event_id"}},
+			DoNothing: true,
+		})
+	}
+
+	if err := db.Create(activity).Error; err != nil {
 		r.log.Error("Failed to record user activity",
 			zap.Error(err),
 			zap.String("user_id", activity.UserID),
@@ -68,6 +79,17 @@ func (r *analyticsRepository) GetUserStats(ctx context.Context, userID string, f
 		return nil, fmt.Errorf("failed to count messages: %w", err)
 	}
 
+	// Get tokens used, as reported with each message by chat-service
+	err = r.db.WithContext(ctx).
+		Model(&models.UserAnalytics{}).
+		Select("COALESCE(SUM((metadata->>'token_count')::bigint), 0)").
+		Where("user_id = ? AND activity_type = ? AND created_at BETWEEN ? AND ? AND metadata->>'token_count' ~ '^[0-9]+$'",
+			userID, models.ActivityMessageSent, fromDate, toDate).
+		Scan(&stats.TokensUsed).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum tokens used: %w", err)
+	}
+
 	// Get last activity
 	var lastActivity models.UserAnalytics
 	err = r.db.WithContext(ctx).
@@ -81,6 +81,7 @@ type UpdatePreferencesRequest struct {
 	AIPreferences        models.AIPreferences `json:"ai_preferences"`
 	ProfileVisibility    string               `json:"profile_visibility" validate:"oneof=public private friends"`
 	DataSharing          bool                 `json:"data_sharing"`
+	MessageRetentionDays int                  `json:"message_retention_days" validate:"min=0,max=3650"`
 }
 
 type RecordActivityRequest struct {
@@ -90,6 +91,7 @@ type RecordActivityRequest struct {
 	IPAddress    string            `json:"ip_address"`
 	UserAgent    string            `json:"user_agent"`
 	SessionID    string            `json:"session_id"`
+	EventID      string            `json:"event_id"`
 }
 
 func NewUserService(
@@ -364,6 +366,7 @@ func (s *userService) UpdatePreferences(ctx context.Context, req *UpdatePreferen
 	preferences.AIPreferences = req.AIPreferences
 	preferences.ProfileVisibility = req.ProfileVisibility
 	preferences.DataSharing = req.DataSharing
+	preferences.MessageRetentionDays = req.MessageRetentionDays
 
 	if err := s.prefsRepo.Update(ctx, preferences); err != nil {
 		return nil, fmt.Errorf("failed to update preferences: %w", err)
@@ -417,11 +420,15 @@ func (s *userService) RecordActivity(ctx context.Context, req *RecordActivityReq
 		SessionID:    req.SessionID,
 		CreatedAt:    time.Now(),
 	}
+	if req.EventID != "" {
+		activity.EventID = &req.EventID
+	}
 
 	fmt.Printf("<<<<< activity: %+v\n", activity)
 
 	if err := s.analyticsRepo.RecordActivityRepo(ctx, activity); err != nil {
 		s.log.Error("Failed to record activity", zap.Error(err))
+		return err
 	}
 
 	return nil
@@ -0,0 +1,3 @@
+ALTER TABLE user_preferences
+    ADD COLUMN IF NOT EXISTS message_retention_days INTEGER NOT NULL DEFAULT 0
+        CHECK (message_retention_days >= 0 AND message_retention_days <= 3650);
@@ -0,0 +1,6 @@
+-- Activities reported by other services carry an event_id so that a
+-- delivery retried after a timeout is only counted once.
+ALTER TABLE user_analytics
+    ADD COLUMN IF NOT EXISTS event_id UUID;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_analytics_event_id ON user_analytics(event_id);